@@ -0,0 +1,98 @@
+package vl53l0x
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Acquisition is long-running measurement stream of single sensor, published
+// to Hub. It has Start/Wait/Run lifecycle compatible with errgroup package,
+// so multi-sensor services supervise all acquisition goroutines uniformly:
+//
+//	g, ctx := errgroup.WithContext(ctx)
+//	for _, a := range acquisitions {
+//		g.Go(func() error { return a.Run(ctx) })
+//	}
+//	err := g.Wait()
+//
+// Acquisition failure of any sensor is returned by Run, which cancels
+// group context, so all others stop and leave continuous mode.
+type Acquisition struct {
+	sync.Mutex
+	sensor *Vl53l0x
+	i2c    Bus
+	period time.Duration
+	hub    *Hub
+	// closed, when acquisition is over; nil, if not started
+	done chan struct{}
+	// acquisition error, set before done is closed
+	err error
+}
+
+// NewAcquisition creates acquisition of sensor in continuous mode with
+// inter-measurement period given (0 stands for back-to-back mode).
+func NewAcquisition(sensor *Vl53l0x, i2c Bus, period time.Duration) *Acquisition {
+	v := &Acquisition{sensor: sensor, i2c: i2c, period: period, hub: NewHub()}
+	return v
+}
+
+// Hub returns hub measurements are published to. Subscribe before Start,
+// to get the first measurements. Hub is closed, when acquisition is over.
+func (v *Acquisition) Hub() *Hub {
+	return v.hub
+}
+
+// Start continuous mode and return, leaving acquisition in background goroutine,
+// until context is done or acquisition fails. Returns error, if continuous
+// mode can't be started or acquisition was started already.
+func (v *Acquisition) Start(ctx context.Context) error {
+	v.Lock()
+	defer v.Unlock()
+	if v.done != nil {
+		return errors.New("acquisition is started already")
+	}
+	ch, err := v.sensor.Stream(ctx, v.i2c, v.period)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	v.done = done
+	go func() {
+		defer close(done)
+		defer v.hub.Close()
+		for m := range ch {
+			if m.Err != nil {
+				v.err = m.Err
+				continue
+			}
+			v.hub.Publish(m)
+		}
+	}()
+	return nil
+}
+
+// Wait blocks until acquisition is over and continuous mode left. Returns
+// acquisition error, or nil, if it's stopped by context. Returns error
+// immediately, if acquisition wasn't started.
+func (v *Acquisition) Wait() error {
+	v.Lock()
+	done := v.done
+	v.Unlock()
+	if done == nil {
+		return errors.New("acquisition isn't started")
+	}
+	<-done
+	return v.err
+}
+
+// Run start acquisition and block until it's over, combining
+// Start and Wait. Returns nil, once context is done.
+func (v *Acquisition) Run(ctx context.Context) error {
+	err := v.Start(ctx)
+	if err != nil {
+		return err
+	}
+	return v.Wait()
+}
@@ -0,0 +1,149 @@
+package vl53l0x
+
+// Default thresholds of AdaptiveRange.
+const (
+	defaultAdaptiveSwitchAfter = 3
+	defaultAdaptiveReturnBelow = 800
+)
+
+// AdaptiveRange switches sensor between RegularRange and LongRange
+// automatically: it starts in RegularRange and reconfigures sensor to
+// LongRange when several consecutive readings come back out-of-range or
+// with weak signal, and back to RegularRange when target is close again.
+// VCSEL periods change is followed by phase re-calibration and timing
+// budget recalculation, performed by SetVcselPulsePeriod. Running
+// continuous mode is restarted with the same period.
+type AdaptiveRange struct {
+	sensor *Vl53l0x
+	i2c    Bus
+	speed  SpeedAccuracySpec
+	mode   RangeSpec
+	// number of consecutive readings to trigger switching
+	switchAfter int
+	// distance in millimeters to return to RegularRange
+	returnBelow uint16
+	misses      int
+	hits        int
+	// handler registered by OnSwitch
+	onSwitch func(mode RangeSpec)
+}
+
+// NewAdaptiveRange creates adaptive range controller for sensor
+// initialized by Init. Call Start to configure sensor.
+func NewAdaptiveRange(sensor *Vl53l0x, i2c Bus, speed SpeedAccuracySpec) *AdaptiveRange {
+	v := &AdaptiveRange{sensor: sensor, i2c: i2c, speed: speed,
+		switchAfter: defaultAdaptiveSwitchAfter,
+		returnBelow: defaultAdaptiveReturnBelow}
+	return v
+}
+
+// SetThresholds define number of consecutive readings to trigger
+// switching and distance in millimeters to return from LongRange
+// to RegularRange. Defaults are 3 readings and 800 mm.
+func (v *AdaptiveRange) SetThresholds(switchAfter int, returnBelowMm uint16) {
+	if switchAfter < 1 {
+		switchAfter = 1
+	}
+	v.switchAfter = switchAfter
+	v.returnBelow = returnBelowMm
+}
+
+// OnSwitch registers handler called after mode is switched.
+func (v *AdaptiveRange) OnSwitch(handler func(mode RangeSpec)) {
+	v.onSwitch = handler
+}
+
+// Mode returns current range mode.
+func (v *AdaptiveRange) Mode() RangeSpec {
+	return v.mode
+}
+
+// Start configure sensor for RegularRange.
+func (v *AdaptiveRange) Start() error {
+	v.misses = 0
+	v.hits = 0
+	return v.switchMode(RegularRange)
+}
+
+// Process account measurement and switch mode, if needed.
+// Returns true, if mode was switched.
+func (v *AdaptiveRange) Process(m Measurement) (bool, error) {
+	if m.Err != nil {
+		return false, nil
+	}
+	switch v.mode {
+	case RegularRange:
+		if m.Status == OutOfRange || m.Status == SignalFail {
+			v.misses++
+		} else {
+			v.misses = 0
+		}
+		if v.misses >= v.switchAfter {
+			return true, v.switchMode(LongRange)
+		}
+	case LongRange:
+		if m.Valid() && m.RangeMillimeters < v.returnBelow {
+			v.hits++
+		} else {
+			v.hits = 0
+		}
+		if v.hits >= v.switchAfter {
+			return true, v.switchMode(RegularRange)
+		}
+	}
+	return false, nil
+}
+
+// ReadMeasurementSingle perform single-shot measurement
+// and switch mode, if needed.
+func (v *AdaptiveRange) ReadMeasurementSingle() (Measurement, error) {
+	m, err := v.sensor.ReadMeasurementSingle(v.i2c)
+	if err != nil {
+		return m, err
+	}
+	_, err = v.Process(m)
+	return m, err
+}
+
+// ReadMeasurementContinuous read measurement in continuous mode
+// and switch mode, if needed.
+func (v *AdaptiveRange) ReadMeasurementContinuous() (Measurement, error) {
+	m, err := v.sensor.ReadMeasurementContinuous(v.i2c)
+	if err != nil {
+		return m, err
+	}
+	_, err = v.Process(m)
+	return m, err
+}
+
+// Reconfigure sensor for range mode given,
+// stopping and restarting continuous mode if running.
+func (v *AdaptiveRange) switchMode(mode RangeSpec) error {
+
+	debugf("Switch range mode to %v", mode)
+
+	settings := v.sensor.settings
+	if settings.continuous {
+		err := v.sensor.StopContinuous(v.i2c)
+		if err != nil {
+			return err
+		}
+	}
+	err := v.sensor.Config(v.i2c, mode, v.speed)
+	if err != nil {
+		return err
+	}
+	v.mode = mode
+	v.misses = 0
+	v.hits = 0
+	if settings.continuous {
+		err = v.sensor.restartContinuous(v.i2c, settings.periodMs)
+		if err != nil {
+			return err
+		}
+	}
+	if v.onSwitch != nil {
+		v.onSwitch(mode)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package vl53l0x
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	i2c "github.com/d2r2/go-i2c"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// Time to wait for sensor boot during array bring-up.
+const bootTimeout = time.Millisecond * 100
+
+var errNotBroughtUp = errors.New("sensor is not brought up")
+
+// ArraySensor describes single sensor of Array.
+type ArraySensor struct {
+	Name    string
+	Model   Model
+	Address byte
+	XShut   *XShutController
+	// Available after bring-up. Sensor and Module are set
+	// for VL53L0X only, Device is set for any model.
+	Device RangeSensor
+	Sensor *Vl53l0x
+	I2C    *i2c.I2C
+	Module *ModuleInfo
+}
+
+// ModelDriver describes bring-up of sensor model other than VL53L0X
+// in Array. Drivers of such models register it by RegisterModelDriver.
+type ModelDriver struct {
+	// Booted checks, whether sensor answers over connection
+	// to default address after power-on.
+	Booted func(conn Bus) (bool, error)
+	// SetAddress assign new address to sensor.
+	SetAddress func(conn Bus, addr byte) error
+	// Open initialize sensor over connection to assigned address.
+	// Connection is owned by Array and closed by Array.Close.
+	Open func(conn Bus) (RangeSensor, error)
+}
+
+var (
+	modelDriversMutex sync.Mutex
+	modelDrivers      = make(map[Model]ModelDriver)
+)
+
+// RegisterModelDriver make model available for Array.AddModel.
+func RegisterModelDriver(model Model, driver ModelDriver) {
+	modelDriversMutex.Lock()
+	defer modelDriversMutex.Unlock()
+	modelDrivers[model] = driver
+}
+
+// Returns driver registered for model.
+func lookupModelDriver(model Model) (ModelDriver, bool) {
+	modelDriversMutex.Lock()
+	defer modelDriversMutex.Unlock()
+	driver, ok := modelDrivers[model]
+	return driver, ok
+}
+
+// Array is a multi-sensor manager: it brings up several sensors sharing
+// the same I2C-bus, using XSHUT pins to wake them one by one and assign
+// individual addresses. Optionally, calibration for each physical module
+// is loaded from CalibrationStore by module unique identifier. Array
+// could mix VL53L0X with other models, which drivers are registered
+// by RegisterModelDriver, for instance short- and long-range modules.
+type Array struct {
+	bus     int
+	store   CalibrationStore
+	sensors []*ArraySensor
+}
+
+// NewArray creates multi-sensor manager on I2C-bus given.
+// Store could be nil, if no calibration should be loaded.
+func NewArray(bus int, store CalibrationStore) *Array {
+	v := &Array{bus: bus, store: store}
+	return v
+}
+
+// Add VL53L0X sensor with XSHUT pin controller and address to assign.
+func (v *Array) Add(name string, xshut *XShutController, address byte) *ArraySensor {
+	return v.AddModel(name, ModelVl53l0x, xshut, address)
+}
+
+// AddModel add sensor of model given with XSHUT pin controller and
+// address to assign. Models other than VL53L0X require driver registered
+// by RegisterModelDriver before bring-up.
+func (v *Array) AddModel(name string, model Model, xshut *XShutController,
+	address byte) *ArraySensor {
+
+	s := &ArraySensor{Name: name, Model: model, XShut: xshut, Address: address}
+	v.sensors = append(v.sensors, s)
+	return s
+}
+
+// Sensors returns sensors in the order they were added.
+func (v *Array) Sensors() []*ArraySensor {
+	return v.sensors
+}
+
+// BringUp power off all sensors, then power on them one by one, assign
+// addresses, initialize and load calibration, if available in store.
+func (v *Array) BringUp() error {
+	for _, s := range v.sensors {
+		err := s.XShut.PowerOff()
+		if err != nil {
+			return err
+		}
+	}
+	for _, s := range v.sensors {
+		err := v.bringUpSensor(s)
+		if err != nil {
+			return errors.New(spew.Sprintf("sensor %q: %s", s.Name, err))
+		}
+	}
+	return nil
+}
+
+// Power on single sensor, assign address, initialize and load calibration.
+func (v *Array) bringUpSensor(s *ArraySensor) error {
+
+	debugf("Bring up %s sensor %q at address 0x%x", s.Model, s.Name, s.Address)
+
+	if s.Model != ModelVl53l0x {
+		return v.bringUpModel(s)
+	}
+	err := s.XShut.PowerOn()
+	if err != nil {
+		return err
+	}
+	conn, err := newI2C(defaultAddress, v.bus)
+	if err != nil {
+		return err
+	}
+	err = s.XShut.WaitBoot(conn, bootTimeout)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	sensor := NewVl53l0x()
+	if s.Address != defaultAddress {
+		old := conn
+		err = sensor.SetAddress(&conn, s.Address)
+		old.Close()
+		if err != nil {
+			return err
+		}
+	}
+	s.Sensor = sensor
+	s.I2C = conn
+	s.Device = &Device{sensor: sensor, bus: conn}
+	err = sensor.Init(conn)
+	if err != nil {
+		return err
+	}
+	s.Module, err = sensor.GetModuleInfo(conn)
+	if err != nil {
+		return err
+	}
+	if v.store == nil {
+		return nil
+	}
+	data, err := v.store.LoadCalibration(s.Module.Uid())
+	if err == ErrCalibrationNotFound {
+		lg.Infof("No calibration found for sensor %q (module %s)", s.Name, s.Module.Uid())
+		return nil
+	} else if err != nil {
+		return err
+	}
+	debugf("Load calibration for sensor %q (module %s)", s.Name, s.Module.Uid())
+	return sensor.ImportCalibration(conn, data)
+}
+
+// Power on sensor of model other than VL53L0X, assign address
+// and initialize it with registered driver.
+func (v *Array) bringUpModel(s *ArraySensor) error {
+	driver, ok := lookupModelDriver(s.Model)
+	if !ok {
+		return errors.New(spew.Sprintf("no driver registered for model %s", s.Model))
+	}
+	err := s.XShut.PowerOn()
+	if err != nil {
+		return err
+	}
+	conn, err := newI2C(defaultAddress, v.bus)
+	if err != nil {
+		return err
+	}
+	err = s.XShut.waitBoot(conn, bootTimeout, driver.Booted)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if s.Address != defaultAddress {
+		err = driver.SetAddress(conn, s.Address)
+		conn.Close()
+		if err != nil {
+			return err
+		}
+		conn, err = newI2C(s.Address, v.bus)
+		if err != nil {
+			return err
+		}
+	}
+	s.I2C = conn
+	s.Device, err = driver.Open(conn)
+	return err
+}
+
+// SaveCalibration export calibration of each sensor to the store.
+func (v *Array) SaveCalibration() error {
+	if v.store == nil {
+		return errors.New("no calibration store specified")
+	}
+	for _, s := range v.sensors {
+		if s.Sensor == nil || s.Module == nil {
+			continue
+		}
+		data, err := s.Sensor.ExportCalibration(s.I2C)
+		if err != nil {
+			return err
+		}
+		err = v.store.SaveCalibration(s.Module.Uid(), data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close I2C-connections of all sensors.
+func (v *Array) Close() error {
+	var firstErr error
+	for _, s := range v.sensors {
+		if s.I2C != nil {
+			err := s.I2C.Close()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			s.I2C = nil
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,36 @@
+package vl53l0x
+
+import (
+	"errors"
+)
+
+// ReadRangeAveraged performs n single-shot range measurements, drops invalid
+// ones (out-of-range) and returns mean and standard deviation of the rest
+// in millimeters. Used for calibration targets and anywhere, where precision
+// is more important than measurement time. Warm-up samples are not counted.
+// Returns error, if no valid measurement taken.
+func (v *Vl53l0x) ReadRangeAveraged(i2c Bus, n int) (float64, float64, error) {
+
+	debugf("Read range averaged over %d measurements", n)
+
+	if n < 1 {
+		return 0, 0, errors.New("number of measurements should be positive")
+	}
+	stats := NewStats()
+	for i := 0; i < n; {
+		m, err := v.withRecovery(i2c, v.readMeasurementSingle)
+		if err != nil {
+			return 0, 0, err
+		}
+		if v.warmingUp(m) {
+			continue
+		}
+		stats.Add(m)
+		i++
+	}
+	snapshot := stats.Snapshot()
+	if snapshot.Valid == 0 {
+		return 0, 0, errors.New("no valid measurements taken")
+	}
+	return snapshot.Mean, snapshot.StdDev, nil
+}
@@ -0,0 +1,115 @@
+package vl53l0x
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	i2c "github.com/d2r2/go-i2c"
+	logger "github.com/d2r2/go-logger"
+)
+
+// Benchmarks run against SimulatedSensor by default. To benchmark real
+// hardware, pass I2C-bus number and optionally address of the sensor:
+//
+//	go test -run XXX -bench . -vl53l0x.bus 1 -vl53l0x.addr 0x29
+var (
+	benchBus  = flag.Int("vl53l0x.bus", -1, "I2C-bus of real sensor to benchmark, simulated sensor if negative")
+	benchAddr = flag.Uint("vl53l0x.addr", 0x29, "I2C address of real sensor to benchmark")
+)
+
+// Measurement time of simulated sensor, short enough
+// to let driver overhead dominate in results.
+const benchSimMeasurementTime = time.Millisecond
+
+// Open bus of benchmark, either real or simulated one.
+func openBenchBus(b *testing.B) Bus {
+	b.Helper()
+	logger.ChangePackageLogLevel("i2c", logger.InfoLevel)
+	SetLogLevel(logger.InfoLevel)
+	if *benchBus < 0 {
+		sim := NewSimulatedSensor(ConstantScenario(500))
+		sim.SetMeasurementTime(benchSimMeasurementTime)
+		return sim
+	}
+	conn, err := i2c.NewI2C(uint8(*benchAddr), *benchBus)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// Open bus and initialize sensor. Simulated sensor is waited
+// with interrupt emulation, instead of sleeping through timing budget.
+func initBenchSensor(b *testing.B) (*Vl53l0x, Bus) {
+	b.Helper()
+	conn := openBenchBus(b)
+	v := NewVl53l0x()
+	if waiter, ok := conn.(InterruptWaiter); ok {
+		v.SetInterruptWaiter(waiter)
+	}
+	err := v.Init(conn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return v, conn
+}
+
+func BenchmarkInit(b *testing.B) {
+	conn := openBenchBus(b)
+	v := NewVl53l0x()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := v.Init(conn)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadSingle(b *testing.B) {
+	v, conn := initBenchSensor(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := v.ReadMeasurementSingle(conn)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadContinuous(b *testing.B) {
+	v, conn := initBenchSensor(b)
+	err := v.StartContinuous(conn, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer v.StopContinuous(conn)
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		_, err := v.ReadMeasurementContinuous(conn)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "reads/s")
+}
+
+// Allocations of result decoding alone, without bus transactions.
+func BenchmarkParseResult(b *testing.B) {
+	v := NewVl53l0x()
+	v.measurementTimingBudgetUsec = 33000
+	buf := &[resultBlockSize]byte{0x58, 0x00, 0x04, 0x00, 0x00, 0x00, 0x0a, 0x0c, 0x00, 0x0d, 0x00, 0x96}
+	ts := time.Now()
+	var m Measurement
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.parseResult(&m, buf, ts, v.measurementTimingBudgetUsec)
+	}
+}
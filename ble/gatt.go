@@ -0,0 +1,138 @@
+//go:build linux
+
+// Package ble exposes current VL53L0X distance and range status as
+// Bluetooth Low Energy GATT characteristic with notifications (Linux,
+// BlueZ), so phones and tablets could read the sensor directly with any
+// generic BLE explorer app during installation and commissioning.
+//
+// Measurement characteristic value is 4 bytes:
+//
+//	bytes 0-1  distance in millimeters, unsigned little-endian
+//	byte  2    range status, see vl53l0x.RangeStatus
+//	byte  3    flags: bit 0 - measurement valid, bit 1 - acquisition error
+package ble
+
+import (
+	"encoding/binary"
+	"sync"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	"tinygo.org/x/bluetooth"
+)
+
+// UUIDs of service and measurement characteristic.
+const (
+	ServiceUUID     = "6e5d0001-8f3b-4b5e-9d0a-3c1f5a7e2b40"
+	MeasurementUUID = "6e5d0002-8f3b-4b5e-9d0a-3c1f5a7e2b40"
+)
+
+// Flags of measurement characteristic value.
+const (
+	FlagValid = 1 << 0
+	FlagError = 1 << 1
+)
+
+// Size of measurement characteristic value.
+const valueSize = 4
+
+// Service is GATT service with single measurement characteristic,
+// advertised under local name given.
+type Service struct {
+	sync.Mutex
+	adv   *bluetooth.Advertisement
+	char  bluetooth.Characteristic
+	value [valueSize]byte
+}
+
+// NewService enable adapter, register GATT service and start advertising.
+// Nil adapter stands for bluetooth.DefaultAdapter.
+func NewService(adapter *bluetooth.Adapter, localName string) (*Service, error) {
+	if adapter == nil {
+		adapter = bluetooth.DefaultAdapter
+	}
+	serviceUUID, err := bluetooth.ParseUUID(ServiceUUID)
+	if err != nil {
+		return nil, err
+	}
+	measurementUUID, err := bluetooth.ParseUUID(MeasurementUUID)
+	if err != nil {
+		return nil, err
+	}
+	err = adapter.Enable()
+	if err != nil {
+		return nil, err
+	}
+	v := &Service{}
+	err = adapter.AddService(&bluetooth.Service{
+		UUID: serviceUUID,
+		Characteristics: []bluetooth.CharacteristicConfig{{
+			Handle: &v.char,
+			UUID:   measurementUUID,
+			Value:  v.value[:],
+			Flags: bluetooth.CharacteristicReadPermission |
+				bluetooth.CharacteristicNotifyPermission,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	v.adv = adapter.DefaultAdvertisement()
+	err = v.adv.Configure(bluetooth.AdvertisementOptions{
+		LocalName:    localName,
+		ServiceUUIDs: []bluetooth.UUID{serviceUUID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = v.adv.Start()
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Encode measurement to characteristic value.
+func Encode(m vl53l0x.Measurement) [valueSize]byte {
+	var b [valueSize]byte
+	binary.LittleEndian.PutUint16(b[0:], m.RangeMillimeters)
+	b[2] = byte(m.Status)
+	if m.Valid() {
+		b[3] |= FlagValid
+	}
+	if m.Err != nil {
+		b[3] |= FlagError
+	}
+	return b
+}
+
+// Update set characteristic value and notify subscribed clients.
+// Notification is skipped, if value hasn't changed.
+func (v *Service) Update(m vl53l0x.Measurement) error {
+	value := Encode(m)
+	v.Lock()
+	defer v.Unlock()
+	if value == v.value {
+		return nil
+	}
+	v.value = value
+	_, err := v.char.Write(value[:])
+	return err
+}
+
+// Run update characteristic with measurements from stream, for instance
+// one returned by Vl53l0x.Stream, until it's closed. Update errors
+// are returned immediately.
+func (v *Service) Run(in <-chan vl53l0x.Measurement) error {
+	for m := range in {
+		err := v.Update(m)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stop advertising.
+func (v *Service) Close() error {
+	return v.adv.Stop()
+}
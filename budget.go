@@ -0,0 +1,139 @@
+package vl53l0x
+
+import (
+	"errors"
+	"time"
+)
+
+// Limits and step of BudgetTuner.
+const (
+	minTunerBudget         = time.Microsecond * 20000
+	defaultTunerWindow     = 10
+	budgetTunerStepPercent = 50
+)
+
+// BudgetTuner adjusts measurement timing budget at runtime to keep
+// measurement sigma around target value, while staying under latency cap.
+// Useful, when ambient light varies drastically between day and night:
+// longer budget reduces sigma in bright conditions, shorter one
+// improves latency in dark conditions. Decision is made once per
+// window of samples, using average sigma of valid measurements. Window
+// without valid measurements raises budget only, if signal was too weak
+// (SigmaFail or SignalFail); with nothing in front of sensor (OutOfRange)
+// budget is kept, so target appearing is detected without extra latency.
+type BudgetTuner struct {
+	sensor      *Vl53l0x
+	i2c         Bus
+	targetSigma float32
+	minBudget   time.Duration
+	maxBudget   time.Duration
+	window      int
+	count       int
+	sigmaSum    float32
+	valid       int
+	// measurements failed because of weak signal
+	weak int
+}
+
+// NewBudgetTuner creates timing budget controller for sensor initialized
+// by Init, targeting sigma in millimeters, with maxBudget as latency cap.
+func NewBudgetTuner(sensor *Vl53l0x, i2c Bus, targetSigmaMm float32,
+	maxBudget time.Duration) (*BudgetTuner, error) {
+
+	if maxBudget < minTunerBudget {
+		return nil, errors.New("latency cap is less than minimum timing budget")
+	}
+	v := &BudgetTuner{sensor: sensor, i2c: i2c, targetSigma: targetSigmaMm,
+		minBudget: minTunerBudget, maxBudget: maxBudget,
+		window: defaultTunerWindow}
+	return v, nil
+}
+
+// SetWindow define number of samples to make single decision on.
+// Default is 10 samples.
+func (v *BudgetTuner) SetWindow(samples int) {
+	if samples < 1 {
+		samples = 1
+	}
+	v.window = samples
+	v.Reset()
+}
+
+// Budget returns current measurement timing budget.
+func (v *BudgetTuner) Budget() time.Duration {
+	return time.Duration(v.sensor.measurementTimingBudgetUsec) * time.Microsecond
+}
+
+// Process account measurement and adjust timing budget, if needed.
+// Returns true, if timing budget was changed.
+func (v *BudgetTuner) Process(m Measurement) (bool, error) {
+	if m.Err != nil {
+		return false, nil
+	}
+	v.count++
+	switch m.Status {
+	case RangeValid:
+		v.valid++
+		v.sigmaSum += m.SigmaMillimeters
+	case SigmaFail, SignalFail:
+		v.weak++
+	}
+	if v.count < v.window {
+		return false, nil
+	}
+	budget := v.Budget()
+	newBudget := budget
+	if v.valid == 0 {
+		if v.weak > 0 {
+			// signal too weak: try to collect more signal
+			newBudget = budget * (100 + budgetTunerStepPercent) / 100
+		}
+	} else {
+		sigma := v.sigmaSum / float32(v.valid)
+		if sigma > v.targetSigma {
+			newBudget = budget * (100 + budgetTunerStepPercent) / 100
+		} else if sigma < v.targetSigma/2 {
+			newBudget = budget * 100 / (100 + budgetTunerStepPercent)
+		}
+	}
+	v.Reset()
+	newBudget = max(v.minBudget, min(v.maxBudget, newBudget))
+	if newBudget == budget {
+		return false, nil
+	}
+	return true, v.setBudget(newBudget)
+}
+
+// Reset clears collected statistics.
+func (v *BudgetTuner) Reset() {
+	v.count = 0
+	v.valid = 0
+	v.weak = 0
+	v.sigmaSum = 0
+}
+
+// Apply new timing budget, stopping and restarting
+// continuous mode if running.
+func (v *BudgetTuner) setBudget(budget time.Duration) error {
+
+	debugf("Adjust timing budget to %v", budget)
+
+	settings := v.sensor.settings
+	if settings.continuous {
+		err := v.sensor.StopContinuous(v.i2c)
+		if err != nil {
+			return err
+		}
+	}
+	err := v.sensor.SetMeasurementTimingBudgetDuration(v.i2c, budget)
+	if err != nil {
+		return err
+	}
+	if settings.continuous {
+		err = v.sensor.restartContinuous(v.i2c, settings.periodMs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package vl53l0x
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudgetTunerOutOfRange(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	tuner, err := NewBudgetTuner(v, bus, 5, 200*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tuner.SetWindow(3)
+	budget := tuner.Budget()
+	for i := 0; i < 9; i++ {
+		changed, err := tuner.Process(Measurement{RangeMillimeters: 8190, Status: OutOfRange})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed {
+			t.Fatalf("budget changed to %v with no target", tuner.Budget())
+		}
+	}
+	for i := 0; i < 3; i++ {
+		_, err = tuner.Process(Measurement{RangeMillimeters: 1200, Status: SignalFail})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tuner.Budget() <= budget {
+		t.Errorf("budget %v isn't raised on weak signal, was %v", tuner.Budget(), budget)
+	}
+}
@@ -0,0 +1,335 @@
+package vl53l0x
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// Version of calibration data format produced by ExportCalibration.
+const calibrationVersion = 1
+
+// Calibration keeps per unit calibration data, which could be stored
+// and applied on every boot, instead of running calibration procedures.
+type Calibration struct {
+	// format version
+	Version int `json:"version"`
+	// range offset correction in micrometers
+	OffsetMicrometers int32 `json:"offset_um"`
+	// crosstalk compensation rate in MCPS; 0 means compensation disabled
+	CrosstalkRateMcps float32 `json:"crosstalk_rate_mcps"`
+	// reference SPAD selection
+	RefSpadCount          uint8 `json:"ref_spad_count"`
+	RefSpadTypeIsAperture bool  `json:"ref_spad_is_aperture"`
+	// reference calibration values
+	VhvSettings uint8 `json:"vhv_settings"`
+	PhaseCal    uint8 `json:"phase_cal"`
+}
+
+// Limits of range offset correction.
+const (
+	maxOffsetMicrometers = 511000
+	minOffsetMicrometers = -512000
+)
+
+// SetOffsetCalibration set range offset correction in micrometers,
+// which is added by sensor to measured distance. Offset is restored
+// automatically on re-initialization.
+// Based on VL53L0X_SetOffsetCalibrationDataMicroMeter().
+func (v *Vl53l0x) SetOffsetCalibration(i2c Bus, offsetUm int32) error {
+	if offsetUm > maxOffsetMicrometers {
+		offsetUm = maxOffsetMicrometers
+	} else if offsetUm < minOffsetMicrometers {
+		offsetUm = minOffsetMicrometers
+	}
+	// The offset register is 10.2 format and units are mm
+	// therefore conversion is applied by a division of 250.
+	var encoded uint16
+	if offsetUm >= 0 {
+		encoded = uint16(offsetUm / 250)
+	} else {
+		encoded = uint16(4096 + offsetUm/250)
+	}
+	err := v.writeRegU16(i2c, ALGO_PART_TO_PART_RANGE_OFFSET_MM, encoded)
+	if err != nil {
+		return err
+	}
+	v.settings.offsetUm = &offsetUm
+	return nil
+}
+
+// GetOffsetCalibration returns range offset correction in micrometers.
+// Based on VL53L0X_GetOffsetCalibrationDataMicroMeter().
+func (v *Vl53l0x) GetOffsetCalibration(i2c Bus) (int32, error) {
+	u16, err := v.readRegU16(i2c, ALGO_PART_TO_PART_RANGE_OFFSET_MM)
+	if err != nil {
+		return 0, err
+	}
+	u16 &= 0x0FFF
+	// apply 12 bit 2's complement conversion
+	if u16 > 2047 {
+		return (int32(u16) - 4096) * 250, nil
+	}
+	return int32(u16) * 250, nil
+}
+
+// SetCrosstalkCompensation set crosstalk compensation rate in MCPS, caused
+// by cover glass reflections. Zero value disables compensation.
+// Compensation is restored automatically on re-initialization.
+// Based on VL53L0X_SetXTalkCompensationRateMegaCps()
+// and VL53L0X_SetXTalkCompensationEnable().
+func (v *Vl53l0x) SetCrosstalkCompensation(i2c Bus, rateMcps float32) error {
+	if rateMcps < 0 || rateMcps >= 8 {
+		return errors.New("out of crosstalk rate range")
+	}
+	// Q3.13 fixed point format
+	err := v.writeRegU16(i2c, CROSSTALK_COMPENSATION_PEAK_RATE_MCPS,
+		uint16(rateMcps*(1<<13)))
+	if err != nil {
+		return err
+	}
+	v.settings.crosstalkRate = &rateMcps
+	return nil
+}
+
+// GetCrosstalkCompensation returns crosstalk compensation rate in MCPS.
+// Zero value means compensation disabled.
+func (v *Vl53l0x) GetCrosstalkCompensation(i2c Bus) (float32, error) {
+	u16, err := v.readRegU16(i2c, CROSSTALK_COMPENSATION_PEAK_RATE_MCPS)
+	if err != nil {
+		return 0, err
+	}
+	return float32(u16) / (1 << 13), nil
+}
+
+// Read or write VHV settings and phase calibration values.
+// Based on VL53L0X_ref_calibration_io().
+func (v *Vl53l0x) refCalibrationIo(i2c Bus, read bool, vhvSettings, phaseCal uint8) (uint8, uint8, error) {
+	err := v.writeRegValues(i2c, []RegBytePair{
+		{Reg: 0xFF, Value: 0x01},
+		{Reg: 0x00, Value: 0x00},
+		{Reg: 0xFF, Value: 0x00},
+	}...)
+	if err != nil {
+		return 0, 0, err
+	}
+	if read {
+		vhvSettings, err = v.readRegU8(i2c, 0xCB)
+		if err != nil {
+			return 0, 0, err
+		}
+		phaseCal, err = v.readRegU8(i2c, 0xEE)
+		if err != nil {
+			return 0, 0, err
+		}
+	} else {
+		err = v.writeRegU8(i2c, 0xCB, vhvSettings)
+		if err != nil {
+			return 0, 0, err
+		}
+		u8, err := v.readRegU8(i2c, 0xEE)
+		if err != nil {
+			return 0, 0, err
+		}
+		err = v.writeRegU8(i2c, 0xEE, u8&0x80|phaseCal)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	err = v.writeRegValues(i2c, []RegBytePair{
+		{Reg: 0xFF, Value: 0x01},
+		{Reg: 0x00, Value: 0x01},
+		{Reg: 0xFF, Value: 0x00},
+	}...)
+	if err != nil {
+		return 0, 0, err
+	}
+	return vhvSettings, phaseCal & 0xEF, nil
+}
+
+// GetCalibration collects calibration data currently applied to the sensor.
+func (v *Vl53l0x) GetCalibration(i2c Bus) (*Calibration, error) {
+	offset, err := v.GetOffsetCalibration(i2c)
+	if err != nil {
+		return nil, err
+	}
+	crosstalk, err := v.GetCrosstalkCompensation(i2c)
+	if err != nil {
+		return nil, err
+	}
+	vhv, phase, err := v.refCalibrationIo(i2c, true, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	c := &Calibration{Version: calibrationVersion,
+		OffsetMicrometers: offset, CrosstalkRateMcps: crosstalk,
+		RefSpadCount: v.refSpadInfo.Count, RefSpadTypeIsAperture: v.refSpadInfo.TypeIsAperture,
+		VhvSettings: vhv, PhaseCal: phase}
+	return c, nil
+}
+
+// SetCalibration apply calibration data to the sensor initialized by Init.
+// Calibration is restored automatically on re-initialization.
+func (v *Vl53l0x) SetCalibration(i2c Bus, c *Calibration) error {
+	if c.Version != calibrationVersion {
+		return errors.New(spew.Sprintf("unsupported calibration version %d", c.Version))
+	}
+	err := v.setReferenceSpads(i2c, SpadInfo{Count: c.RefSpadCount,
+		TypeIsAperture: c.RefSpadTypeIsAperture})
+	if err != nil {
+		return err
+	}
+	_, _, err = v.refCalibrationIo(i2c, false, c.VhvSettings, c.PhaseCal)
+	if err != nil {
+		return err
+	}
+	err = v.SetOffsetCalibration(i2c, c.OffsetMicrometers)
+	if err != nil {
+		return err
+	}
+	err = v.SetCrosstalkCompensation(i2c, c.CrosstalkRateMcps)
+	if err != nil {
+		return err
+	}
+	calibration := *c
+	v.settings.calibration = &calibration
+	return nil
+}
+
+// ExportCalibration returns versioned JSON blob with calibration data
+// currently applied to the sensor: range offset, crosstalk rate, reference
+// SPAD selection, VHV and phase calibration values. Store it per unit
+// and apply with ImportCalibration on every boot.
+func (v *Vl53l0x) ExportCalibration(i2c Bus) ([]byte, error) {
+	c, err := v.GetCalibration(i2c)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(c)
+}
+
+// ImportCalibration apply calibration data from JSON blob produced
+// by ExportCalibration. Call it after Init.
+func (v *Vl53l0x) ImportCalibration(i2c Bus, data []byte) error {
+	c := &Calibration{}
+	err := json.Unmarshal(data, c)
+	if err != nil {
+		return err
+	}
+	return v.SetCalibration(i2c, c)
+}
+
+// Default number of measurements taken by calibration procedures.
+const calibrationSamples = 50
+
+// PerformOffsetCalibration measures white target placed at distance given
+// in millimeters (ST recommends 100 mm), then calculates and applies range
+// offset correction. Number of measurements n defaults to 50, if zero.
+// Perform it before crosstalk calibration, without cover glass
+// or with final one. Returns offset in micrometers.
+// Based on VL53L0X_perform_offset_calibration().
+func (v *Vl53l0x) PerformOffsetCalibration(i2c Bus, targetMm float32, n int) (int32, error) {
+
+	debugf("Start offset calibration at %v mm", targetMm)
+
+	if targetMm <= 0 {
+		return 0, errors.New("calibration distance should be positive")
+	}
+	err := v.SetOffsetCalibration(i2c, 0)
+	if err != nil {
+		return 0, err
+	}
+	avg, err := v.calibrationAverage(i2c, n)
+	if err != nil {
+		return 0, err
+	}
+	offsetUm := int32((targetMm - avg.rng) * 1000)
+	err = v.SetOffsetCalibration(i2c, offsetUm)
+	if err != nil {
+		return 0, err
+	}
+	debugf("Offset calibration = %d um", offsetUm)
+	return offsetUm, nil
+}
+
+// PerformCrosstalkCalibration measures grey (17% reflectance) target placed
+// at distance given in millimeters behind cover glass, then calculates and
+// applies crosstalk compensation rate. Number of measurements n defaults
+// to 50, if zero. Returns compensation rate in MCPS.
+// Based on VL53L0X_perform_xtalk_calibration().
+func (v *Vl53l0x) PerformCrosstalkCalibration(i2c Bus, targetMm float32, n int) (float32, error) {
+
+	debugf("Start crosstalk calibration at %v mm", targetMm)
+
+	if targetMm <= 0 {
+		return 0, errors.New("calibration distance should be positive")
+	}
+	err := v.SetCrosstalkCompensation(i2c, 0)
+	if err != nil {
+		return 0, err
+	}
+	avg, err := v.calibrationAverage(i2c, n)
+	if err != nil {
+		return 0, err
+	}
+	if avg.spads == 0 {
+		return 0, errors.New("no effective SPADs reported")
+	}
+	// crosstalk per SPAD is a part of signal rate,
+	// not explained by distance to the target
+	rateMcps := avg.signal / avg.spads * (1 - avg.rng/targetMm)
+	if rateMcps < 0 {
+		rateMcps = 0
+	}
+	err = v.SetCrosstalkCompensation(i2c, rateMcps)
+	if err != nil {
+		return 0, err
+	}
+	debugf("Crosstalk compensation = %v MCPS", rateMcps)
+	return rateMcps, nil
+}
+
+// Averages collected by calibration procedures.
+type calibrationAverages struct {
+	rng    float32
+	signal float32
+	spads  float32
+}
+
+// Take n valid single-shot measurements with software corrections
+// disabled and returns averaged range, signal rate and SPAD count.
+func (v *Vl53l0x) calibrationAverage(i2c Bus, n int) (calibrationAverages, error) {
+	if n <= 0 {
+		n = calibrationSamples
+	}
+	// raw distances required
+	correction, angle, threshold := v.correction, v.mountingAngle, v.rangeIgnoreThreshold
+	v.correction, v.mountingAngle, v.rangeIgnoreThreshold = nil, 0, 0
+	defer func() {
+		v.correction, v.mountingAngle, v.rangeIgnoreThreshold = correction, angle, threshold
+	}()
+
+	var avg calibrationAverages
+	var count int
+	for i := 0; i < n; i++ {
+		m, err := v.readMeasurementSingle(i2c)
+		if err != nil {
+			return avg, err
+		}
+		if !m.Valid() {
+			continue
+		}
+		avg.rng += float32(m.RangeMillimeters)
+		avg.signal += m.SignalRateMcps
+		avg.spads += m.EffectiveSpadCount
+		count++
+	}
+	if count == 0 {
+		return avg, errors.New("no valid measurements taken")
+	}
+	avg.rng /= float32(count)
+	avg.signal /= float32(count)
+	avg.spads /= float32(count)
+	return avg, nil
+}
@@ -0,0 +1,74 @@
+package vl53l0x
+
+import (
+	"testing"
+)
+
+// Clear offset and crosstalk registers, as sensor reset does.
+func clearCalibrationRegs(bus *traceBus) {
+	for _, reg := range []byte{ALGO_PART_TO_PART_RANGE_OFFSET_MM,
+		CROSSTALK_COMPENSATION_PEAK_RATE_MCPS} {
+		bus.sim.WriteBytes([]byte{reg, 0, 0})
+	}
+}
+
+func TestCalibrationReInit(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	// imported calibration, changed in place afterwards
+	err := v.SetCalibration(bus, &Calibration{Version: calibrationVersion,
+		OffsetMicrometers: 25000, CrosstalkRateMcps: 0.5,
+		RefSpadCount: 5, RefSpadTypeIsAperture: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, err := v.PerformOffsetCalibration(bus, 510, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 10000 {
+		t.Fatalf("offset %d um, want 10000", offset)
+	}
+	rate, err := v.PerformCrosstalkCalibration(bus, 600, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clearCalibrationRegs(bus)
+	err = v.ReInit(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, err = v.GetOffsetCalibration(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 10000 {
+		t.Errorf("offset %d um after ReInit, want 10000", offset)
+	}
+	got, err := v.GetCrosstalkCompensation(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := float32(uint16(rate*(1<<13))) / (1 << 13); got != want {
+		t.Errorf("crosstalk %v MCPS after ReInit, want %v", got, want)
+	}
+}
+
+func TestOffsetCalibrationReInit(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	err := v.SetOffsetCalibration(bus, -5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clearCalibrationRegs(bus)
+	err = v.ReInit(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, err := v.GetOffsetCalibration(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != -5000 {
+		t.Errorf("offset %d um after ReInit, want -5000", offset)
+	}
+}
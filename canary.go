@@ -0,0 +1,78 @@
+package vl53l0x
+
+import (
+	"errors"
+)
+
+// ErrDeviceReset returned, when sensor lost configuration written
+// by driver, since it was reset by brown-out or power glitch.
+var ErrDeviceReset = errors.New("device reset detected: configuration lost")
+
+// Canary register, which keeps value written by Init (new sample ready
+// interrupt), while reset sensor has it zero (interrupt disabled).
+const (
+	canaryReg   = SYSTEM_INTERRUPT_CONFIG_GPIO
+	canaryValue = 0x04
+)
+
+// Reset detection state.
+type resetDetection struct {
+	// check canary every n measurements; zero disables detection
+	every int
+	// measurements since last check
+	count int
+}
+
+// SetResetDetection enable detection of unexpected sensor reset. Canary
+// register is verified every n measurements, and on suspicious ones (no
+// update or hardware failure reported, data ready timeout), so measurements
+// taken with default configuration after brown-out are reported as
+// ErrDeviceReset, rather than returned silently. Combined with SetAutoRecovery,
+// sensor is re-initialized and measurement repeated transparently.
+// Zero value disables detection, which is default.
+func (v *Vl53l0x) SetResetDetection(n int) {
+	v.resetDetection = resetDetection{every: n}
+}
+
+// CheckDeviceReset verify canary register still holds value written
+// by driver. Returns ErrDeviceReset, if sensor was reset since Init.
+func (v *Vl53l0x) CheckDeviceReset(i2c Bus) error {
+	u8, err := v.readRegU8(i2c, canaryReg)
+	if err != nil {
+		return err
+	}
+	if u8 != canaryValue {
+		warnf("Canary register 0x%x holds 0x%x instead of 0x%x",
+			canaryReg, u8, canaryValue)
+		return ErrDeviceReset
+	}
+	return nil
+}
+
+// Check canary register, if detection is enabled and check is due
+// by measurements count or suspicious measurement.
+func (v *Vl53l0x) checkDeviceResetIfDue(i2c Bus, m *Measurement) error {
+	d := &v.resetDetection
+	if d.every <= 0 {
+		return nil
+	}
+	d.count++
+	if d.count < d.every && m.Status != RangeStatusNone && m.Status != HardwareFail {
+		return nil
+	}
+	d.count = 0
+	return v.CheckDeviceReset(i2c)
+}
+
+// Replace data ready timeout with ErrDeviceReset, if detection
+// is enabled and sensor was reset, which stops ranging.
+func (v *Vl53l0x) deviceResetCause(i2c Bus, err error) error {
+	if v.resetDetection.every <= 0 || !errors.Is(err, ErrTimeout) {
+		return err
+	}
+	v.resetDetection.count = 0
+	if v.CheckDeviceReset(i2c) == ErrDeviceReset {
+		return ErrDeviceReset
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package vl53l0x
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDeviceResetDetection(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	v.SetResetDetection(3)
+	for i := 0; i < 3; i++ {
+		_, err := v.ReadMeasurementSingle(bus)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// configuration lost by brown-out
+	err := bus.sim.WriteRegU8(SYSTEM_INTERRUPT_CONFIG_GPIO, 0x00)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		_, err = v.ReadMeasurementSingle(bus)
+		if err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrDeviceReset) {
+		t.Fatalf("got error %v, want ErrDeviceReset", err)
+	}
+	err = v.ReInit(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = v.CheckDeviceReset(bus)
+	if err != nil {
+		t.Errorf("reset detected after re-initialization: %v", err)
+	}
+}
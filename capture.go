@@ -0,0 +1,35 @@
+package vl53l0x
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Capture runs continuous mode with inter-measurement period given (0 stands
+// for back-to-back mode), collects exactly n measurements and stops ranging.
+// Useful for scripted characterization runs and calibration routines.
+// If ranging limit set by SetRangingLimit is reached before n measurements
+// are taken, those collected so far are returned with ErrLimitReached.
+func (v *Vl53l0x) Capture(i2c Bus, n int, period time.Duration) ([]Measurement, error) {
+
+	debugf("Capture %d measurements each %v", n, period)
+
+	if n < 1 {
+		return nil, errors.New("number of measurements should be positive")
+	}
+	list := make([]Measurement, 0, n)
+	for m, err := range v.Measurements(context.Background(), i2c, period) {
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, m)
+		if len(list) == n {
+			break
+		}
+	}
+	if len(list) < n {
+		return list, ErrLimitReached
+	}
+	return list, nil
+}
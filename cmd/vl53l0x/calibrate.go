@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Run interactive calibration wizard.
+func runCalibrate(args []string) error {
+	fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	conn := addConnFlags(fs)
+	offsetMm := fs.Float64("offset-distance", 100, "white target distance in mm for offset calibration")
+	crosstalkMm := fs.Float64("crosstalk-distance", 400, "grey target distance in mm for crosstalk calibration")
+	samples := fs.Int("samples", 50, "number of measurements per calibration step")
+	skipCrosstalk := fs.Bool("no-crosstalk", false, "skip crosstalk calibration (no cover glass)")
+	out := fs.String("out", "calibration.json", "file to export calibration to")
+	fs.Parse(args)
+
+	sensor, i2c, err := conn.open()
+	if err != nil {
+		return err
+	}
+	defer i2c.Close()
+
+	in := bufio.NewReader(os.Stdin)
+	fmt.Println("VL53L0X calibration wizard")
+	fmt.Println("Keep sensor fixed and avoid strong ambient light during calibration.")
+	fmt.Println()
+
+	// step 1: offset
+	fmt.Printf("Step 1: offset calibration.\n"+
+		"Place white target (88%% reflectance) at %g mm from the sensor.\n", *offsetMm)
+	if !confirm(in, "Ready?") {
+		return errors.New("calibration cancelled")
+	}
+	fmt.Printf("Measuring %d samples...\n", *samples)
+	offset, err := sensor.PerformOffsetCalibration(i2c, float32(*offsetMm), *samples)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Offset correction = %.2f mm\n\n", float64(offset)/1000)
+
+	// step 2: crosstalk
+	if !*skipCrosstalk {
+		fmt.Printf("Step 2: crosstalk calibration.\n"+
+			"Mount cover glass and place grey target (17%% reflectance) at %g mm.\n",
+			*crosstalkMm)
+		if !confirm(in, "Ready?") {
+			return errors.New("calibration cancelled")
+		}
+		fmt.Printf("Measuring %d samples...\n", *samples)
+		rate, err := sensor.PerformCrosstalkCalibration(i2c, float32(*crosstalkMm), *samples)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Crosstalk compensation = %.4f MCPS\n\n", rate)
+	}
+
+	// step 3: verification and export
+	mean, stddev, err := sensor.ReadRangeAveraged(i2c, *samples)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Verification: %.1f mm (std dev %.1f mm)\n", mean, stddev)
+	data, err := sensor.ExportCalibration(i2c)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(*out, data, 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Calibration exported to %s; apply it with ImportCalibration on every boot.\n", *out)
+	return nil
+}
+
+// Ask user yes/no question; empty answer means yes.
+func confirm(in *bufio.Reader, question string) bool {
+	fmt.Printf("%s [Y/n] ", question)
+	answer, err := in.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Write register snapshot to file or standard output.
+func runDump(args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	conn := addConnFlags(fs)
+	out := fs.String("out", "", "file to write snapshot to (standard output by default)")
+	noInit := fs.Bool("no-init", false, "don't initialize sensor, dump state as is")
+	fs.Parse(args)
+
+	sensor, i2c, err := conn.openWith(!*noInit)
+	if err != nil {
+		return err
+	}
+	defer i2c.Close()
+	s, err := sensor.TakeSnapshot(i2c)
+	if err != nil {
+		return err
+	}
+	data, err := s.Marshal()
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	err = os.WriteFile(*out, data, 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Snapshot of %d registers written to %s\n", len(s.Registers), *out)
+	return nil
+}
+
+// Initialize sensor and apply register snapshot from file.
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	conn := addConnFlags(fs)
+	in := fs.String("in", "", "snapshot file to restore (required)")
+	fs.Parse(args)
+
+	if *in == "" {
+		fs.Usage()
+		return errors.New("snapshot file not specified")
+	}
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		return err
+	}
+	s, err := vl53l0x.UnmarshalSnapshot(data)
+	if err != nil {
+		return err
+	}
+	sensor, i2c, err := conn.open()
+	if err != nil {
+		return err
+	}
+	defer i2c.Close()
+	err = sensor.RestoreSnapshot(i2c, s)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Snapshot taken by %s at %s restored\n",
+		s.Driver, s.Time.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// Print registers, which differ between two snapshot files.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s diff baseline.json snapshot.json\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("two snapshot files expected")
+	}
+	var snapshots [2]*vl53l0x.Snapshot
+	for i, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		snapshots[i], err = vl53l0x.UnmarshalSnapshot(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	changes, err := vl53l0x.DiffSnapshots(snapshots[0], snapshots[1])
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	fmt.Fprintf(os.Stderr, "%d register(s) differ\n", len(changes))
+	return nil
+}
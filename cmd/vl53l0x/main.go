@@ -0,0 +1,115 @@
+// Command vl53l0x is a command line utility to calibrate, monitor
+// and troubleshoot VL53L0X sensors attached to I2C-bus.
+//
+// Usage:
+//
+//	vl53l0x <command> [flags]
+//
+// Run "vl53l0x help" to get list of commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	i2c "github.com/d2r2/go-i2c"
+	logger "github.com/d2r2/go-logger"
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+var lg = logger.NewPackageLogger("main",
+	logger.InfoLevel,
+)
+
+// Command of the utility.
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"calibrate", "interactive offset and crosstalk calibration", runCalibrate},
+		{"monitor", "live display of measurements", runMonitor},
+		{"dump", "write register snapshot", runDump},
+		{"restore", "apply register snapshot", runRestore},
+		{"diff", "compare register snapshots", runDiff},
+		{"scan", "find sensors on I2C-bus", runScan},
+	}
+}
+
+func main() {
+	defer logger.FinalizeLogger()
+	logger.ChangePackageLogLevel("i2c", logger.InfoLevel)
+	vl53l0x.SetLogLevel(logger.InfoLevel)
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	for _, c := range commands {
+		if c.name == name {
+			err := c.run(os.Args[2:])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	if name != "help" && name != "-h" && name != "--help" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+	}
+	usage()
+	os.Exit(2)
+}
+
+// Print list of commands.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: vl53l0x <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", c.name, c.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun \"vl53l0x <command> -h\" to get command flags.\n")
+}
+
+// Connection flags common for all commands.
+type connFlags struct {
+	bus  int
+	addr uint
+}
+
+// Register connection flags in flag set.
+func addConnFlags(fs *flag.FlagSet) *connFlags {
+	v := &connFlags{}
+	fs.IntVar(&v.bus, "bus", 1, "I2C-bus number")
+	fs.UintVar(&v.addr, "addr", 0x29, "sensor I2C address")
+	return v
+}
+
+// Open I2C-connection and initialize sensor.
+func (v *connFlags) open() (*vl53l0x.Vl53l0x, *i2c.I2C, error) {
+	return v.openWith(true)
+}
+
+// Open I2C-connection and optionally initialize sensor.
+func (v *connFlags) openWith(init bool) (*vl53l0x.Vl53l0x, *i2c.I2C, error) {
+	conn, err := i2c.NewI2C(uint8(v.addr), v.bus)
+	if err != nil {
+		return nil, nil, err
+	}
+	sensor := vl53l0x.NewVl53l0x()
+	if init {
+		err = sensor.Init(conn)
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+	return sensor, conn, nil
+}
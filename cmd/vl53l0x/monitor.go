@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Sparkline characters from lowest to highest.
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// Run live terminal monitor.
+func runMonitor(args []string) error {
+	fs := flag.NewFlagSet("monitor", flag.ExitOnError)
+	conn := addConnFlags(fs)
+	period := fs.Duration("period", 0, "inter-measurement period (0 for back-to-back)")
+	width := fs.Int("width", 60, "sparkline history length")
+	profileName := fs.String("profile", vl53l0x.ProfileDefault.Name, "initial profile")
+	fs.Parse(args)
+
+	profile, err := vl53l0x.ProfileByName(*profileName)
+	if err != nil {
+		return err
+	}
+	sensor, i2c, err := conn.open()
+	if err != nil {
+		return err
+	}
+	defer i2c.Close()
+	err = sensor.ApplyProfile(i2c, profile)
+	if err != nil {
+		return err
+	}
+
+	restore, err := rawTerminal()
+	if err != nil {
+		return err
+	}
+	defer restore()
+	keys := readKeys()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	profiles := vl53l0x.Profiles()
+	history := make([]vl53l0x.Measurement, 0, *width)
+	err = sensor.StartContinuousDuration(i2c, *period)
+	if err != nil {
+		return err
+	}
+	defer sensor.StopContinuous(i2c)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case key, ok := <-keys:
+			if !ok {
+				// standard input closed
+				keys = nil
+				break
+			}
+			if key == 'q' || key == 'Q' {
+				return nil
+			}
+			i := int(key - '1')
+			if i < 0 || i >= len(profiles) {
+				break
+			}
+			profile = profiles[i]
+			err = sensor.StopContinuous(i2c)
+			if err != nil {
+				return err
+			}
+			err = sensor.ApplyProfile(i2c, profile)
+			if err != nil {
+				return err
+			}
+			history = history[:0]
+			err = sensor.StartContinuousDuration(i2c, *period)
+			if err != nil {
+				return err
+			}
+		default:
+		}
+		m, err := sensor.ReadMeasurementContinuous(i2c)
+		if err != nil {
+			return err
+		}
+		if len(history) == *width {
+			history = append(history[:0], history[1:]...)
+		}
+		history = append(history, m)
+		render(m, history, profile, profiles)
+	}
+}
+
+// Draw monitor screen.
+func render(m vl53l0x.Measurement, history []vl53l0x.Measurement,
+	profile vl53l0x.Profile, profiles []vl53l0x.Profile) {
+
+	var b strings.Builder
+	// move cursor home and clear screen
+	b.WriteString("\033[H\033[2J")
+	fmt.Fprintf(&b, "VL53L0X monitor                profile: %s\r\n\r\n", profile.Name)
+	if m.Valid() {
+		fmt.Fprintf(&b, "  Distance    %6d mm\r\n", m.RangeMillimeters)
+		fmt.Fprintf(&b, "  Spot        %6.0f mm\r\n",
+			vl53l0x.SpotDiameter(m.Distance()).Millimeters())
+	} else {
+		fmt.Fprintf(&b, "  Distance         - mm\r\n")
+		fmt.Fprintf(&b, "  Spot             - mm\r\n")
+	}
+	fmt.Fprintf(&b, "  Status      %s\r\n", m.Status)
+	if hints := m.Status.Remediations(); len(hints) > 0 {
+		fmt.Fprintf(&b, "  Hint        %s\r\n", hints[0].Description())
+	}
+	fmt.Fprintf(&b, "  Signal      %8.3f MCPS\r\n", m.SignalRateMcps)
+	fmt.Fprintf(&b, "  Ambient     %8.3f MCPS\r\n", m.AmbientRateMcps)
+	fmt.Fprintf(&b, "  Sigma       %8.1f mm\r\n", m.SigmaMillimeters)
+	fmt.Fprintf(&b, "  Confidence  %8.2f\r\n\r\n", m.Confidence)
+	fmt.Fprintf(&b, "  %s\r\n\r\n", sparkline(history))
+	for i, p := range profiles {
+		fmt.Fprintf(&b, "  [%d] %-20s %s\r\n", i+1, p.Name, p.Description)
+	}
+	b.WriteString("  [q] quit\r\n")
+	os.Stdout.WriteString(b.String())
+}
+
+// Build sparkline of valid measurements, scaled to min/max of history.
+// Invalid measurements are shown as spaces.
+func sparkline(history []vl53l0x.Measurement) string {
+	var lo, hi uint16
+	first := true
+	for _, m := range history {
+		if !m.Valid() {
+			continue
+		}
+		if first || m.RangeMillimeters < lo {
+			lo = m.RangeMillimeters
+		}
+		if first || m.RangeMillimeters > hi {
+			hi = m.RangeMillimeters
+		}
+		first = false
+	}
+	var b strings.Builder
+	for _, m := range history {
+		if !m.Valid() {
+			b.WriteRune(' ')
+			continue
+		}
+		i := 0
+		if hi > lo {
+			i = int(m.RangeMillimeters-lo) * (len(sparkChars) - 1) / int(hi-lo)
+		}
+		b.WriteRune(sparkChars[i])
+	}
+	return b.String()
+}
+
+// Switch terminal to raw mode with stty, so single key presses are
+// delivered without Enter. Returns function restoring terminal mode.
+func rawTerminal() (func(), error) {
+	stty := func(args ...string) error {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = os.Stdin
+		return cmd.Run()
+	}
+	err := stty("-icanon", "-echo", "min", "1")
+	if err != nil {
+		return nil, fmt.Errorf("switching terminal to raw mode: %w", err)
+	}
+	// hide cursor
+	os.Stdout.WriteString("\033[?25l")
+	return func() {
+		os.Stdout.WriteString("\033[?25h\r\n")
+		stty("icanon", "echo")
+	}, nil
+}
+
+// Read key presses from standard input.
+func readKeys() <-chan byte {
+	keys := make(chan byte, 1)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			if n == 1 {
+				select {
+				case keys <- buf[0]:
+				case <-time.After(time.Second):
+				}
+			}
+		}
+	}()
+	return keys
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Probe I2C-bus and list VL53L0X sensors found.
+func runScan(args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	bus := fs.Int("bus", 1, "I2C-bus number")
+	fs.Parse(args)
+
+	found, err := vl53l0x.Scan(*bus, nil)
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		fmt.Printf("No sensors found on bus %d\n", *bus)
+		return nil
+	}
+	for _, f := range found {
+		fmt.Printf("0x%02x  %s revision 0x%02x (minor %d)\n",
+			f.Address, vl53l0x.ModelVl53l0x, f.Revision, f.MinorRevision)
+	}
+	return nil
+}
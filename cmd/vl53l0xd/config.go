@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a daemon configuration file structure.
+//
+// Example:
+//
+//	calibration_store: /var/lib/vl53l0xd/calibration
+//	control_socket: /run/vl53l0xd.sock
+//	sensors:
+//	  - name: door
+//	    bus: 1
+//	    address: 0x30
+//	    xshut: {chip: gpiochip0, line: 17}
+//	    profile: cover-glass-thin
+//	    period: 50ms
+//	    filters:
+//	      - {type: median, size: 5}
+//	      - {type: outlier, size: 15, k: 3}
+//	sinks:
+//	  prometheus: {listen: ":9120"}
+//	  textfile: {path: /var/lib/node_exporter/vl53l0x.prom, interval: 15s}
+//	  mqtt: {broker: "tcp://localhost:1883", discovery: true}
+//	  file: {dir: /var/log/vl53l0xd, format: csv, max_size: 10485760, max_age: 24h}
+type Config struct {
+	// directory of per module calibration blobs, keyed by module UID
+	CalibrationStore string `yaml:"calibration_store"`
+	// Unix socket path of control interface, disabled if empty;
+	// see controlRequest for protocol
+	ControlSocket string         `yaml:"control_socket"`
+	Sensors       []SensorConfig `yaml:"sensors"`
+	Sinks         SinksConfig    `yaml:"sinks"`
+}
+
+// SensorConfig describes single sensor.
+type SensorConfig struct {
+	Name    string `yaml:"name"`
+	Bus     int    `yaml:"bus"`
+	Address byte   `yaml:"address"`
+	// XSHUT line; sensors sharing bus, which have XSHUT line,
+	// are brought up one by one to assign addresses
+	XShut *LineConfig `yaml:"xshut"`
+	// profile name, see vl53l0x.Profiles
+	Profile string `yaml:"profile"`
+	// inter-measurement period, 0 for back-to-back mode
+	Period  time.Duration  `yaml:"period"`
+	Filters []FilterConfig `yaml:"filters"`
+	// recovery attempts on measurement failure, see Vl53l0x.SetAutoRecovery
+	Recovery int `yaml:"recovery"`
+}
+
+// LineConfig identifies GPIO line: either line of native GPIO chip,
+// or pin of I2C GPIO expander attached to sensor bus, for instance
+// {expander: mcp23017, address: 0x20, line: 9}.
+type LineConfig struct {
+	Chip string `yaml:"chip"`
+	// line offset of GPIO chip, or expander pin
+	Line int `yaml:"line"`
+	// "pcf8574" or "mcp23017", if line belongs to expander
+	Expander string `yaml:"expander"`
+	// expander address on sensor bus
+	Address byte `yaml:"address"`
+}
+
+// FilterConfig describes filter of pipeline.
+type FilterConfig struct {
+	// "median" or "outlier"
+	Type string `yaml:"type"`
+	// window size
+	Size int `yaml:"size"`
+	// outlier threshold in MADs
+	K float64 `yaml:"k"`
+	// median out-of-range handling: "ignore" (default), "keep" or "drop"
+	Mode string `yaml:"mode"`
+}
+
+// SinksConfig lists enabled sinks; nil means disabled.
+type SinksConfig struct {
+	Prometheus *PrometheusConfig `yaml:"prometheus"`
+	Textfile   *TextfileConfig   `yaml:"textfile"`
+	MQTT       *MQTTConfig       `yaml:"mqtt"`
+	File       *FileConfig       `yaml:"file"`
+}
+
+// PrometheusConfig describes metrics HTTP endpoint.
+type PrometheusConfig struct {
+	Listen string `yaml:"listen"`
+}
+
+// TextfileConfig describes node_exporter textfile collector output.
+type TextfileConfig struct {
+	// output file, must have ".prom" extension
+	Path string `yaml:"path"`
+	// update interval, defaults to 15s
+	Interval time.Duration `yaml:"interval"`
+}
+
+// MQTTConfig describes MQTT broker connection.
+type MQTTConfig struct {
+	Broker      string `yaml:"broker"`
+	ClientId    string `yaml:"client_id"`
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	Topic       string `yaml:"topic"`
+	StatusTopic string `yaml:"status_topic"`
+	QoS         byte   `yaml:"qos"`
+	Retain      bool   `yaml:"retain"`
+	// emit Home Assistant discovery messages
+	Discovery       bool   `yaml:"discovery"`
+	DiscoveryPrefix string `yaml:"discovery_prefix"`
+}
+
+// FileConfig describes rolling file logger.
+type FileConfig struct {
+	Dir string `yaml:"dir"`
+	// "csv" or "jsonl"
+	Format  string        `yaml:"format"`
+	MaxSize int64         `yaml:"max_size"`
+	MaxAge  time.Duration `yaml:"max_age"`
+}
+
+// Check line configuration.
+func (v *LineConfig) validate() error {
+	var pins int
+	switch v.Expander {
+	case "":
+		if v.Chip == "" {
+			return errors.New("chip is required")
+		}
+		return nil
+	case "pcf8574":
+		pins = 8
+	case "mcp23017":
+		pins = 16
+	default:
+		return fmt.Errorf("unknown expander %q", v.Expander)
+	}
+	if v.Address == 0 {
+		return errors.New("expander address is required")
+	}
+	if v.Line < 0 || v.Line >= pins {
+		return fmt.Errorf("%s has no pin %d", v.Expander, v.Line)
+	}
+	return nil
+}
+
+// Read and validate configuration file.
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	err = config.validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Check configuration consistency and fill defaults.
+func (v *Config) validate() error {
+	if len(v.Sensors) == 0 {
+		return errors.New("no sensors configured")
+	}
+	names := make(map[string]bool)
+	addrs := make(map[[2]int]bool)
+	for i := range v.Sensors {
+		s := &v.Sensors[i]
+		if s.Name == "" {
+			return fmt.Errorf("sensor #%d: name is required", i+1)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("sensor %q: duplicate name", s.Name)
+		}
+		names[s.Name] = true
+		if s.Address == 0 {
+			s.Address = 0x29
+		}
+		key := [2]int{s.Bus, int(s.Address)}
+		if addrs[key] {
+			return fmt.Errorf("sensor %q: duplicate address 0x%x on bus %d",
+				s.Name, s.Address, s.Bus)
+		}
+		addrs[key] = true
+		if x := s.XShut; x != nil {
+			err := x.validate()
+			if err != nil {
+				return fmt.Errorf("sensor %q: xshut: %w", s.Name, err)
+			}
+		}
+		if s.Profile == "" {
+			s.Profile = vl53l0x.ProfileDefault.Name
+		}
+		_, err := vl53l0x.ProfileByName(s.Profile)
+		if err != nil {
+			return fmt.Errorf("sensor %q: %w", s.Name, err)
+		}
+		for _, f := range s.Filters {
+			switch f.Type {
+			case "median":
+				if f.Mode != "" && f.Mode != "ignore" && f.Mode != "keep" && f.Mode != "drop" {
+					return fmt.Errorf("sensor %q: unknown median mode %q", s.Name, f.Mode)
+				}
+			case "outlier":
+			default:
+				return fmt.Errorf("sensor %q: unknown filter %q", s.Name, f.Type)
+			}
+			if f.Size <= 0 {
+				return fmt.Errorf("sensor %q: filter %s: size should be positive",
+					s.Name, f.Type)
+			}
+		}
+	}
+	if f := v.Sinks.File; f != nil {
+		if f.Dir == "" {
+			return errors.New("file sink: dir is required")
+		}
+		if f.Format == "" {
+			f.Format = "csv"
+		}
+		if f.Format != "csv" && f.Format != "jsonl" {
+			return fmt.Errorf("file sink: unknown format %q", f.Format)
+		}
+	}
+	if t := v.Sinks.Textfile; t != nil && filepath.Ext(t.Path) != ".prom" {
+		return errors.New("textfile sink: path should have .prom extension")
+	}
+	if m := v.Sinks.MQTT; m != nil && m.Broker == "" {
+		return errors.New("mqtt sink: broker is required")
+	}
+	if p := v.Sinks.Prometheus; p != nil && p.Listen == "" {
+		p.Listen = ":9120"
+	}
+	return nil
+}
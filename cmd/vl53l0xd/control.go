@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Control socket protocol: client sends JSON request per line and gets
+// JSON response per line, so socket could be driven by provisioning
+// tooling or manually, for instance with
+//
+//	echo '{"command":"readings"}' | socat - UNIX-CONNECT:/run/vl53l0xd.sock
+//
+// Commands:
+//
+//	readings                          last measurement of each sensor
+//	profile    sensor, profile        switch sensor profile
+//	calibrate  sensor, kind, distance_mm[, samples]
+//	                                  run "offset" or "crosstalk" calibration
+//	                                  and save it to calibration store
+//	reload                            re-read configuration file and restart
+//	                                  sensors and sinks
+type controlRequest struct {
+	Command    string  `json:"command"`
+	Sensor     string  `json:"sensor,omitempty"`
+	Profile    string  `json:"profile,omitempty"`
+	Kind       string  `json:"kind,omitempty"`
+	DistanceMm float32 `json:"distance_mm,omitempty"`
+	Samples    int     `json:"samples,omitempty"`
+}
+
+type controlResponse struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// Sensor state reported by readings command.
+type reading struct {
+	Profile     string               `json:"profile"`
+	Module      string               `json:"module"`
+	Measurement *vl53l0x.Measurement `json:"measurement"`
+}
+
+// Result of calibrate command.
+type calibrationResult struct {
+	OffsetMicrometers int32   `json:"offset_um,omitempty"`
+	CrosstalkRateMcps float32 `json:"crosstalk_rate_mcps,omitempty"`
+	Saved             bool    `json:"saved"`
+}
+
+// Control socket server, which survives daemon reloads.
+type controlServer struct {
+	sync.Mutex
+	listener net.Listener
+	daemon   *daemon
+	// reload daemon, returns error, if new configuration is rejected
+	reload func() error
+}
+
+// Listen on Unix socket path given; stale socket file is removed.
+func newControlServer(path string, reload func() error) (*controlServer, error) {
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		err = os.Remove(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Chmod(path, 0660)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	v := &controlServer{listener: listener, reload: reload}
+	return v, nil
+}
+
+// Set daemon commands are addressed to.
+func (v *controlServer) setDaemon(d *daemon) {
+	v.Lock()
+	defer v.Unlock()
+	v.daemon = d
+}
+
+// Accept connections until closed.
+func (v *controlServer) serve() {
+	lg.Infof("Listen control socket %s", v.listener.Addr())
+	for {
+		conn, err := v.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				lg.Errorf("Control socket: %s", err)
+			}
+			return
+		}
+		go v.handle(conn)
+	}
+}
+
+// Serve requests of single connection.
+func (v *controlServer) handle(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var resp controlResponse
+		var req controlRequest
+		err := json.Unmarshal(scanner.Bytes(), &req)
+		if err == nil {
+			resp.Result, err = v.execute(req)
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		err = enc.Encode(resp)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Execute single request.
+func (v *controlServer) execute(req controlRequest) (interface{}, error) {
+	lg.Debugf("Control request %+v", req)
+
+	if req.Command == "reload" {
+		return nil, v.reload()
+	}
+	v.Lock()
+	d := v.daemon
+	v.Unlock()
+	if d == nil {
+		return nil, errors.New("daemon is not running")
+	}
+	ctx := context.Background()
+	switch req.Command {
+	case "readings":
+		return d.readings(), nil
+	case "profile":
+		return nil, d.setProfile(ctx, req.Sensor, req.Profile)
+	case "calibrate":
+		return d.calibrate(ctx, req.Sensor, req.Kind, req.DistanceMm, req.Samples)
+	default:
+		return nil, fmt.Errorf("unknown command %q", req.Command)
+	}
+}
+
+// Close stop accepting connections and remove socket file.
+func (v *controlServer) close() error {
+	return v.listener.Close()
+}
+
+// Find pipeline by sensor name.
+func (v *daemon) pipeline(name string) (*pipeline, error) {
+	for _, p := range v.pipelines {
+		if p.config.Name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown sensor %q", name)
+}
+
+// Collect last measurement of each sensor.
+func (v *daemon) readings() map[string]reading {
+	readings := make(map[string]reading)
+	for _, p := range v.pipelines {
+		p.Lock()
+		readings[p.config.Name] = reading{Profile: p.config.Profile,
+			Module: p.module.Uid(), Measurement: p.last}
+		p.Unlock()
+	}
+	return readings
+}
+
+// Switch profile of sensor.
+func (v *daemon) setProfile(ctx context.Context, name, profileName string) error {
+	p, err := v.pipeline(name)
+	if err != nil {
+		return err
+	}
+	profile, err := vl53l0x.ProfileByName(profileName)
+	if err != nil {
+		return err
+	}
+	_, err = p.do(ctx, func() (interface{}, error) {
+		err := p.sensor.ApplyProfile(p.conn, profile)
+		if err != nil {
+			return nil, err
+		}
+		p.chain.Reset()
+		p.Lock()
+		p.config.Profile = profile.Name
+		p.Unlock()
+		lg.Infof("Sensor %q switched to profile %q", name, profile.Name)
+		return nil, nil
+	})
+	return err
+}
+
+// Run offset or crosstalk calibration of sensor and save
+// calibration to store, if configured.
+func (v *daemon) calibrate(ctx context.Context, name, kind string,
+	distanceMm float32, samples int) (*calibrationResult, error) {
+
+	p, err := v.pipeline(name)
+	if err != nil {
+		return nil, err
+	}
+	if kind != "offset" && kind != "crosstalk" {
+		return nil, fmt.Errorf("unknown calibration kind %q", kind)
+	}
+	r, err := p.do(ctx, func() (interface{}, error) {
+		result := &calibrationResult{}
+		var err error
+		if kind == "offset" {
+			result.OffsetMicrometers, err = p.sensor.PerformOffsetCalibration(
+				p.conn, distanceMm, samples)
+		} else {
+			result.CrosstalkRateMcps, err = p.sensor.PerformCrosstalkCalibration(
+				p.conn, distanceMm, samples)
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.chain.Reset()
+		lg.Infof("Sensor %q %s calibration done", name, kind)
+		if v.store == nil {
+			return result, nil
+		}
+		data, err := p.sensor.ExportCalibration(p.conn)
+		if err != nil {
+			return nil, err
+		}
+		err = v.store.SaveCalibration(p.module.Uid(), data)
+		if err != nil {
+			return nil, err
+		}
+		result.Saved = true
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.(*calibrationResult), nil
+}
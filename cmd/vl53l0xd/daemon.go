@@ -0,0 +1,529 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	i2c "github.com/d2r2/go-i2c"
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	"github.com/d2r2/go-vl53l0x/filelog"
+	"github.com/d2r2/go-vl53l0x/filter"
+	"github.com/d2r2/go-vl53l0x/gpio"
+	"github.com/d2r2/go-vl53l0x/mqtt"
+	vlprom "github.com/d2r2/go-vl53l0x/prometheus"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/sync/errgroup"
+)
+
+// Delay before stream is restarted after acquisition error.
+const restartDelay = time.Second * 5
+
+var errPipelineStopped = errors.New("pipeline is stopped")
+
+// Measurement pipeline of single sensor: sensor stream
+// passed through filters and fanned out to sinks.
+type pipeline struct {
+	sync.Mutex
+	config SensorConfig
+	sensor *vl53l0x.Vl53l0x
+	conn   vl53l0x.Bus
+	// connection owned by pipeline, nil for array sensors
+	own    *i2c.I2C
+	module *vl53l0x.ModuleInfo
+	chain  *filter.Chain
+	// sinks
+	exporter  *vlprom.Exporter
+	publisher *mqtt.Publisher
+	logger    *filelog.Logger
+	// operations requested by control interface,
+	// executed while stream is stopped
+	ops chan pipelineOp
+	// closed, when pipelines are stopped
+	stopped <-chan struct{}
+	// last measurement delivered
+	last *vl53l0x.Measurement
+}
+
+// Operation executed on sensor by pipeline goroutine.
+type pipelineOp struct {
+	fn   func() (interface{}, error)
+	done chan opResult
+}
+
+// Result of pipeline operation.
+type opResult struct {
+	result interface{}
+	err    error
+}
+
+// Daemon brings up sensors described by configuration
+// and runs their pipelines.
+type daemon struct {
+	config *Config
+	store  vl53l0x.CalibrationStore
+	lines  []*gpio.XShutLine
+	// GPIO expanders by bus and address, and their connections
+	expanders map[[2]int]gpio.Expander
+	conns     []*i2c.I2C
+	arrays    []*vl53l0x.Array
+	pipelines []*pipeline
+	registry  *prom.Registry
+	server    *http.Server
+	textfile  *vlprom.TextfileWriter
+	stopped   chan struct{}
+}
+
+// Create daemon for configuration given.
+func newDaemon(config *Config) *daemon {
+	v := &daemon{config: config, stopped: make(chan struct{})}
+	return v
+}
+
+// Start bring up sensors and create sinks, returning once they are ready;
+// bring-up is interrupted, when context is done. Call close to release
+// resources, even if start failed.
+func (v *daemon) start(ctx context.Context) error {
+	err := v.bringUp(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range v.pipelines {
+		err = v.setup(p)
+		if err != nil {
+			return fmt.Errorf("sensor %q: %w", p.config.Name, err)
+		}
+	}
+	return v.startSinks()
+}
+
+// Bring up sensors: those with XSHUT line are grouped by bus
+// to arrays, others are connected directly.
+func (v *daemon) bringUp(ctx context.Context) error {
+	if v.config.CalibrationStore != "" {
+		v.store = vl53l0x.NewDirCalibrationStore(v.config.CalibrationStore)
+	}
+	arrays := make(map[int]*vl53l0x.Array)
+	members := make(map[*vl53l0x.ArraySensor]*pipeline)
+	for _, sc := range v.config.Sensors {
+		p := &pipeline{config: sc, ops: make(chan pipelineOp), stopped: v.stopped}
+		v.pipelines = append(v.pipelines, p)
+		if sc.XShut == nil {
+			continue
+		}
+		setLevel, err := v.xshutLine(sc)
+		if err != nil {
+			return fmt.Errorf("sensor %q: %w", sc.Name, err)
+		}
+		array, ok := arrays[sc.Bus]
+		if !ok {
+			array = vl53l0x.NewArray(sc.Bus, v.store)
+			arrays[sc.Bus] = array
+			v.arrays = append(v.arrays, array)
+		}
+		s := array.Add(sc.Name, vl53l0x.NewXShutController(setLevel), sc.Address)
+		members[s] = p
+	}
+	for _, array := range v.arrays {
+		err := array.BringUp()
+		if err != nil {
+			return err
+		}
+		for _, s := range array.Sensors() {
+			p := members[s]
+			p.sensor, p.conn, p.module = s.Sensor, s.I2C, s.Module
+		}
+	}
+	for _, p := range v.pipelines {
+		if p.sensor != nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		lg.Infof("Connect sensor %q at address 0x%x on bus %d",
+			p.config.Name, p.config.Address, p.config.Bus)
+		conn, err := i2c.NewI2C(p.config.Address, p.config.Bus)
+		if err != nil {
+			return fmt.Errorf("sensor %q: %w", p.config.Name, err)
+		}
+		p.own, p.conn = conn, conn
+		p.sensor = vl53l0x.NewVl53l0x()
+		err = p.sensor.Init(conn)
+		if err != nil {
+			return fmt.Errorf("sensor %q: %w", p.config.Name, err)
+		}
+		p.module, err = p.sensor.GetModuleInfo(conn)
+		if err != nil {
+			return fmt.Errorf("sensor %q: %w", p.config.Name, err)
+		}
+		err = v.loadCalibration(p)
+		if err != nil {
+			return fmt.Errorf("sensor %q: %w", p.config.Name, err)
+		}
+	}
+	return nil
+}
+
+// Load calibration of directly connected sensor from store, if any;
+// array sensors load it during bring-up.
+func (v *daemon) loadCalibration(p *pipeline) error {
+	if v.store == nil {
+		return nil
+	}
+	data, err := v.store.LoadCalibration(p.module.Uid())
+	if err == vl53l0x.ErrCalibrationNotFound {
+		lg.Infof("No calibration found for sensor %q (module %s)",
+			p.config.Name, p.module.Uid())
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return p.sensor.ImportCalibration(p.conn, data)
+}
+
+// Apply profile and build filter chain of pipeline.
+func (v *daemon) setup(p *pipeline) error {
+	profile, err := vl53l0x.ProfileByName(p.config.Profile)
+	if err != nil {
+		return err
+	}
+	err = p.sensor.ApplyProfile(p.conn, profile)
+	if err != nil {
+		return err
+	}
+	p.sensor.SetAutoRecovery(p.config.Recovery)
+	p.chain = filter.NewChain()
+	for _, f := range p.config.Filters {
+		switch f.Type {
+		case "median":
+			mode := filter.IgnoreOutOfRange
+			switch f.Mode {
+			case "keep":
+				mode = filter.KeepOutOfRange
+			case "drop":
+				mode = filter.DropOutOfRange
+			}
+			p.chain.Append(filter.NewMedian(f.Size, mode))
+		case "outlier":
+			p.chain.Append(filter.NewOutlier(f.Size, f.K))
+		}
+	}
+	lg.Infof("Sensor %q (module %s) ready, profile %q",
+		p.config.Name, p.module.Uid(), profile.Name)
+	return nil
+}
+
+// Request XSHUT line of sensor: native GPIO line, or pin of expander
+// on sensor bus, which is shared by sensors with the same expander.
+func (v *daemon) xshutLine(sc SensorConfig) (func(high bool) error, error) {
+	x := sc.XShut
+	if x.Expander == "" {
+		line, err := gpio.NewXShutLine(x.Chip, x.Line)
+		if err != nil {
+			return nil, err
+		}
+		v.lines = append(v.lines, line)
+		return line.SetLevel, nil
+	}
+	key := [2]int{sc.Bus, int(x.Address)}
+	expander, ok := v.expanders[key]
+	if !ok {
+		conn, err := i2c.NewI2C(x.Address, sc.Bus)
+		if err != nil {
+			return nil, err
+		}
+		v.conns = append(v.conns, conn)
+		switch x.Expander {
+		case "pcf8574":
+			expander, err = gpio.NewPCF8574(conn)
+		case "mcp23017":
+			expander, err = gpio.NewMCP23017(conn)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s at 0x%x: %w", x.Expander, x.Address, err)
+		}
+		if v.expanders == nil {
+			v.expanders = make(map[[2]int]gpio.Expander)
+		}
+		v.expanders[key] = expander
+	}
+	pin, err := expander.Pin(x.Line)
+	if err != nil {
+		return nil, err
+	}
+	return pin.SetLevel, nil
+}
+
+// Create sinks of all pipelines and start metrics endpoint.
+func (v *daemon) startSinks() error {
+	sinks := v.config.Sinks
+	if sinks.Prometheus != nil || sinks.Textfile != nil {
+		v.registry = prom.NewRegistry()
+	}
+	for _, p := range v.pipelines {
+		if v.registry != nil {
+			p.exporter = vlprom.NewExporter(p.sensor, vlprom.Labels{Bus: p.config.Bus,
+				Address: p.config.Address, Name: p.config.Name})
+			err := v.registry.Register(p.exporter)
+			if err != nil {
+				return err
+			}
+		}
+		if sinks.MQTT != nil {
+			err := v.startPublisher(p, sinks.MQTT)
+			if err != nil {
+				return fmt.Errorf("sensor %q: mqtt: %w", p.config.Name, err)
+			}
+		}
+		if sinks.File != nil {
+			format := filelog.CSV
+			if sinks.File.Format == "jsonl" {
+				format = filelog.JSONL
+			}
+			logger, err := filelog.NewLogger(sinks.File.Dir, p.config.Name, format,
+				sinks.File.MaxSize, sinks.File.MaxAge)
+			if err != nil {
+				return fmt.Errorf("sensor %q: file: %w", p.config.Name, err)
+			}
+			p.logger = logger
+		}
+	}
+	if sinks.Textfile != nil {
+		v.textfile = vlprom.NewTextfileWriter(sinks.Textfile.Path,
+			v.registry, sinks.Textfile.Interval)
+	}
+	if sinks.Prometheus != nil {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(v.registry, promhttp.HandlerOpts{}))
+		v.server = &http.Server{Addr: sinks.Prometheus.Listen, Handler: mux}
+	}
+	return nil
+}
+
+// Connect MQTT publisher of pipeline.
+func (v *daemon) startPublisher(p *pipeline, config *MQTTConfig) error {
+	clientId := config.ClientId
+	if clientId == "" {
+		clientId = "vl53l0xd"
+	}
+	opts := paho.NewClientOptions().AddBroker(config.Broker).
+		SetClientID(clientId + "-" + p.config.Name).
+		SetUsername(config.Username).SetPassword(config.Password)
+	publisher, err := mqtt.NewPublisher(opts, mqtt.Config{Name: p.config.Name,
+		Bus: p.config.Bus, Address: p.config.Address, Topic: config.Topic,
+		StatusTopic: config.StatusTopic, QoS: config.QoS, Retain: config.Retain})
+	if err != nil {
+		return err
+	}
+	p.publisher = publisher
+	if config.Discovery {
+		err = publisher.PublishDiscovery(config.DiscoveryPrefix,
+			mqtt.Device{Identifier: "vl53l0x_" + p.module.Uid(), Name: p.config.Name})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run pipelines, metrics endpoint and textfile writer under errgroup and
+// block until context is done. Returns the first failure, which stops the
+// rest: metrics endpoint failing to serve, for instance. Pipelines restart
+// acquisition after errors, and textfile writer errors are logged only,
+// as with other sinks.
+func (v *daemon) run(ctx context.Context) error {
+	defer close(v.stopped)
+	g, ctx := errgroup.WithContext(ctx)
+	if v.server != nil {
+		g.Go(func() error {
+			return v.serveMetrics(ctx)
+		})
+	}
+	if v.textfile != nil {
+		g.Go(func() error {
+			err := v.textfile.Run(ctx)
+			if err != nil {
+				lg.Errorf("Textfile: %s", err)
+			}
+			return nil
+		})
+	}
+	for _, p := range v.pipelines {
+		g.Go(func() error {
+			v.runPipeline(ctx, p)
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// Serve metrics endpoint, until context is done.
+func (v *daemon) serveMetrics(ctx context.Context) error {
+	errc := make(chan error, 1)
+	go func() {
+		lg.Infof("Serve metrics on %s", v.server.Addr)
+		errc <- v.server.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		return fmt.Errorf("metrics endpoint: %w", err)
+	case <-ctx.Done():
+	}
+	sctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := v.server.Shutdown(sctx)
+	<-errc
+	return err
+}
+
+// Stream measurements of single sensor to sinks, restarting
+// stream after acquisition error, until context is done.
+// Stream is stopped for operations requested by control interface.
+func (v *daemon) runPipeline(ctx context.Context, p *pipeline) {
+	for {
+		sctx, cancel := context.WithCancel(ctx)
+		errc := make(chan error, 1)
+		go func() {
+			errc <- v.stream(sctx, p)
+		}()
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-errc
+			return
+		case op := <-p.ops:
+			cancel()
+			<-errc
+			p.execute(op)
+			continue
+		case err := <-errc:
+			cancel()
+			lg.Warnf("Sensor %q: %s, restart in %v", p.config.Name, err, restartDelay)
+		}
+		timer := time.NewTimer(restartDelay)
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case op := <-p.ops:
+				p.execute(op)
+			case <-timer.C:
+				break wait
+			}
+		}
+	}
+}
+
+// Run operation and report result.
+func (v *pipeline) execute(op pipelineOp) {
+	result, err := op.fn()
+	op.done <- opResult{result: result, err: err}
+}
+
+// Request operation to be run, while stream is stopped.
+func (v *pipeline) do(ctx context.Context,
+	fn func() (interface{}, error)) (interface{}, error) {
+
+	op := pipelineOp{fn: fn, done: make(chan opResult, 1)}
+	select {
+	case v.ops <- op:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-v.stopped:
+		return nil, errPipelineStopped
+	}
+	r := <-op.done
+	return r.result, r.err
+}
+
+// Last measurement delivered, nil if none yet.
+func (v *pipeline) lastMeasurement() *vl53l0x.Measurement {
+	v.Lock()
+	defer v.Unlock()
+	return v.last
+}
+
+// Run single acquisition session, return acquisition error.
+func (v *daemon) stream(ctx context.Context, p *pipeline) error {
+	a := vl53l0x.NewAcquisition(p.sensor, p.conn, p.config.Period)
+	sub := a.Hub().Subscribe(0)
+	err := a.Start(ctx)
+	if err != nil {
+		return err
+	}
+	for m := range p.chain.Attach(sub.C) {
+		v.dispatch(p, m)
+	}
+	err = a.Wait()
+	if err != nil {
+		// acquisition error isn't published to hub, deliver it to sinks
+		v.dispatch(p, vl53l0x.Measurement{Timestamp: time.Now(), Err: err})
+		return err
+	}
+	if ctx.Err() == nil {
+		return errors.New("stream closed")
+	}
+	return nil
+}
+
+// Deliver measurement to sinks of pipeline; sink errors are logged only,
+// so failing sink doesn't stop others.
+func (v *daemon) dispatch(p *pipeline, m vl53l0x.Measurement) {
+	p.Lock()
+	p.last = &m
+	p.Unlock()
+	if p.exporter != nil {
+		p.exporter.Observe(m)
+	}
+	if p.publisher != nil {
+		err := p.publisher.Publish(m)
+		if err != nil {
+			lg.Warnf("Sensor %q: mqtt: %s", p.config.Name, err)
+		}
+	}
+	if p.logger != nil {
+		err := p.logger.Write(m)
+		if err != nil {
+			lg.Warnf("Sensor %q: file: %s", p.config.Name, err)
+		}
+	}
+}
+
+// Close stop sinks and release sensors.
+func (v *daemon) close() error {
+	var firstErr error
+	keep := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, p := range v.pipelines {
+		if p.publisher != nil {
+			keep(p.publisher.Close())
+		}
+		if p.logger != nil {
+			keep(p.logger.Close())
+		}
+		if p.own != nil {
+			keep(p.own.Close())
+		}
+	}
+	for _, array := range v.arrays {
+		keep(array.Close())
+	}
+	for _, line := range v.lines {
+		keep(line.Close())
+	}
+	for _, conn := range v.conns {
+		keep(conn.Close())
+	}
+	return firstErr
+}
@@ -0,0 +1,144 @@
+// Command vl53l0xd is a daemon, which brings up VL53L0X sensors described
+// by YAML configuration file, filters their measurements and delivers them
+// to MQTT broker, Prometheus metrics endpoint and rolling log files, so the
+// package could be deployed as an appliance without writing Go code.
+//
+// Usage:
+//
+//	vl53l0xd -config /etc/vl53l0xd.yaml
+//
+// See Config for configuration file structure. Send SIGHUP to reload
+// configuration; optional control socket allows to query readings, switch
+// profiles, recalibrate sensors and reload configuration at runtime.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	logger "github.com/d2r2/go-logger"
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+var lg = logger.NewPackageLogger("main",
+	logger.InfoLevel,
+)
+
+func main() {
+	defer logger.FinalizeLogger()
+	logger.ChangePackageLogLevel("i2c", logger.InfoLevel)
+	vl53l0x.SetLogLevel(logger.InfoLevel)
+
+	path := flag.String("config", "/etc/vl53l0xd.yaml", "configuration file")
+	flag.Parse()
+
+	err := run(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "vl53l0xd: %s\n", err)
+		logger.FinalizeLogger()
+		os.Exit(1)
+	}
+}
+
+// Load configuration, start daemon and run it until terminated.
+// On SIGHUP or reload request from control socket configuration is
+// read again and, if valid, daemon is restarted with it.
+func run(path string) error {
+	config, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	reloads := make(chan chan error)
+	var control *controlServer
+	if config.ControlSocket != "" {
+		control, err = newControlServer(config.ControlSocket, func() error {
+			reply := make(chan error, 1)
+			select {
+			case reloads <- reply:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return <-reply
+		})
+		if err != nil {
+			return err
+		}
+		defer control.close()
+		go control.serve()
+	}
+
+	for config != nil {
+		d := newDaemon(config)
+		err = d.start(ctx)
+		if err != nil {
+			d.close()
+			return err
+		}
+		lg.Infof("Started %d sensor(s)", len(d.pipelines))
+		if control != nil {
+			control.setDaemon(d)
+		}
+		dctx, cancel := context.WithCancel(ctx)
+		finished := make(chan error, 1)
+		go func() {
+			finished <- d.run(dctx)
+			// stop waiting for reload, if daemon failed
+			cancel()
+		}()
+
+		next := waitReload(dctx, path, hup, reloads)
+		if control != nil {
+			control.setDaemon(nil)
+			if next != nil && next.ControlSocket != config.ControlSocket {
+				lg.Warn("Control socket change requires restart")
+			}
+		}
+		cancel()
+		err = <-finished
+		d.close()
+		if err != nil {
+			return err
+		}
+		config = next
+	}
+	lg.Info("Stopped")
+	return nil
+}
+
+// Wait for reload request and return new configuration; invalid
+// configuration is rejected, so daemon keeps running. Returns nil,
+// when context is done.
+func waitReload(ctx context.Context, path string, hup <-chan os.Signal,
+	reloads <-chan chan error) *Config {
+
+	for {
+		var reply chan error
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+		case reply = <-reloads:
+		}
+		lg.Info("Reload configuration")
+		config, err := loadConfig(path)
+		if err != nil {
+			lg.Errorf("Configuration rejected: %s", err)
+		}
+		if reply != nil {
+			reply <- err
+		}
+		if err == nil {
+			return config
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package coap
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Message types.
+type msgType byte
+
+const (
+	typeCON msgType = iota
+	typeNON
+	typeACK
+	typeRST
+)
+
+// Message codes, class in upper 3 bits and detail in lower 5 bits.
+const (
+	codeEmpty            = 0x00
+	codeGET              = 0x01
+	codeContent          = 0x45 // 2.05
+	codeBadOption        = 0x82 // 4.02
+	codeNotFound         = 0x84 // 4.04
+	codeMethodNotAllowed = 0x85 // 4.05
+)
+
+// Option numbers.
+const (
+	optUriHost       = 3
+	optObserve       = 6
+	optUriPort       = 7
+	optUriPath       = 11
+	optContentFormat = 12
+	optMaxAge        = 14
+	optAccept        = 17
+)
+
+// Content formats.
+const (
+	formatLinkFormat = 40
+	formatJSON       = 50
+)
+
+// Protocol version and payload marker.
+const (
+	version       = 1
+	payloadMarker = 0xFF
+)
+
+// Maximum message size recommended by RFC 7252.
+const maxMessageSize = 1152
+
+var errMalformed = errors.New("malformed message")
+
+// Single option; repeatable options appear several times.
+type option struct {
+	number uint16
+	value  []byte
+}
+
+// CoAP message (RFC 7252, section 3).
+type message struct {
+	typ     msgType
+	code    byte
+	id      uint16
+	token   []byte
+	options []option
+	payload []byte
+}
+
+// Parse datagram to message.
+func parseMessage(b []byte) (*message, error) {
+	if len(b) < 4 || b[0]>>6 != version {
+		return nil, errMalformed
+	}
+	tkl := int(b[0] & 0x0F)
+	if tkl > 8 || len(b) < 4+tkl {
+		return nil, errMalformed
+	}
+	m := &message{typ: msgType(b[0] >> 4 & 0x03), code: b[1],
+		id: binary.BigEndian.Uint16(b[2:4]), token: b[4 : 4+tkl]}
+	b = b[4+tkl:]
+	var number uint16
+	for len(b) > 0 {
+		if b[0] == payloadMarker {
+			if len(b) == 1 {
+				return nil, errMalformed
+			}
+			m.payload = b[1:]
+			break
+		}
+		delta, length := uint16(b[0]>>4), uint16(b[0]&0x0F)
+		b = b[1:]
+		var err error
+		delta, b, err = extendedNibble(delta, b)
+		if err != nil {
+			return nil, err
+		}
+		length, b, err = extendedNibble(length, b)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) < int(length) {
+			return nil, errMalformed
+		}
+		number += delta
+		m.options = append(m.options, option{number: number, value: b[:length]})
+		b = b[length:]
+	}
+	return m, nil
+}
+
+// Decode extended option delta or length.
+func extendedNibble(n uint16, b []byte) (uint16, []byte, error) {
+	switch n {
+	case 13:
+		if len(b) < 1 {
+			return 0, nil, errMalformed
+		}
+		return uint16(b[0]) + 13, b[1:], nil
+	case 14:
+		if len(b) < 2 {
+			return 0, nil, errMalformed
+		}
+		return binary.BigEndian.Uint16(b) + 269, b[2:], nil
+	case 15:
+		return 0, nil, errMalformed
+	default:
+		return n, b, nil
+	}
+}
+
+// Encode option delta or length to nibble and extended bytes.
+func encodeNibble(n uint16) (byte, []byte) {
+	switch {
+	case n < 13:
+		return byte(n), nil
+	case n < 269:
+		return 13, []byte{byte(n - 13)}
+	default:
+		return 14, binary.BigEndian.AppendUint16(nil, n-269)
+	}
+}
+
+// Marshal message to datagram.
+func (v *message) marshal() []byte {
+	b := make([]byte, 4, 4+len(v.token)+len(v.payload)+16)
+	b[0] = version<<6 | byte(v.typ)<<4 | byte(len(v.token))
+	b[1] = v.code
+	binary.BigEndian.PutUint16(b[2:], v.id)
+	b = append(b, v.token...)
+	options := append([]option(nil), v.options...)
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].number < options[j].number
+	})
+	var number uint16
+	for _, o := range options {
+		delta, deltaExt := encodeNibble(o.number - number)
+		length, lengthExt := encodeNibble(uint16(len(o.value)))
+		b = append(b, delta<<4|length)
+		b = append(b, deltaExt...)
+		b = append(b, lengthExt...)
+		b = append(b, o.value...)
+		number = o.number
+	}
+	if len(v.payload) > 0 {
+		b = append(b, payloadMarker)
+		b = append(b, v.payload...)
+	}
+	return b
+}
+
+// Returns first option value with number given.
+func (v *message) option(number uint16) ([]byte, bool) {
+	for _, o := range v.options {
+		if o.number == number {
+			return o.value, true
+		}
+	}
+	return nil, false
+}
+
+// Returns resource path built from Uri-Path options.
+func (v *message) path() string {
+	var segments []string
+	for _, o := range v.options {
+		if o.number == optUriPath {
+			segments = append(segments, string(o.value))
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Check, that message has no unrecognized critical options
+// (odd option numbers).
+func (v *message) criticalOptionsKnown() bool {
+	for _, o := range v.options {
+		switch o.number {
+		case optUriHost, optUriPort, optUriPath, optAccept:
+			continue
+		}
+		if o.number&1 == 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode unsigned integer option value with minimal length.
+func encodeUint(u uint32) []byte {
+	b := binary.BigEndian.AppendUint32(nil, u)
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+// Decode unsigned integer option value.
+func decodeUint(b []byte) uint32 {
+	var u uint32
+	for _, c := range b {
+		u = u<<8 | uint32(c)
+	}
+	return u
+}
@@ -0,0 +1,312 @@
+// Package coap exposes latest VL53L0X measurement as CoAP resource
+// (RFC 7252) with observe option (RFC 7641) for push updates, targeting
+// constrained IoT networks, where HTTP and MQTT are too heavy.
+//
+// Resource representation is measurement JSON (content format 50).
+// Resources are listed at /.well-known/core in CoRE link format.
+// Notifications are sent as non-confirmable messages; every
+// confirmEvery-th one is confirmable, and observer, which doesn't
+// acknowledge it till the next confirmable one, is dropped.
+package coap
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Default CoAP UDP port.
+const DefaultPort = 5683
+
+// Every n-th notification is sent as confirmable message to check,
+// that observer is still alive.
+const confirmEvery = 32
+
+// Maximum observers per resource.
+const maxObservers = 64
+
+// Client registered to observe resource.
+type observer struct {
+	addr  net.Addr
+	token []byte
+	// ID of last notification sent
+	lastId uint16
+	// confirmable notification is not acknowledged yet
+	pending bool
+}
+
+// Resource keeps latest measurement representation
+// of single sensor and its observers.
+type Resource struct {
+	sync.Mutex
+	server    *Server
+	path      string
+	payload   []byte
+	seq       uint32
+	observers map[string]*observer
+}
+
+// Update resource with measurement and notify observers.
+func (v *Resource) Update(m vl53l0x.Measurement) error {
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	v.Lock()
+	defer v.Unlock()
+	v.payload = payload
+	v.seq = (v.seq + 1) & 0xFFFFFF
+	for key, o := range v.observers {
+		typ := typeNON
+		if v.seq%confirmEvery == 0 {
+			if o.pending {
+				lg.Debugf("Observer %s of %q is gone", o.addr, v.path)
+				delete(v.observers, key)
+				continue
+			}
+			typ = typeCON
+			o.pending = true
+		}
+		o.lastId = v.server.nextId()
+		err = v.server.send(o.addr, v.content(typ, o.lastId, o.token, true))
+		if err != nil {
+			lg.Debugf("Notify observer %s of %q: %s", o.addr, v.path, err)
+			delete(v.observers, key)
+		}
+	}
+	return nil
+}
+
+// Run update resource from stream, for instance one returned
+// by Vl53l0x.Stream, until it's closed.
+func (v *Resource) Run(in <-chan vl53l0x.Measurement) error {
+	for m := range in {
+		err := v.Update(m)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Build 2.05 Content message with current representation.
+// Call with resource locked.
+func (v *Resource) content(typ msgType, id uint16, token []byte, observe bool) *message {
+	m := &message{typ: typ, code: codeContent, id: id, token: token,
+		payload: v.payload}
+	m.options = append(m.options,
+		option{number: optContentFormat, value: encodeUint(formatJSON)})
+	if observe {
+		m.options = append(m.options,
+			option{number: optObserve, value: encodeUint(v.seq)})
+	}
+	return m
+}
+
+// Handle GET request, registering or deregistering observer.
+func (v *Resource) get(addr net.Addr, req *message) *message {
+	v.Lock()
+	defer v.Unlock()
+	key := addr.String() + "/" + string(req.token)
+	observe := false
+	if value, ok := req.option(optObserve); ok {
+		switch decodeUint(value) {
+		case 0:
+			if _, ok := v.observers[key]; ok || len(v.observers) < maxObservers {
+				v.observers[key] = &observer{addr: addr,
+					token: append([]byte(nil), req.token...)}
+				observe = true
+			}
+		case 1:
+			delete(v.observers, key)
+		}
+	}
+	return v.content(typeNON, 0, req.token, observe)
+}
+
+// Handle acknowledge or reset of notification.
+func (v *Resource) confirm(addr net.Addr, id uint16, reset bool) {
+	v.Lock()
+	defer v.Unlock()
+	for key, o := range v.observers {
+		if o.lastId != id || o.addr.String() != addr.String() {
+			continue
+		}
+		if reset {
+			delete(v.observers, key)
+		} else {
+			o.pending = false
+		}
+	}
+}
+
+// Server is CoAP server over UDP, serving resources.
+type Server struct {
+	sync.Mutex
+	resources map[string]*Resource
+	conn      net.PacketConn
+	id        uint16
+}
+
+// NewServer creates CoAP server without resources.
+func NewServer() *Server {
+	v := &Server{resources: make(map[string]*Resource)}
+	return v
+}
+
+// AddResource creates resource on path given, for instance
+// "door/measurement"; use distinct paths for each sensor of an array.
+func (v *Server) AddResource(path string) *Resource {
+	v.Lock()
+	defer v.Unlock()
+	path = strings.Trim(path, "/")
+	r := &Resource{server: v, path: path, observers: make(map[string]*observer)}
+	v.resources[path] = r
+	return r
+}
+
+// ListenAndServe listens on UDP address (for instance ":5683")
+// and serves requests until Close called.
+func (v *Server) ListenAndServe(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return v.Serve(conn)
+}
+
+// Serve requests received on connection until Close called.
+func (v *Server) Serve(conn net.PacketConn) error {
+	v.Lock()
+	v.conn = conn
+	v.Unlock()
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		req, err := parseMessage(buf[:n])
+		if err != nil {
+			lg.Debugf("Drop message from %s: %s", addr, err)
+			continue
+		}
+		resp := v.handle(addr, req)
+		if resp == nil {
+			continue
+		}
+		err = v.send(addr, resp)
+		if err != nil {
+			lg.Debugf("Reply to %s: %s", addr, err)
+		}
+	}
+}
+
+// Close stops serving.
+func (v *Server) Close() error {
+	v.Lock()
+	defer v.Unlock()
+	if v.conn == nil {
+		return nil
+	}
+	return v.conn.Close()
+}
+
+// Returns next message ID.
+func (v *Server) nextId() uint16 {
+	v.Lock()
+	defer v.Unlock()
+	v.id++
+	return v.id
+}
+
+// Send message to address; messages are dropped, while not serving.
+func (v *Server) send(addr net.Addr, m *message) error {
+	v.Lock()
+	conn := v.conn
+	v.Unlock()
+	if conn == nil {
+		return nil
+	}
+	_, err := conn.WriteTo(m.marshal(), addr)
+	return err
+}
+
+// Handle message and returns response, if any.
+func (v *Server) handle(addr net.Addr, req *message) *message {
+	switch req.typ {
+	case typeACK, typeRST:
+		v.confirmAll(addr, req.id, req.typ == typeRST)
+		return nil
+	}
+	if req.code == codeEmpty {
+		// ping
+		if req.typ == typeCON {
+			return &message{typ: typeRST, id: req.id}
+		}
+		return nil
+	}
+	var resp *message
+	path := req.path()
+	v.Lock()
+	r := v.resources[path]
+	v.Unlock()
+	switch {
+	case req.code>>5 != 0:
+		// not a request
+		return nil
+	case req.code != codeGET:
+		resp = &message{code: codeMethodNotAllowed, token: req.token}
+	case !req.criticalOptionsKnown():
+		resp = &message{code: codeBadOption, token: req.token}
+	case path == ".well-known/core":
+		resp = &message{code: codeContent, token: req.token,
+			options: []option{{number: optContentFormat,
+				value: encodeUint(formatLinkFormat)}},
+			payload: []byte(v.links())}
+	case r == nil:
+		resp = &message{code: codeNotFound, token: req.token}
+	default:
+		resp = r.get(addr, req)
+	}
+	// piggybacked response for confirmable request
+	if req.typ == typeCON {
+		resp.typ, resp.id = typeACK, req.id
+	} else {
+		resp.typ, resp.id = typeNON, v.nextId()
+	}
+	return resp
+}
+
+// Pass acknowledge or reset to resources.
+func (v *Server) confirmAll(addr net.Addr, id uint16, reset bool) {
+	v.Lock()
+	resources := make([]*Resource, 0, len(v.resources))
+	for _, r := range v.resources {
+		resources = append(resources, r)
+	}
+	v.Unlock()
+	for _, r := range resources {
+		r.confirm(addr, id, reset)
+	}
+}
+
+// Build CoRE link format list of resources.
+func (v *Server) links() string {
+	v.Lock()
+	defer v.Unlock()
+	links := make([]string, 0, len(v.resources))
+	for path := range v.resources {
+		links = append(links, "</"+path+`>;rt="vl53l0x";ct=50;obs`)
+	}
+	sort.Strings(links)
+	return strings.Join(links, ",")
+}
@@ -0,0 +1,52 @@
+package vl53l0x
+
+import (
+	"math"
+)
+
+// Effective width of VCSEL pulse, converted to distance in millimeters:
+// 8 ns of light travel there and back.
+const pulseEffectiveWidthMm = 1200
+
+// Characteristic sigma, at which sigma component of confidence is about 0.37.
+const confidenceSigmaMm = 30
+
+// Return signal rates range in MCPS, scaled to signal component of confidence.
+const (
+	confidenceMinSignalMcps = 0.1
+	confidenceMaxSignalMcps = 10
+)
+
+// Estimate standard deviation of measured distance in millimeters.
+// It's a simplified version of VL53L0X_calc_sigma_estimate(): sigma
+// is proportional to pulse width and inversely proportional to square root
+// of collected signal events, and grows with ambient to signal ratio.
+func estimateSigma(signalMcps, ambientMcps float32, budgetUsec uint32) float32 {
+	if signalMcps <= 0 {
+		return math.MaxFloat32
+	}
+	if budgetUsec == 0 {
+		budgetUsec = 33000
+	}
+	signalEvents := float64(signalMcps) * float64(budgetUsec)
+	ambientRatio := float64(ambientMcps / signalMcps)
+	return float32(pulseEffectiveWidthMm * math.Sqrt((1+ambientRatio)/signalEvents))
+}
+
+// Compute normalized confidence in range [0..1] from range status, sigma
+// estimate, return signal rate and ambient rate. Invalid measurements
+// have zero confidence.
+func calcConfidence(status RangeStatus, sigmaMm, signalMcps, ambientMcps float32) float32 {
+	if status != RangeValid || signalMcps <= 0 {
+		return 0
+	}
+	// sigma component
+	sigma := math.Exp(-float64(sigmaMm) / confidenceSigmaMm)
+	// signal strength component, logarithmic scale
+	signal := math.Log(float64(signalMcps)/confidenceMinSignalMcps) /
+		math.Log(confidenceMaxSignalMcps/confidenceMinSignalMcps)
+	signal = math.Max(0, math.Min(1, signal))
+	// signal to noise component
+	snr := float64(signalMcps / (signalMcps + ambientMcps))
+	return float32(sigma * math.Sqrt(signal*snr))
+}
@@ -0,0 +1,146 @@
+package vl53l0x
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// CorrectionPoint is a pair of distance reported by sensor
+// and actual distance, both in millimeters.
+type CorrectionPoint struct {
+	Measured uint16
+	Actual   uint16
+}
+
+// CorrectionTable applies piecewise-linear correction to measured
+// distances. Between points distance is interpolated linearly, beyond
+// the first and the last points outermost segments are extrapolated.
+// Use it, when single offset correction is insufficient, for instance
+// with modules showing distance-dependent bias behind cover glass.
+type CorrectionTable struct {
+	points []CorrectionPoint
+}
+
+// NewCorrectionTable creates correction table from points given
+// in any order. At least 2 points with distinct measured values required.
+func NewCorrectionTable(points []CorrectionPoint) (*CorrectionTable, error) {
+	sorted := slices.Clone(points)
+	slices.SortFunc(sorted, func(a, b CorrectionPoint) int {
+		return int(a.Measured) - int(b.Measured)
+	})
+	if len(sorted) < 2 {
+		return nil, errors.New("at least 2 correction points required")
+	}
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Measured == sorted[i-1].Measured {
+			return nil, errors.New(spew.Sprintf(
+				"duplicate correction point for measured distance %d mm",
+				sorted[i].Measured))
+		}
+	}
+	v := &CorrectionTable{points: sorted}
+	return v, nil
+}
+
+// LoadCorrectionTable reads correction table from text, where each line
+// contains "measured,actual" pair of distances in millimeters. Empty
+// lines and lines starting with "#" are ignored.
+func LoadCorrectionTable(r io.Reader) (*CorrectionTable, error) {
+	var points []CorrectionPoint
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, ",")
+		if len(fields) != 2 {
+			return nil, errors.New(spew.Sprintf(
+				"line %d: expected \"measured,actual\" pair", line))
+		}
+		measured, err := strconv.ParseUint(strings.TrimSpace(fields[0]), 10, 16)
+		if err != nil {
+			return nil, errors.New(spew.Sprintf("line %d: %s", line, err))
+		}
+		actual, err := strconv.ParseUint(strings.TrimSpace(fields[1]), 10, 16)
+		if err != nil {
+			return nil, errors.New(spew.Sprintf("line %d: %s", line, err))
+		}
+		points = append(points, CorrectionPoint{Measured: uint16(measured),
+			Actual: uint16(actual)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewCorrectionTable(points)
+}
+
+// Points returns correction points sorted by measured distance.
+func (v *CorrectionTable) Points() []CorrectionPoint {
+	return slices.Clone(v.points)
+}
+
+// Correct returns corrected distance in millimeters.
+func (v *CorrectionTable) Correct(measured uint16) uint16 {
+	// find segment to interpolate on
+	i, _ := slices.BinarySearchFunc(v.points, measured,
+		func(p CorrectionPoint, t uint16) int {
+			return int(p.Measured) - int(t)
+		})
+	i = max(1, min(len(v.points)-1, i))
+	p0, p1 := v.points[i-1], v.points[i]
+	k := float64(int(p1.Actual)-int(p0.Actual)) /
+		float64(int(p1.Measured)-int(p0.Measured))
+	actual := float64(p0.Actual) + k*float64(int(measured)-int(p0.Measured))
+	return uint16(max(0, min(math.MaxUint16, math.Round(actual))))
+}
+
+// SetCorrectionTable sets table to correct distance of valid
+// measurements. Nil value disables correction, which is default.
+func (v *Vl53l0x) SetCorrectionTable(table *CorrectionTable) {
+	v.correction = table
+}
+
+// GetCorrectionTable returns correction table, if set.
+func (v *Vl53l0x) GetCorrectionTable() *CorrectionTable {
+	return v.correction
+}
+
+// SetMountingAngle sets angle in degrees between sensor axis and normal
+// to the measured surface (tank walls, bumper skirts), so valid measurements
+// are corrected to perpendicular distance: reported = measured * cos(angle).
+// Correction is applied after correction table. Zero value disables
+// correction, which is default.
+func (v *Vl53l0x) SetMountingAngle(degrees float64) error {
+	if degrees <= -90 || degrees >= 90 {
+		return errors.New("mounting angle is out of range (-90, 90) degrees")
+	}
+	v.mountingAngle = degrees
+	v.mountingCos = math.Cos(degrees * math.Pi / 180)
+	return nil
+}
+
+// GetMountingAngle returns mounting angle in degrees.
+func (v *Vl53l0x) GetMountingAngle() float64 {
+	return v.mountingAngle
+}
+
+// Apply distance corrections configured: correction table and mounting angle.
+func (v *Vl53l0x) correctRange(rng uint16) uint16 {
+	if v.correction != nil {
+		rng = v.correction.Correct(rng)
+	}
+	if v.mountingAngle != 0 {
+		rng = uint16(math.Round(float64(rng) * v.mountingCos))
+	}
+	return rng
+}
@@ -0,0 +1,109 @@
+package vl53l0x
+
+// Model is ST time-of-flight sensor model.
+type Model int
+
+const (
+	// ModelUnknown means device doesn't identify itself
+	// as any of supported models.
+	ModelUnknown Model = iota
+	ModelVl53l0x
+	ModelVl53l1x
+	ModelVl6180x
+)
+
+// String implement Stringer interface.
+func (v Model) String() string {
+	switch v {
+	case ModelVl53l0x:
+		return "VL53L0X"
+	case ModelVl53l1x:
+		return "VL53L1X"
+	case ModelVl6180x:
+		return "VL6180X"
+	default:
+		return "<unknown>"
+	}
+}
+
+// Identification registers of supported models. VL53L0X uses 8-bit
+// register index, VL53L1X and VL6180X - 16-bit one.
+const (
+	// VL53L0X IDENTIFICATION_MODEL_ID and IDENTIFICATION_MODULE_TYPE
+	moduleTypeVl53l0x = 0xAA
+	regModuleTypeL0x  = 0xC1
+	// VL53L1X IDENTIFICATION__MODEL_ID, followed by MODULE_TYPE
+	regModelIdL1x     = 0x010F
+	modelIdVl53l1x    = 0xEA
+	moduleTypeVl53l1x = 0xCC
+	// VL6180X IDENTIFICATION__MODEL_ID
+	regModelId6180x = 0x0000
+	modelIdVl6180x  = 0xB4
+)
+
+// DetectModel reads identification registers of the device at address
+// given on I2C-bus and reports, whether it's VL53L0X, VL53L1X or VL6180X,
+// so multi-model fleets could instantiate the right driver automatically.
+// All models share default address 0x29.
+func DetectModel(bus int, addr byte) (Model, error) {
+	conn, err := newI2C(addr, bus)
+	if err != nil {
+		return ModelUnknown, err
+	}
+	defer conn.Close()
+	return DetectModelOn(conn)
+}
+
+// DetectModelOn is DetectModel over established connection. VL53L0X is
+// probed first, since its registers are addressed with 8-bit index and
+// 16-bit index probes of other models would modify its configuration.
+func DetectModelOn(i2c Bus) (Model, error) {
+	modelId, err := i2c.ReadRegU8(IDENTIFICATION_MODEL_ID)
+	if err != nil {
+		return ModelUnknown, err
+	}
+	if modelId == modelIdVl53l0x {
+		moduleType, err := i2c.ReadRegU8(regModuleTypeL0x)
+		if err != nil {
+			return ModelUnknown, err
+		}
+		if moduleType == moduleTypeVl53l0x {
+			debugf("Detected %s", ModelVl53l0x)
+			return ModelVl53l0x, nil
+		}
+		// device answers with 8-bit index, so 16-bit index
+		// probes are not safe to run
+		debugf("Unknown device, model ID 0x%X, module type 0x%X",
+			modelId, moduleType)
+		return ModelUnknown, nil
+	}
+	var buf [2]byte
+	err = readReg16(i2c, regModelIdL1x, buf[:])
+	if err != nil {
+		return ModelUnknown, err
+	}
+	if buf[0] == modelIdVl53l1x && buf[1] == moduleTypeVl53l1x {
+		debugf("Detected %s", ModelVl53l1x)
+		return ModelVl53l1x, nil
+	}
+	err = readReg16(i2c, regModelId6180x, buf[:1])
+	if err != nil {
+		return ModelUnknown, err
+	}
+	if buf[0] == modelIdVl6180x {
+		debugf("Detected %s", ModelVl6180x)
+		return ModelVl6180x, nil
+	}
+	debugf("Unknown device, model ID 0x%X", modelId)
+	return ModelUnknown, nil
+}
+
+// Read registers addressed with 16-bit index.
+func readReg16(i2c Bus, reg uint16, buf []byte) error {
+	_, err := i2c.WriteBytes([]byte{byte(reg >> 8), byte(reg)})
+	if err != nil {
+		return err
+	}
+	_, err = i2c.ReadBytes(buf)
+	return err
+}
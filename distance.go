@@ -0,0 +1,76 @@
+package vl53l0x
+
+import (
+	"github.com/davecgh/go-spew/spew"
+)
+
+// Distance keeps length in millimeters, providing conversion to other units.
+// Use constants to build distance from other units, for instance
+// 1.5*vl53l0x.Meter or 12*vl53l0x.Inch.
+type Distance float64
+
+// Common distance units.
+const (
+	Millimeter Distance = 1
+	Centimeter          = 10 * Millimeter
+	Meter               = 1000 * Millimeter
+	Inch                = 25.4 * Millimeter
+	Foot                = 12 * Inch
+)
+
+// Millimeters returns distance in millimeters.
+func (v Distance) Millimeters() float64 {
+	return float64(v)
+}
+
+// Centimeters returns distance in centimeters.
+func (v Distance) Centimeters() float64 {
+	return float64(v / Centimeter)
+}
+
+// Meters returns distance in meters.
+func (v Distance) Meters() float64 {
+	return float64(v / Meter)
+}
+
+// Inches returns distance in inches.
+func (v Distance) Inches() float64 {
+	return float64(v / Inch)
+}
+
+// Feet returns distance in feet.
+func (v Distance) Feet() float64 {
+	return float64(v / Foot)
+}
+
+// String implement Stringer interface.
+func (v Distance) String() string {
+	return spew.Sprintf("%g mm", float64(v))
+}
+
+// Distance returns measured range as Distance.
+func (m Measurement) Distance() Distance {
+	return Distance(m.RangeMillimeters) * Millimeter
+}
+
+// ReadDistanceSingle performs a single-shot range measurement
+// and returns the reading as Distance. Returns ErrOutOfRange,
+// when no target detected.
+func (v *Vl53l0x) ReadDistanceSingle(i2c Bus) (Distance, error) {
+	m, err := v.ReadMeasurementSingle(i2c)
+	if err != nil {
+		return 0, err
+	}
+	return m.Distance(), m.outOfRangeErr()
+}
+
+// ReadDistanceContinuous returns a range reading as Distance
+// when continuous mode is active. Returns ErrOutOfRange,
+// when no target detected.
+func (v *Vl53l0x) ReadDistanceContinuous(i2c Bus) (Distance, error) {
+	m, err := v.ReadMeasurementContinuous(i2c)
+	if err != nil {
+		return 0, err
+	}
+	return m.Distance(), m.outOfRangeErr()
+}
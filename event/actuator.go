@@ -0,0 +1,177 @@
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+// ActuatorMode specify how Actuator drives output on presence events.
+type ActuatorMode int
+
+const (
+	// Follow drives output active while object is present.
+	Follow ActuatorMode = iota + 1
+	// Pulse drives output active for pulse duration on each Enter event.
+	Pulse
+	// Latch toggles output on each Enter event.
+	Latch
+)
+
+// String implement Stringer interface.
+func (v ActuatorMode) String() string {
+	switch v {
+	case Follow:
+		return "Follow"
+	case Pulse:
+		return "Pulse"
+	case Latch:
+		return "Latch"
+	default:
+		return "<unknown>"
+	}
+}
+
+// Actuator drives output (GPIO line, relay, LED) by presence events,
+// so simple "switch light when object is closer than X for Y ms"
+// applications need no loop of their own:
+//
+//	line, _ := gpio.NewOutputLine("gpiochip0", 17, false)
+//	act := event.NewActuator(line.SetLevel, event.Follow)
+//	<-act.Run(detector.Run(stream))
+type Actuator struct {
+	sync.Mutex
+	setLevel  func(high bool) error
+	mode      ActuatorMode
+	pulse     time.Duration
+	activeLow bool
+	active    bool
+	timer     *time.Timer
+	// generation of pulse, so pulse end fired before timer was
+	// restarted doesn't finish the new pulse
+	pulseGen uint64
+	onError  func(error)
+}
+
+// Default pulse duration in Pulse mode.
+const defaultActuatorPulse = time.Millisecond * 500
+
+// NewActuator creates actuator driving output with setLevel function,
+// for instance SetLevel method of gpio.OutputLine, or any callback.
+func NewActuator(setLevel func(high bool) error, mode ActuatorMode) *Actuator {
+	v := &Actuator{setLevel: setLevel, mode: mode, pulse: defaultActuatorPulse}
+	return v
+}
+
+// SetPulse define output active duration in Pulse mode. Default is 500 ms.
+func (v *Actuator) SetPulse(pulse time.Duration) {
+	v.Lock()
+	defer v.Unlock()
+	v.pulse = pulse
+}
+
+// SetActiveLow makes output active at low level.
+func (v *Actuator) SetActiveLow(activeLow bool) {
+	v.Lock()
+	defer v.Unlock()
+	v.activeLow = activeLow
+}
+
+// OnError registers handler of output driving errors.
+// Errors are logged, if no handler registered.
+func (v *Actuator) OnError(handler func(error)) {
+	v.Lock()
+	defer v.Unlock()
+	v.onError = handler
+}
+
+// Active returns true, if output is active now.
+func (v *Actuator) Active() bool {
+	v.Lock()
+	defer v.Unlock()
+	return v.active
+}
+
+// Process presence event, driving output according to mode.
+func (v *Actuator) Process(e Event) error {
+	v.Lock()
+	defer v.Unlock()
+	switch v.mode {
+	case Follow:
+		return v.drive(e.Kind == Enter)
+	case Pulse:
+		if e.Kind != Enter {
+			return nil
+		}
+		if v.timer != nil {
+			v.timer.Stop()
+		}
+		v.pulseGen++
+		gen := v.pulseGen
+		v.timer = time.AfterFunc(v.pulse, func() { v.pulseEnd(gen) })
+		return v.drive(true)
+	case Latch:
+		if e.Kind != Enter {
+			return nil
+		}
+		return v.drive(!v.active)
+	}
+	return nil
+}
+
+// Run attach actuator to event stream. Returned channel is closed, once
+// event stream is closed; output is driven inactive at this moment.
+func (v *Actuator) Run(events <-chan Event) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range events {
+			v.report(v.Process(e))
+		}
+		v.Lock()
+		if v.timer != nil {
+			v.timer.Stop()
+		}
+		err := v.drive(false)
+		v.Unlock()
+		v.report(err)
+	}()
+	return done
+}
+
+// Finish pulse of generation given started in Pulse mode,
+// unless pulse was restarted since.
+func (v *Actuator) pulseEnd(gen uint64) {
+	v.Lock()
+	if gen != v.pulseGen {
+		v.Unlock()
+		return
+	}
+	err := v.drive(false)
+	v.Unlock()
+	v.report(err)
+}
+
+// Drive output to active or inactive state. Must be called under lock.
+func (v *Actuator) drive(active bool) error {
+	err := v.setLevel(active != v.activeLow)
+	if err != nil {
+		return err
+	}
+	v.active = active
+	return nil
+}
+
+// Report error to handler, or log it.
+func (v *Actuator) report(err error) {
+	if err == nil {
+		return
+	}
+	v.Lock()
+	handler := v.onError
+	v.Unlock()
+	if handler != nil {
+		handler(err)
+	} else {
+		lg.Warnf("Error driving actuator output: %s", err)
+	}
+}
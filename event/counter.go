@@ -0,0 +1,182 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Direction of crossing detected by Counter.
+type Direction int
+
+const (
+	// In means object crossed sensor A, then sensor B.
+	In Direction = iota + 1
+	// Out means object crossed sensor B, then sensor A.
+	Out
+)
+
+// String implement Stringer interface.
+func (v Direction) String() string {
+	switch v {
+	case In:
+		return "In"
+	case Out:
+		return "Out"
+	default:
+		return "<unknown>"
+	}
+}
+
+// Crossing reported by Counter.
+type Crossing struct {
+	Direction Direction
+	// time of the event confirmed crossing
+	Time time.Time
+	// totals after this crossing
+	InTotal  int
+	OutTotal int
+}
+
+// Sensor side of Counter.
+type side int
+
+const (
+	sideNone side = iota
+	sideA
+	sideB
+)
+
+// Counter is a directional counter for a pair of sensors mounted one after
+// another along the passage (doorway footfall counting). Crossing is
+// counted as In, when sensor A detects object and sensor B detects it within
+// timeout, and as Out in the opposite order. Next crossing is counted only
+// after both sensors see no object. Presence detection of each sensor is
+// performed by PresenceDetector, so debouncing and dwell time apply.
+// Counter is safe for concurrent use.
+type Counter struct {
+	sync.Mutex
+	a, b    *PresenceDetector
+	timeout time.Duration
+	// first sensor fired and when
+	first     side
+	firstTime time.Time
+	// crossing counted, waiting for both sensors to clear
+	counted bool
+	in, out int
+}
+
+// NewCounter creates directional counter from presence
+// detectors of sensors A and B, with timeout between them.
+func NewCounter(a, b *PresenceDetector, timeout time.Duration) *Counter {
+	v := &Counter{a: a, b: b, timeout: timeout}
+	return v
+}
+
+// ProcessA process measurement of sensor A, returns crossing, if counted.
+func (v *Counter) ProcessA(m vl53l0x.Measurement) (Crossing, bool) {
+	v.Lock()
+	defer v.Unlock()
+	e, ok := v.a.Process(m)
+	if !ok {
+		return Crossing{}, false
+	}
+	return v.handle(sideA, e)
+}
+
+// ProcessB process measurement of sensor B, returns crossing, if counted.
+func (v *Counter) ProcessB(m vl53l0x.Measurement) (Crossing, bool) {
+	v.Lock()
+	defer v.Unlock()
+	e, ok := v.b.Process(m)
+	if !ok {
+		return Crossing{}, false
+	}
+	return v.handle(sideB, e)
+}
+
+// Run attach counter to measurement streams of sensors A and B,
+// returning channel of crossings. Crossing channel is closed,
+// once both measurement streams are closed.
+func (v *Counter) Run(inA, inB <-chan vl53l0x.Measurement) <-chan Crossing {
+	out := make(chan Crossing, 1)
+	go func() {
+		defer close(out)
+		for inA != nil || inB != nil {
+			var c Crossing
+			var ok bool
+			select {
+			case m, more := <-inA:
+				if !more {
+					inA = nil
+					continue
+				}
+				c, ok = v.ProcessA(m)
+			case m, more := <-inB:
+				if !more {
+					inB = nil
+					continue
+				}
+				c, ok = v.ProcessB(m)
+			}
+			if ok {
+				out <- c
+			}
+		}
+	}()
+	return out
+}
+
+// Totals returns number of In and Out crossings counted.
+func (v *Counter) Totals() (in, out int) {
+	v.Lock()
+	defer v.Unlock()
+	return v.in, v.out
+}
+
+// Reset clears totals and state of presence detectors.
+func (v *Counter) Reset() {
+	v.Lock()
+	defer v.Unlock()
+	v.in = 0
+	v.out = 0
+	v.first = sideNone
+	v.counted = false
+	v.a.Reset()
+	v.b.Reset()
+}
+
+// Handle presence event of sensor given.
+func (v *Counter) handle(s side, e Event) (Crossing, bool) {
+	if e.Kind == Leave {
+		// pending first sensor is kept until timeout,
+		// since sensors may have a gap between beams
+		if !v.a.Present() && !v.b.Present() {
+			v.counted = false
+		}
+		return Crossing{}, false
+	}
+	if v.counted {
+		return Crossing{}, false
+	}
+	if v.first == sideNone || v.first == s ||
+		e.Time.Sub(v.firstTime) > v.timeout {
+		v.first = s
+		v.firstTime = e.Time
+		return Crossing{}, false
+	}
+	c := Crossing{Time: e.Time}
+	if v.first == sideA {
+		c.Direction = In
+		v.in++
+	} else {
+		c.Direction = Out
+		v.out++
+	}
+	c.InTotal = v.in
+	c.OutTotal = v.out
+	v.first = sideNone
+	v.counted = true
+	return c, true
+}
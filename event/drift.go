@@ -0,0 +1,138 @@
+package event
+
+import (
+	"math"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// DriftEvent reported by DriftMonitor, once drift exceeds threshold,
+// which suggests sensor recalibration (offset, crosstalk).
+type DriftEvent struct {
+	// time of the measurement confirmed drift
+	Time time.Time
+	// mean distances of reference window and of the last window in millimeters
+	ReferenceMillimeters float64
+	CurrentMillimeters   float64
+	// current distance relative to reference one in millimeters
+	DriftMillimeters float64
+}
+
+// DriftMonitor tracks slow drift of reported distance in fixed-target
+// installations (tank level, bin fill, mounting height). Mean of the first
+// valid measurements of reference window is taken as reference, then rolling
+// mean of the same number of the last valid measurements is compared against
+// it. Drift event is reported once, when difference exceeds threshold, and
+// again only after drift returns within threshold. Call Reset
+// after recalibration to take new reference.
+type DriftMonitor struct {
+	threshold float64
+	// reference window mean, valid once reference window is complete
+	reference float64
+	// rolling window of the last distances and their sum
+	window []float64
+	next   int
+	count  int
+	sum    float64
+	// reference window is complete
+	referenced bool
+	// drift reported and not returned within threshold yet
+	drifted bool
+}
+
+// NewDriftMonitor creates monitor with reference (and rolling)
+// window of size valid measurements, which reports drift beyond
+// threshold in millimeters.
+func NewDriftMonitor(size int, threshold float64) *DriftMonitor {
+	if size < 1 {
+		size = 1
+	}
+	v := &DriftMonitor{threshold: threshold, window: make([]float64, size)}
+	return v
+}
+
+// Reference returns reference distance in millimeters, and false
+// if reference window isn't complete yet.
+func (v *DriftMonitor) Reference() (float64, bool) {
+	return v.reference, v.referenced
+}
+
+// Drift returns mean distance of the last window relative to reference one
+// in millimeters, and false if reference or the last window isn't complete yet.
+func (v *DriftMonitor) Drift() (float64, bool) {
+	if !v.referenced || v.count < len(v.window) {
+		return 0, false
+	}
+	return v.sum/float64(v.count) - v.reference, true
+}
+
+// Process measurement and returns event, if drift exceeds threshold.
+// Invalid measurements are ignored.
+func (v *DriftMonitor) Process(m vl53l0x.Measurement) (DriftEvent, bool) {
+	if !m.Valid() {
+		return DriftEvent{}, false
+	}
+	rng := float64(m.RangeMillimeters)
+	if v.count == len(v.window) {
+		v.sum -= v.window[v.next]
+	} else {
+		v.count++
+	}
+	v.window[v.next] = rng
+	v.sum += rng
+	v.next = (v.next + 1) % len(v.window)
+	if !v.referenced {
+		if v.count == len(v.window) {
+			v.reference = v.sum / float64(v.count)
+			v.referenced = true
+			// the next window shouldn't overlap reference one
+			v.next, v.count, v.sum = 0, 0, 0
+		}
+		return DriftEvent{}, false
+	}
+	drift, ok := v.Drift()
+	if !ok {
+		return DriftEvent{}, false
+	}
+	if math.Abs(drift) <= v.threshold {
+		v.drifted = false
+		return DriftEvent{}, false
+	}
+	if v.drifted {
+		return DriftEvent{}, false
+	}
+	v.drifted = true
+	ts := m.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	e := DriftEvent{Time: ts, ReferenceMillimeters: v.reference,
+		CurrentMillimeters: v.reference + drift, DriftMillimeters: drift}
+	return e, true
+}
+
+// Run attach monitor to measurement stream, returning channel of events.
+// Event channel is closed, once measurement stream is closed.
+func (v *DriftMonitor) Run(in <-chan vl53l0x.Measurement) <-chan DriftEvent {
+	out := make(chan DriftEvent, 1)
+	go func() {
+		defer close(out)
+		for m := range in {
+			if e, ok := v.Process(m); ok {
+				out <- e
+			}
+		}
+	}()
+	return out
+}
+
+// Reset state, so the next valid measurements form new reference window.
+func (v *DriftMonitor) Reset() {
+	v.reference = 0
+	v.next = 0
+	v.count = 0
+	v.sum = 0
+	v.referenced = false
+	v.drifted = false
+}
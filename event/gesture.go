@@ -0,0 +1,185 @@
+package event
+
+import (
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// GestureKind is a type of gesture.
+type GestureKind int
+
+const (
+	// Swipe means object passed through the beam quickly.
+	Swipe GestureKind = iota + 1
+	// Hover means object stays in the beam, moving.
+	Hover
+	// Hold means object stays in the beam at steady distance.
+	Hold
+)
+
+// String implement Stringer interface.
+func (v GestureKind) String() string {
+	switch v {
+	case Swipe:
+		return "Swipe"
+	case Hover:
+		return "Hover"
+	case Hold:
+		return "Hold"
+	default:
+		return "<unknown>"
+	}
+}
+
+// Gesture reported by GestureDetector.
+type Gesture struct {
+	Kind GestureKind
+	// time of the measurement recognized gesture
+	Time time.Time
+	// time since object entered the beam
+	Duration time.Duration
+	// distance of the object; for Swipe it is the closest distance observed
+	RangeMillimeters uint16
+}
+
+// Default timings of GestureDetector.
+const (
+	defaultSwipeMax      = time.Millisecond * 500
+	defaultHoverMin      = time.Millisecond * 500
+	defaultHoldTime      = time.Millisecond * 1000
+	defaultHoldTolerance = 20
+)
+
+// GestureDetector recognizes simple gestures within distance given: quick
+// swipe through the beam, hover and hold at steady distance. It's
+// suitable for touchless buttons and kiosks. Hover and Hold are reported
+// once per object presence; Swipe is reported, when object leaves the beam
+// soon after entering.
+type GestureDetector struct {
+	maxDistance   uint16
+	swipeMax      time.Duration
+	hoverMin      time.Duration
+	holdTime      time.Duration
+	holdTolerance uint16
+	// object presence state
+	present bool
+	enter   time.Time
+	closest uint16
+	hovered bool
+	held    bool
+	// distance and time, since which object is steady
+	steadyRange uint16
+	steadySince time.Time
+}
+
+// NewGestureDetector creates detector recognizing gestures
+// closer than maxDistance in millimeters.
+func NewGestureDetector(maxDistance uint16) *GestureDetector {
+	v := &GestureDetector{maxDistance: maxDistance,
+		swipeMax: defaultSwipeMax, hoverMin: defaultHoverMin,
+		holdTime: defaultHoldTime, holdTolerance: defaultHoldTolerance}
+	return v
+}
+
+// SetTimings define maximum swipe duration, minimum hover duration, and
+// time object should stay within tolerance (in millimeters) to make Hold.
+// Defaults are 500 ms, 500 ms, 1 s and 20 mm.
+func (v *GestureDetector) SetTimings(swipeMax, hoverMin, holdTime time.Duration,
+	holdTolerance uint16) {
+
+	v.swipeMax = swipeMax
+	v.hoverMin = hoverMin
+	v.holdTime = holdTime
+	v.holdTolerance = holdTolerance
+}
+
+// Process measurement and returns gesture, if recognized.
+// Out-of-range measurements mean no object, other invalid
+// measurements are ignored.
+func (v *GestureDetector) Process(m vl53l0x.Measurement) (Gesture, bool) {
+	var near bool
+	switch {
+	case m.Err == nil && m.Status == vl53l0x.OutOfRange:
+		near = false
+	case !m.Valid():
+		return Gesture{}, false
+	default:
+		near = m.RangeMillimeters < v.maxDistance
+	}
+	ts := m.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	if !near {
+		if !v.present {
+			return Gesture{}, false
+		}
+		v.present = false
+		d := ts.Sub(v.enter)
+		if !v.hovered && !v.held && d <= v.swipeMax {
+			return Gesture{Kind: Swipe, Time: ts, Duration: d,
+				RangeMillimeters: v.closest}, true
+		}
+		return Gesture{}, false
+	}
+	rng := m.RangeMillimeters
+	if !v.present {
+		v.present = true
+		v.enter = ts
+		v.closest = rng
+		v.hovered = false
+		v.held = false
+		v.steadyRange = rng
+		v.steadySince = ts
+		return Gesture{}, false
+	}
+	v.closest = min(v.closest, rng)
+	if absDiff(rng, v.steadyRange) > v.holdTolerance {
+		v.steadyRange = rng
+		v.steadySince = ts
+	}
+	d := ts.Sub(v.enter)
+	if !v.held && ts.Sub(v.steadySince) >= v.holdTime {
+		v.held = true
+		return Gesture{Kind: Hold, Time: ts, Duration: d,
+			RangeMillimeters: rng}, true
+	}
+	if !v.hovered && !v.held && d >= v.hoverMin &&
+		ts.Sub(v.steadySince) < d {
+		v.hovered = true
+		return Gesture{Kind: Hover, Time: ts, Duration: d,
+			RangeMillimeters: rng}, true
+	}
+	return Gesture{}, false
+}
+
+// Run attach detector to measurement stream, returning channel of gestures.
+// Gesture channel is closed, once measurement stream is closed.
+func (v *GestureDetector) Run(in <-chan vl53l0x.Measurement) <-chan Gesture {
+	out := make(chan Gesture, 1)
+	go func() {
+		defer close(out)
+		for m := range in {
+			if g, ok := v.Process(m); ok {
+				out <- g
+			}
+		}
+	}()
+	return out
+}
+
+// Reset state to "no object".
+func (v *GestureDetector) Reset() {
+	v.present = false
+	v.hovered = false
+	v.held = false
+}
+
+// Absolute difference of two distances.
+func absDiff(a, b uint16) uint16 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,313 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// Health of the device tracked by HealthMonitor.
+type Health int
+
+const (
+	// Healthy means device delivers valid measurements.
+	Healthy Health = iota + 1
+	// Degraded means data quality suffers: errors repeat, notable share
+	// of measurements is invalid or watchdog recovered stalled sensor.
+	Degraded
+	// Failed means device delivers no usable data.
+	Failed
+)
+
+// String implement Stringer interface.
+func (v Health) String() string {
+	switch v {
+	case Healthy:
+		return "Healthy"
+	case Degraded:
+		return "Degraded"
+	case Failed:
+		return "Failed"
+	default:
+		return "<unknown>"
+	}
+}
+
+// HealthEvent reported by HealthMonitor on each change of health.
+type HealthEvent struct {
+	Time time.Time
+	From Health
+	To   Health
+	// condition, which caused the change, for instance "5 consecutive errors"
+	Reason string
+	// state at the moment of the change
+	ConsecutiveErrors int
+	InvalidRatio      float64
+	// the last acquisition error, if any
+	Err error
+}
+
+// HealthConfig defines thresholds of HealthMonitor.
+// Zero fields take default values.
+type HealthConfig struct {
+	// consecutive acquisition errors to become Degraded (default 2)
+	// and Failed (default 5)
+	DegradedErrors int
+	FailedErrors   int
+	// number of the last measurements, over which share of invalid ones
+	// is calculated (default 20); acquisition errors count as invalid,
+	// while OutOfRange doesn't, since it means no target in front of sensor
+	Window int
+	// share of invalid measurements to become Degraded (default 0.3)
+	// and Failed (default 0.9)
+	DegradedRatio float64
+	FailedRatio   float64
+	// how long device stays at least Degraded after watchdog recovery,
+	// or Failed after failed recovery (default 5 minutes)
+	TripHold time.Duration
+}
+
+// HealthMonitor tracks device health from consecutive acquisition errors,
+// share of invalid measurements and watchdog trips, reporting each change,
+// so operators get alerted before data quality collapses entirely:
+//
+//	trips := make(chan vl53l0x.RecoveryEvent, 1)
+//	watchdog.OnRecovery(func(e vl53l0x.RecoveryEvent) { trips <- e })
+//	health := event.NewHealthMonitor(nil)
+//	for e := range health.Run(stream, trips) {
+//		log.Printf("sensor is %v: %s", e.To, e.Reason)
+//	}
+//
+// Health is the worst state any of conditions gives, so device returns to
+// Healthy only once errors stop, invalid measurements leave the window and
+// trip hold time expires.
+//
+// Besides Run channel, events are published to in-process event hub, so
+// any number of consumers and sinks get them independently:
+//
+//	sub := health.Subscribe(0)
+//	go func() {
+//		for e := range sub.C {
+//			err := publisher.PublishHealthChange(e)
+//			...
+//		}
+//	}()
+type HealthMonitor struct {
+	sync.Mutex
+	config  HealthConfig
+	health  Health
+	errors  int
+	lastErr error
+	// ring of the last measurements validity
+	invalid []bool
+	next    int
+	count   int
+	// number of invalid measurements in ring
+	invalidCount int
+	// health floor set by watchdog trip and its expiration
+	tripHealth Health
+	tripUntil  time.Time
+	// subscribers of health events
+	hub *vl53l0x.EventHub[HealthEvent]
+}
+
+// NewHealthMonitor creates monitor with thresholds given;
+// nil config stands for defaults.
+func NewHealthMonitor(config *HealthConfig) *HealthMonitor {
+	v := &HealthMonitor{health: Healthy, hub: vl53l0x.NewEventHub[HealthEvent]()}
+	if config != nil {
+		v.config = *config
+	}
+	c := &v.config
+	if c.DegradedErrors <= 0 {
+		c.DegradedErrors = 2
+	}
+	if c.FailedErrors <= 0 {
+		c.FailedErrors = 5
+	}
+	if c.Window <= 0 {
+		c.Window = 20
+	}
+	if c.DegradedRatio <= 0 {
+		c.DegradedRatio = 0.3
+	}
+	if c.FailedRatio <= 0 {
+		c.FailedRatio = 0.9
+	}
+	if c.TripHold <= 0 {
+		c.TripHold = 5 * time.Minute
+	}
+	v.invalid = make([]bool, c.Window)
+	return v
+}
+
+// Health returns current health of the device.
+func (v *HealthMonitor) Health() Health {
+	v.Lock()
+	defer v.Unlock()
+	return v.health
+}
+
+// Subscribe creates subscription to health events with buffer size
+// given; zero stands for default of 16. Subscription channel is closed
+// by Close, or once measurement stream passed to Run is closed.
+func (v *HealthMonitor) Subscribe(buffer int) *vl53l0x.EventSubscription[HealthEvent] {
+	return v.hub.Subscribe(buffer)
+}
+
+// Close cancel all subscriptions to health events.
+func (v *HealthMonitor) Close() {
+	v.hub.Close()
+}
+
+// Process measurement, acquisition error passed in Err field included,
+// and returns event, if health changes.
+func (v *HealthMonitor) Process(m vl53l0x.Measurement) (HealthEvent, bool) {
+	v.Lock()
+	defer v.Unlock()
+	if m.Err != nil {
+		v.errors++
+		v.lastErr = m.Err
+	} else {
+		v.errors = 0
+	}
+	// nothing in front of sensor is valid reading as well,
+	// as PresenceDetector and other detectors treat it
+	invalid := !m.Valid() && !(m.Err == nil && m.Status == vl53l0x.OutOfRange)
+	if v.count == len(v.invalid) {
+		if v.invalid[v.next] {
+			v.invalidCount--
+		}
+	} else {
+		v.count++
+	}
+	v.invalid[v.next] = invalid
+	if invalid {
+		v.invalidCount++
+	}
+	v.next = (v.next + 1) % len(v.invalid)
+	ts := m.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	return v.update(ts)
+}
+
+// Trip process watchdog recovery event and returns event, if health
+// changes: device becomes at least Degraded after recovery, and Failed,
+// if recovery failed, for TripHold time.
+func (v *HealthMonitor) Trip(e vl53l0x.RecoveryEvent) (HealthEvent, bool) {
+	v.Lock()
+	defer v.Unlock()
+	ts := e.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	v.tripHealth = Degraded
+	if e.Err != nil {
+		v.tripHealth = Failed
+		v.lastErr = e.Err
+	}
+	v.tripUntil = ts.Add(v.config.TripHold)
+	return v.update(ts)
+}
+
+// Share of invalid measurements in the window, and false
+// if window isn't complete yet.
+func (v *HealthMonitor) ratio() (float64, bool) {
+	if v.count < len(v.invalid) {
+		return 0, false
+	}
+	return float64(v.invalidCount) / float64(v.count), true
+}
+
+// Evaluate health at time ts and build event, if it changes.
+func (v *HealthMonitor) update(ts time.Time) (HealthEvent, bool) {
+	health, reason := Healthy, "recovered"
+	worse := func(h Health, r string) {
+		if h > health {
+			health, reason = h, r
+		}
+	}
+	c := &v.config
+	if v.errors >= c.FailedErrors {
+		worse(Failed, spew.Sprintf("%d consecutive errors", v.errors))
+	} else if v.errors >= c.DegradedErrors {
+		worse(Degraded, spew.Sprintf("%d consecutive errors", v.errors))
+	}
+	ratio, ok := v.ratio()
+	if ok && ratio >= c.FailedRatio {
+		worse(Failed, spew.Sprintf("%.0f%% of measurements are invalid", ratio*100))
+	} else if ok && ratio >= c.DegradedRatio {
+		worse(Degraded, spew.Sprintf("%.0f%% of measurements are invalid", ratio*100))
+	}
+	if ts.Before(v.tripUntil) {
+		if v.tripHealth == Failed {
+			worse(Failed, "watchdog recovery failed")
+		} else {
+			worse(Degraded, "watchdog recovered stalled sensor")
+		}
+	}
+	if health == v.health {
+		return HealthEvent{}, false
+	}
+	e := HealthEvent{Time: ts, From: v.health, To: health, Reason: reason,
+		ConsecutiveErrors: v.errors, InvalidRatio: ratio, Err: v.lastErr}
+	v.health = health
+	if health == Healthy {
+		v.lastErr = nil
+	}
+	v.hub.Publish(e)
+	return e, true
+}
+
+// Run attach monitor to measurement stream and optional (nil) stream
+// of watchdog recovery events, returning channel of health events.
+// Event channel and subscriptions are closed, once measurement
+// stream is closed.
+func (v *HealthMonitor) Run(in <-chan vl53l0x.Measurement,
+	trips <-chan vl53l0x.RecoveryEvent) <-chan HealthEvent {
+
+	out := make(chan HealthEvent, 1)
+	go func() {
+		defer close(out)
+		defer v.Close()
+		for {
+			var e HealthEvent
+			var changed bool
+			select {
+			case m, ok := <-in:
+				if !ok {
+					return
+				}
+				e, changed = v.Process(m)
+			case trip, ok := <-trips:
+				if !ok {
+					trips = nil
+					continue
+				}
+				e, changed = v.Trip(trip)
+			}
+			if changed {
+				out <- e
+			}
+		}
+	}()
+	return out
+}
+
+// Reset state to Healthy, forgetting errors, measurements and trips.
+func (v *HealthMonitor) Reset() {
+	v.Lock()
+	defer v.Unlock()
+	v.health = Healthy
+	v.errors = 0
+	v.lastErr = nil
+	v.next = 0
+	v.count = 0
+	v.invalidCount = 0
+	v.tripHealth = 0
+	v.tripUntil = time.Time{}
+}
@@ -0,0 +1,170 @@
+package event
+
+import (
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Kind is a type of presence event.
+type Kind int
+
+const (
+	// Enter means object came closer than threshold.
+	Enter Kind = iota + 1
+	// Leave means object went away beyond threshold.
+	Leave
+)
+
+// String implement Stringer interface.
+func (v Kind) String() string {
+	switch v {
+	case Enter:
+		return "Enter"
+	case Leave:
+		return "Leave"
+	default:
+		return "<unknown>"
+	}
+}
+
+// Event reported by PresenceDetector.
+type Event struct {
+	Kind Kind
+	// time of the measurement confirmed event
+	Time time.Time
+	// measurement confirmed event
+	Measurement vl53l0x.Measurement
+}
+
+// PresenceDetector emits Enter/Leave events, when distance crosses
+// threshold and stays there for dwell time. It's the core of applications
+// like "is someone at the desk" or "is the parking spot occupied".
+// Feed it with filtered measurements to suppress noise.
+type PresenceDetector struct {
+	threshold uint16
+	dwell     time.Duration
+	present   bool
+	// time, when distance crossed threshold, pending confirmation
+	since time.Time
+	// debouncing: last samples votes ("near"), and required
+	// number of them to agree on state change
+	votes    []bool
+	next     int
+	count    int
+	required int
+}
+
+// NewPresenceDetector creates detector with threshold distance in
+// millimeters and dwell time required to confirm the change.
+func NewPresenceDetector(threshold uint16, dwell time.Duration) *PresenceDetector {
+	v := &PresenceDetector{threshold: threshold, dwell: dwell}
+	return v
+}
+
+// SetDebounce require k of last m samples to agree on the new state, before
+// state change is considered (dwell time is still applied on top of it).
+// This way single-frame glitches don't trigger false events. By default
+// every single sample is trusted (k = m = 1).
+func (v *PresenceDetector) SetDebounce(k, m int) {
+	if m < 1 {
+		m = 1
+	}
+	if k < 1 {
+		k = 1
+	} else if k > m {
+		k = m
+	}
+	v.votes = make([]bool, m)
+	v.next = 0
+	v.count = 0
+	v.required = k
+}
+
+// Present returns true, if object is detected now.
+func (v *PresenceDetector) Present() bool {
+	return v.present
+}
+
+// Process measurement and returns event, if state changed.
+// Out-of-range measurements mean no object, other invalid
+// measurements are ignored.
+func (v *PresenceDetector) Process(m vl53l0x.Measurement) (Event, bool) {
+	var near bool
+	switch {
+	case m.Err == nil && m.Status == vl53l0x.OutOfRange:
+		near = false
+	case !m.Valid():
+		return Event{}, false
+	default:
+		near = m.RangeMillimeters < v.threshold
+	}
+	near = v.debounce(near)
+	if near == v.present {
+		v.since = time.Time{}
+		return Event{}, false
+	}
+	ts := m.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	if v.since.IsZero() {
+		v.since = ts
+	}
+	if ts.Sub(v.since) < v.dwell {
+		return Event{}, false
+	}
+	v.present = near
+	v.since = time.Time{}
+	e := Event{Kind: Leave, Time: ts, Measurement: m}
+	if near {
+		e.Kind = Enter
+	}
+	return e, true
+}
+
+// Run attach detector to measurement stream, returning channel of events.
+// Event channel is closed, once measurement stream is closed.
+func (v *PresenceDetector) Run(in <-chan vl53l0x.Measurement) <-chan Event {
+	out := make(chan Event, 1)
+	go func() {
+		defer close(out)
+		for m := range in {
+			if e, ok := v.Process(m); ok {
+				out <- e
+			}
+		}
+	}()
+	return out
+}
+
+// Reset state to "no object".
+func (v *PresenceDetector) Reset() {
+	v.present = false
+	v.since = time.Time{}
+	v.next = 0
+	v.count = 0
+}
+
+// Register sample vote and returns state, which the last samples
+// agree on: new state if enough votes collected, current state otherwise.
+func (v *PresenceDetector) debounce(near bool) bool {
+	if len(v.votes) == 0 {
+		return near
+	}
+	v.votes[v.next] = near
+	v.next = (v.next + 1) % len(v.votes)
+	if v.count < len(v.votes) {
+		v.count++
+	}
+	var agree int
+	for _, vote := range v.votes[:v.count] {
+		if vote != v.present {
+			agree++
+		}
+	}
+	if agree >= v.required {
+		return !v.present
+	}
+	return v.present
+}
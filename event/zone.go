@@ -0,0 +1,117 @@
+// Package event contains detectors, which turn VL53L0X range readings
+// into higher level events, such as zone changes, object presence
+// or distance drift, and track device health.
+package event
+
+import (
+	"errors"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Boundary separates two neighbour zones.
+type Boundary struct {
+	// boundary distance in millimeters
+	Distance uint16
+	// half-width of dead band around boundary in millimeters:
+	// zone is changed only when distance crosses the band entirely
+	Hysteresis uint16
+}
+
+// ZoneChange describes transition from one zone to another.
+type ZoneChange struct {
+	// zone indexes; From is -1 for the first classified measurement
+	From int
+	To   int
+	// zone names
+	FromName string
+	ToName   string
+	// measurement caused transition
+	Measurement vl53l0x.Measurement
+}
+
+// ZoneClassifier maps distances into user-defined zones (for instance,
+// Near/Mid/Far) and reports zone changes. Hysteresis around each boundary
+// prevents chatter, when object sits right on the boundary.
+type ZoneClassifier struct {
+	names      []string
+	boundaries []Boundary
+	current    int
+}
+
+// NewZoneClassifier creates classifier with zones named from nearest
+// to farthest, separated by boundaries given in ascending order.
+// Number of names should be one more than number of boundaries.
+func NewZoneClassifier(boundaries []Boundary, names ...string) (*ZoneClassifier, error) {
+	if len(names) != len(boundaries)+1 {
+		return nil, errors.New("number of zone names should exceed number of boundaries by one")
+	}
+	for i := 1; i < len(boundaries); i++ {
+		if int(boundaries[i].Distance)-int(boundaries[i].Hysteresis) <=
+			int(boundaries[i-1].Distance)+int(boundaries[i-1].Hysteresis) {
+			return nil, errors.New("boundaries should be ascending and not overlapped")
+		}
+	}
+	v := &ZoneClassifier{names: names, boundaries: boundaries, current: -1}
+	return v, nil
+}
+
+// Zone returns current zone index and name. Index is -1,
+// if nothing classified yet.
+func (v *ZoneClassifier) Zone() (int, string) {
+	if v.current < 0 {
+		return -1, ""
+	}
+	return v.current, v.names[v.current]
+}
+
+// Process classify measurement and returns zone change, if any.
+// Out-of-range measurements are treated as the farthest zone,
+// other invalid measurements are ignored.
+func (v *ZoneClassifier) Process(m vl53l0x.Measurement) (ZoneChange, bool) {
+	var zone int
+	switch {
+	case m.Err == nil && m.Status == vl53l0x.OutOfRange:
+		zone = len(v.names) - 1
+	case !m.Valid():
+		return ZoneChange{}, false
+	case v.current < 0:
+		zone = v.classify(m.RangeMillimeters)
+	default:
+		zone = v.current
+		d := uint32(m.RangeMillimeters)
+		for zone < len(v.boundaries) &&
+			d > uint32(v.boundaries[zone].Distance)+uint32(v.boundaries[zone].Hysteresis) {
+			zone++
+		}
+		for zone > 0 &&
+			d+uint32(v.boundaries[zone-1].Hysteresis) < uint32(v.boundaries[zone-1].Distance) {
+			zone--
+		}
+	}
+	if zone == v.current {
+		return ZoneChange{}, false
+	}
+	change := ZoneChange{From: v.current, To: zone,
+		ToName: v.names[zone], Measurement: m}
+	if v.current >= 0 {
+		change.FromName = v.names[v.current]
+	}
+	v.current = zone
+	return change, true
+}
+
+// Reset forget current zone.
+func (v *ZoneClassifier) Reset() {
+	v.current = -1
+}
+
+// Find zone by distance, ignoring hysteresis.
+func (v *ZoneClassifier) classify(rng uint16) int {
+	for i, b := range v.boundaries {
+		if rng < b.Distance {
+			return i
+		}
+	}
+	return len(v.boundaries)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"syscall"
+	"time"
 
 	i2c "github.com/d2r2/go-i2c"
 	logger "github.com/d2r2/go-logger"
@@ -34,7 +35,7 @@ func main() {
 	lg.Notify("**********************************************************************************************")
 	// Uncomment/comment next line to suppress/increase verbosity of output
 	logger.ChangePackageLogLevel("i2c", logger.InfoLevel)
-	logger.ChangePackageLogLevel("vl53l0x", logger.InfoLevel)
+	vl53l0x.SetLogLevel(logger.InfoLevel)
 
 	sensor := vl53l0x.NewVl53l0x()
 	lg.Notify("**********************************************************************************************")
@@ -72,23 +73,23 @@ func main() {
 	lg.Notify("*** Single shot range measurement mode")
 	lg.Notify("**********************************************************************************************")
 	rng, err := sensor.ReadRangeSingleMillimeters(i2c)
-	if err != nil {
+	if err == vl53l0x.ErrOutOfRange {
+		lg.Info("Measured range is out of range")
+	} else if err != nil {
 		lg.Fatalf("Failed to measure range: %s", err)
+	} else {
+		lg.Infof("Measured range = %v mm", rng)
 	}
-	lg.Infof("Measured range = %v mm", rng)
 
 	lg.Notify("**********************************************************************************************")
 	lg.Notify("*** Continuous shot range measurement mode")
 	lg.Notify("**********************************************************************************************")
-	var freq uint32 = 100
+	freq := 100 * time.Millisecond
 	times := 20
-	lg.Infof("Made measurement each %d milliseconds, %d times", freq, times)
-	err = sensor.StartContinuous(i2c, freq)
-	if err != nil {
-		lg.Fatalf("Can't start continuous measures: %s", err)
-	}
+	lg.Infof("Made measurement each %v, %d times", freq, times)
 	// create context with cancellation possibility
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	// use done channel as a trigger to exit from signal waiting goroutine
 	done := make(chan struct{})
 	defer close(done)
@@ -100,26 +101,23 @@ func main() {
 	// run goroutine waiting for OS termination events, including keyboard Ctrl+C
 	shell.CloseContextOnSignals(cancel, done, signals...)
 
-	for i := 0; i < times; i++ {
-		rng, err = sensor.ReadRangeContinuousMillimeters(i2c)
-		if err != nil {
-			lg.Fatalf("Failed to measure range: %s", err)
-		}
-		lg.Infof("Measured range = %v mm", rng)
-		select {
-		// Check for termination request.
-		case <-ctx.Done():
-			err = sensor.StopContinuous(i2c)
-			if err != nil {
-				lg.Fatal(err)
-			}
-			lg.Fatal(ctx.Err())
-		default:
+	// Run stops continuous mode and clears interrupt by itself on exit,
+	// so sensor is ready for reconfiguration afterwards
+	count := 0
+	err = sensor.Run(ctx, i2c, freq, func(m vl53l0x.Measurement) error {
+		lg.Infof("Measured range = %v mm, status = %v", m.RangeMillimeters, m.Status)
+		count++
+		if count == times {
+			return vl53l0x.ErrStopRun
 		}
-	}
-	err = sensor.StopContinuous(i2c)
+		return nil
+	})
 	if err != nil {
-		lg.Fatalf("Error stopping continuous measures: %s", err)
+		lg.Fatalf("Failed to measure range: %s", err)
+	}
+	if ctx.Err() != nil {
+		// Check for termination request.
+		lg.Fatal(ctx.Err())
 	}
 
 	lg.Notify("**********************************************************************************************")
@@ -138,9 +136,12 @@ func main() {
 	lg.Notify("*** Single shot range measurement mode")
 	lg.Notify("**********************************************************************************************")
 	rng, err = sensor.ReadRangeSingleMillimeters(i2c)
-	if err != nil {
+	if err == vl53l0x.ErrOutOfRange {
+		lg.Info("Measured range is out of range")
+	} else if err != nil {
 		lg.Fatalf("Failed to measure range: %s", err)
+	} else {
+		lg.Infof("Measured range = %v mm", rng)
 	}
-	lg.Infof("Measured range = %v mm", rng)
 
 }
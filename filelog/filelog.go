@@ -0,0 +1,234 @@
+// Package filelog writes VL53L0X measurements to rolling CSV
+// or JSON Lines files, for offline analysis of ranging sessions
+// on devices without network access.
+package filelog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Format of log files.
+type Format int
+
+const (
+	// CSV writes comma separated values with header line.
+	CSV Format = iota + 1
+	// JSONL writes JSON object per line.
+	JSONL
+)
+
+// String implement Stringer interface.
+func (v Format) String() string {
+	switch v {
+	case CSV:
+		return "CSV"
+	case JSONL:
+		return "JSONL"
+	default:
+		return "<unknown>"
+	}
+}
+
+// File extension of format.
+func (v Format) ext() string {
+	if v == JSONL {
+		return ".jsonl"
+	}
+	return ".csv"
+}
+
+// CSV columns.
+var csvHeader = []string{"timestamp", "range_mm", "status", "valid",
+	"signal_rate_mcps", "ambient_rate_mcps", "sigma_mm", "confidence", "error"}
+
+// Time layout used in file names.
+const fileTimeLayout = "20060102-150405.000"
+
+// Logger writes measurements to files in directory, starting new file,
+// when current one exceeds size limit or age limit. Files are named
+// "<prefix>-<time>.csv" or "<prefix>-<time>.jsonl". Output is buffered;
+// call Close on shutdown to flush it.
+type Logger struct {
+	sync.Mutex
+	dir     string
+	prefix  string
+	format  Format
+	maxSize int64
+	maxAge  time.Duration
+	// current file
+	file    *os.File
+	buf     *bufio.Writer
+	csv     *csv.Writer
+	written *countingWriter
+	created time.Time
+}
+
+// Counts bytes written to underlying writer.
+type countingWriter struct {
+	w *os.File
+	n int64
+}
+
+// Write implement io.Writer interface.
+func (v *countingWriter) Write(p []byte) (int, error) {
+	n, err := v.w.Write(p)
+	v.n += int64(n)
+	return n, err
+}
+
+// NewLogger creates logger writing files to directory with prefix given.
+// Zero maxSize or maxAge disables corresponding rotation condition.
+func NewLogger(dir, prefix string, format Format, maxSize int64,
+	maxAge time.Duration) (*Logger, error) {
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	v := &Logger{dir: dir, prefix: prefix, format: format,
+		maxSize: maxSize, maxAge: maxAge}
+	return v, nil
+}
+
+// Write append measurement to current file, rotating it if required.
+func (v *Logger) Write(m vl53l0x.Measurement) error {
+	v.Lock()
+	defer v.Unlock()
+	now := time.Now()
+	if v.file != nil && (v.maxSize > 0 && v.size() >= v.maxSize ||
+		v.maxAge > 0 && now.Sub(v.created) >= v.maxAge) {
+		err := v.closeFile()
+		if err != nil {
+			return err
+		}
+	}
+	if v.file == nil {
+		err := v.openFile(now)
+		if err != nil {
+			return err
+		}
+	}
+	if v.format == JSONL {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+		_, err = v.buf.Write(data)
+		return err
+	}
+	return v.writeCSV(csvRecord(m))
+}
+
+// Run write measurements from stream, for instance one returned
+// by Vl53l0x.Stream, until it's closed, then flush and close file.
+func (v *Logger) Run(in <-chan vl53l0x.Measurement) error {
+	for m := range in {
+		err := v.Write(m)
+		if err != nil {
+			v.Close()
+			return err
+		}
+	}
+	return v.Close()
+}
+
+// Flush write buffered data to file.
+func (v *Logger) Flush() error {
+	v.Lock()
+	defer v.Unlock()
+	if v.file == nil {
+		return nil
+	}
+	return v.flush()
+}
+
+// Close flush and close current file. Next Write starts new file.
+func (v *Logger) Close() error {
+	v.Lock()
+	defer v.Unlock()
+	if v.file == nil {
+		return nil
+	}
+	return v.closeFile()
+}
+
+// Create new file and write CSV header.
+func (v *Logger) openFile(now time.Time) error {
+	name := filepath.Join(v.dir, v.prefix+"-"+now.Format(fileTimeLayout)+v.format.ext())
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	v.file = file
+	v.written = &countingWriter{w: file}
+	v.buf = bufio.NewWriter(v.written)
+	v.csv = csv.NewWriter(v.buf)
+	v.created = now
+	if v.format == CSV {
+		return v.writeCSV(csvHeader)
+	}
+	return nil
+}
+
+// Write CSV record.
+func (v *Logger) writeCSV(record []string) error {
+	err := v.csv.Write(record)
+	if err != nil {
+		return err
+	}
+	// pass record to file buffer, so size is accounted
+	v.csv.Flush()
+	return v.csv.Error()
+}
+
+// Size of current file, including buffered data.
+func (v *Logger) size() int64 {
+	return v.written.n + int64(v.buf.Buffered())
+}
+
+// Flush buffers to file.
+func (v *Logger) flush() error {
+	v.csv.Flush()
+	err := v.csv.Error()
+	if err != nil {
+		return err
+	}
+	return v.buf.Flush()
+}
+
+// Flush and close current file.
+func (v *Logger) closeFile() error {
+	err := v.flush()
+	err2 := v.file.Close()
+	v.file = nil
+	if err != nil {
+		return err
+	}
+	return err2
+}
+
+// Convert measurement to CSV record.
+func csvRecord(m vl53l0x.Measurement) []string {
+	float := func(f float32) string {
+		return strconv.FormatFloat(float64(f), 'g', 6, 32)
+	}
+	var errText string
+	if m.Err != nil {
+		errText = m.Err.Error()
+	}
+	return []string{m.Timestamp.Format(time.RFC3339Nano),
+		strconv.Itoa(int(m.RangeMillimeters)), m.Status.String(),
+		strconv.FormatBool(m.Valid()), float(m.SignalRateMcps),
+		float(m.AmbientRateMcps), float(m.SigmaMillimeters),
+		float(m.Confidence), errText}
+}
@@ -0,0 +1,85 @@
+package filter
+
+import (
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Processor is a common interface of filters: Process consume sample
+// and returns filtered measurement, or false, if no output produced.
+// Median, EWMA, Outlier and Decimator implement it.
+type Processor interface {
+	Process(m vl53l0x.Measurement) (vl53l0x.Measurement, bool)
+}
+
+// ProcessorFunc adapts ordinary function to Processor interface.
+type ProcessorFunc func(m vl53l0x.Measurement) (vl53l0x.Measurement, bool)
+
+// Process implement Processor interface.
+func (f ProcessorFunc) Process(m vl53l0x.Measurement) (vl53l0x.Measurement, bool) {
+	return f(m)
+}
+
+// Chain combines processors into pipeline, where output of each
+// processor is passed to the next one, for instance:
+//
+//	chain := filter.NewChain(filter.NewMedian(5, filter.IgnoreOutOfRange),
+//		filter.NewOutlier(15, 3))
+//
+// Sample dropped by any processor produce no output of the chain.
+type Chain struct {
+	processors []Processor
+}
+
+// NewChain creates pipeline of processors given.
+func NewChain(processors ...Processor) *Chain {
+	v := &Chain{processors: processors}
+	return v
+}
+
+// Append adds processors to the end of pipeline.
+func (v *Chain) Append(processors ...Processor) *Chain {
+	v.processors = append(v.processors, processors...)
+	return v
+}
+
+// Process implement Processor interface.
+func (v *Chain) Process(m vl53l0x.Measurement) (vl53l0x.Measurement, bool) {
+	for _, p := range v.processors {
+		var ok bool
+		m, ok = p.Process(m)
+		if !ok {
+			return m, false
+		}
+	}
+	return m, true
+}
+
+// Wrap returns source producing filtered measurements.
+func (v *Chain) Wrap(src Source) Source {
+	return wrap(src, v.Process)
+}
+
+// Attach returns channel delivering filtered measurements from channel
+// given, for instance one returned by Vl53l0x.Stream. Output channel
+// is closed, when input one is closed.
+func (v *Chain) Attach(in <-chan vl53l0x.Measurement) <-chan vl53l0x.Measurement {
+	out := make(chan vl53l0x.Measurement, cap(in))
+	go func() {
+		defer close(out)
+		for m := range in {
+			if f, ok := v.Process(m); ok {
+				out <- f
+			}
+		}
+	}()
+	return out
+}
+
+// Reset resets state of all processors, which support it.
+func (v *Chain) Reset() {
+	for _, p := range v.processors {
+		if r, ok := p.(interface{ Reset() }); ok {
+			r.Reset()
+		}
+	}
+}
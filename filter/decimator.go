@@ -0,0 +1,117 @@
+package filter
+
+import (
+	"slices"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// DecimationMode specify how Decimator turns N samples into one.
+type DecimationMode int
+
+const (
+	// DecimateKeepLast keeps the last sample of each N and drops others.
+	DecimateKeepLast DecimationMode = iota + 1
+	// DecimateMean outputs mean range of valid samples of each N.
+	DecimateMean
+	// DecimateMedian outputs median range of valid samples of each N.
+	DecimateMedian
+)
+
+// String implement Stringer interface.
+func (v DecimationMode) String() string {
+	switch v {
+	case DecimateKeepLast:
+		return "DecimateKeepLast"
+	case DecimateMean:
+		return "DecimateMean"
+	case DecimateMedian:
+		return "DecimateMedian"
+	default:
+		return "<unknown>"
+	}
+}
+
+// Decimator produces single output per N samples, so high-rate continuous
+// acquisition could feed low-rate sinks (MQTT, InfluxDB) directly:
+//
+//	chain := filter.NewChain(filter.NewDecimator(10, filter.DecimateMedian))
+//	for m := range chain.Attach(ch) {
+//		...
+//	}
+//
+// Aggregated output is the last valid measurement of N with range replaced
+// by aggregate of valid ones; if none of N is valid, the last one is output
+// as is.
+type Decimator struct {
+	mode DecimationMode
+	n    int
+	// samples taken since last output
+	count int
+	// valid ranges collected since last output and the last valid sample
+	ranges    []uint16
+	lastValid vl53l0x.Measurement
+}
+
+// NewDecimator creates decimator producing single output per n samples.
+func NewDecimator(n int, mode DecimationMode) *Decimator {
+	if n < 1 {
+		n = 1
+	}
+	v := &Decimator{mode: mode, n: n}
+	if mode != DecimateKeepLast {
+		v.ranges = make([]uint16, 0, n)
+	}
+	return v
+}
+
+// Process add sample and returns output, once n samples collected.
+// Returns false, if no output produced. Measurements with error
+// pass through unchanged and aren't counted.
+func (v *Decimator) Process(m vl53l0x.Measurement) (vl53l0x.Measurement, bool) {
+	if m.Err != nil {
+		return m, true
+	}
+	v.count++
+	if v.mode != DecimateKeepLast && m.Valid() {
+		v.ranges = append(v.ranges, m.RangeMillimeters)
+		v.lastValid = m
+	}
+	if v.count < v.n {
+		return m, false
+	}
+	if len(v.ranges) > 0 {
+		m = v.lastValid
+		m.RangeMillimeters = v.aggregate()
+	}
+	v.Reset()
+	return m, true
+}
+
+// Wrap returns source producing decimated measurements.
+func (v *Decimator) Wrap(src Source) Source {
+	return wrap(src, v.Process)
+}
+
+// Reset drops samples collected since last output.
+func (v *Decimator) Reset() {
+	v.count = 0
+	v.ranges = v.ranges[:0]
+}
+
+// Calculate aggregate of collected valid ranges.
+func (v *Decimator) aggregate() uint16 {
+	n := len(v.ranges)
+	if v.mode == DecimateMedian {
+		slices.Sort(v.ranges)
+		if n%2 == 1 {
+			return v.ranges[n/2]
+		}
+		return uint16((uint32(v.ranges[n/2-1]) + uint32(v.ranges[n/2])) / 2)
+	}
+	var sum uint32
+	for _, rng := range v.ranges {
+		sum += uint32(rng)
+	}
+	return uint16((sum + uint32(n)/2) / uint32(n))
+}
@@ -0,0 +1,79 @@
+package filter
+
+import (
+	"math"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// EWMA is an exponentially weighted moving average filter. It keeps single
+// running value instead of window of samples, so it's a lightweight
+// alternative to windowed filters for memory-constrained targets, though
+// spikes are smoothed rather than suppressed.
+type EWMA struct {
+	mode  OutOfRangeMode
+	alpha float64
+	// running average, valid once the first sample is taken
+	average float64
+	started bool
+	// weighted share of out-of-range samples, averaged the same way
+	outOfRange float64
+}
+
+// NewEWMA creates filter with smoothing factor alpha from 0 to 1: weight of
+// the new sample, so lower alpha gives smoother, but more lagging output.
+// Alpha of 2/(n+1) roughly matches moving average over n samples.
+func NewEWMA(alpha float64, mode OutOfRangeMode) *EWMA {
+	alpha = math.Max(0, math.Min(1, alpha))
+	v := &EWMA{mode: mode, alpha: alpha}
+	return v
+}
+
+// Process add sample to the running average and returns measurement with
+// range replaced by the average. Out-of-range samples never get to the
+// average: in KeepOutOfRange mode they are passed through unchanged,
+// once their weighted share exceeds half. Returns false, if no output
+// produced. Measurements with error pass through unchanged.
+func (v *EWMA) Process(m vl53l0x.Measurement) (vl53l0x.Measurement, bool) {
+	if m.Err != nil {
+		return m, true
+	}
+	if !m.Valid() {
+		switch v.mode {
+		case DropOutOfRange:
+			return m, false
+		case KeepOutOfRange:
+			v.outOfRange += v.alpha * (1 - v.outOfRange)
+			if v.outOfRange > 0.5 {
+				return m, true
+			}
+		}
+		if !v.started {
+			return m, true
+		}
+		m.RangeMillimeters = uint16(math.Round(v.average))
+		m.Status = vl53l0x.RangeValid
+		return m, true
+	}
+	v.outOfRange -= v.alpha * v.outOfRange
+	if v.started {
+		v.average += v.alpha * (float64(m.RangeMillimeters) - v.average)
+	} else {
+		v.average = float64(m.RangeMillimeters)
+		v.started = true
+	}
+	m.RangeMillimeters = uint16(math.Round(v.average))
+	return m, true
+}
+
+// Wrap returns source producing filtered measurements.
+func (v *EWMA) Wrap(src Source) Source {
+	return wrap(src, v.Process)
+}
+
+// Reset clears the running average.
+func (v *EWMA) Reset() {
+	v.average = 0
+	v.started = false
+	v.outOfRange = 0
+}
@@ -0,0 +1,35 @@
+package filter
+
+import (
+	"testing"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+func TestEWMA(t *testing.T) {
+	tests := []struct {
+		name string
+		mode OutOfRangeMode
+		in   []vl53l0x.Measurement
+		want []output
+	}{
+		{"keep", KeepOutOfRange,
+			[]vl53l0x.Measurement{valid(500), outOfRange(), valid(500),
+				outOfRange(), outOfRange(), failed(), valid(700)},
+			[]output{wantValid(500), wantValid(500), wantValid(500),
+				wantOutOfRange(), wantOutOfRange(), wantNone, wantValid(600)}},
+		{"ignore", IgnoreOutOfRange,
+			[]vl53l0x.Measurement{outOfRange(), valid(500), outOfRange(),
+				failed(), valid(700)},
+			[]output{wantOutOfRange(), wantValid(500), wantValid(500),
+				wantNone, wantValid(600)}},
+		{"drop", DropOutOfRange,
+			[]vl53l0x.Measurement{valid(500), outOfRange(), failed(), valid(700)},
+			[]output{wantValid(500), wantNone, wantNone, wantValid(600)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkFilter(t, NewEWMA(0.5, test.mode).Process, test.in, test.want)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Package filter contains filters for VL53L0X range readings,
+// suppressing noise and occasional spikes the sensor produces.
+package filter
+
+import (
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Source is anything producing measurements one by one, for instance
+// ReadRange method of vl53l0x.RangeSensor, or
+// func() (vl53l0x.Measurement, error) { return sensor.ReadMeasurementContinuous(i2c) }.
+type Source func() (vl53l0x.Measurement, error)
+
+// Distance reported by sensor, when no target detected.
+const outOfRangeMillimeters = 8190
+
+// OutOfRangeMode specify how filter treats out-of-range samples
+// (when no target detected).
+type OutOfRangeMode int
+
+const (
+	// IgnoreOutOfRange keeps out-of-range samples away from the window,
+	// output for them is produced from the samples collected so far.
+	IgnoreOutOfRange OutOfRangeMode = iota + 1
+	// KeepOutOfRange puts out-of-range samples to the window as is,
+	// so filter output becomes out-of-range when they dominate.
+	KeepOutOfRange
+	// DropOutOfRange keeps out-of-range samples away from the window
+	// and produce no output for them.
+	DropOutOfRange
+)
+
+// String implement Stringer interface.
+func (v OutOfRangeMode) String() string {
+	switch v {
+	case IgnoreOutOfRange:
+		return "IgnoreOutOfRange"
+	case KeepOutOfRange:
+		return "KeepOutOfRange"
+	case DropOutOfRange:
+		return "DropOutOfRange"
+	default:
+		return "<unknown>"
+	}
+}
+
+// Read source until process produce output.
+func wrap(src Source, process func(vl53l0x.Measurement) (vl53l0x.Measurement, bool)) Source {
+	return func() (vl53l0x.Measurement, error) {
+		for {
+			m, err := src()
+			if err != nil {
+				return m, err
+			}
+			if out, ok := process(m); ok {
+				return out, nil
+			}
+		}
+	}
+}
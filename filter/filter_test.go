@@ -0,0 +1,68 @@
+package filter
+
+import (
+	"errors"
+	"testing"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Output expected from filter for single sample.
+type output struct {
+	rng    uint16
+	status vl53l0x.RangeStatus
+	// false, if no output expected
+	ok bool
+}
+
+var errSample = errors.New("sample failed")
+
+// Sample shortcuts.
+func valid(rng uint16) vl53l0x.Measurement {
+	return vl53l0x.Measurement{RangeMillimeters: rng, Status: vl53l0x.RangeValid}
+}
+
+func outOfRange() vl53l0x.Measurement {
+	return vl53l0x.Measurement{RangeMillimeters: outOfRangeMillimeters,
+		Status: vl53l0x.OutOfRange}
+}
+
+func failed() vl53l0x.Measurement {
+	return vl53l0x.Measurement{Err: errSample}
+}
+
+// Output shortcuts.
+func wantValid(rng uint16) output {
+	return output{rng: rng, status: vl53l0x.RangeValid, ok: true}
+}
+
+func wantOutOfRange() output {
+	return output{rng: outOfRangeMillimeters, status: vl53l0x.OutOfRange, ok: true}
+}
+
+var wantNone = output{}
+
+// Feed samples to filter and compare outputs, error samples
+// are expected to pass through unchanged.
+func checkFilter(t *testing.T, process func(vl53l0x.Measurement) (vl53l0x.Measurement, bool),
+	in []vl53l0x.Measurement, want []output) {
+
+	t.Helper()
+	for i, m := range in {
+		out, ok := process(m)
+		if m.Err != nil {
+			if !ok || !errors.Is(out.Err, m.Err) {
+				t.Errorf("sample %d: error doesn't pass through", i+1)
+			}
+			continue
+		}
+		if ok != want[i].ok {
+			t.Errorf("sample %d: got output %v, want %v", i+1, ok, want[i].ok)
+			continue
+		}
+		if ok && (out.RangeMillimeters != want[i].rng || out.Status != want[i].status) {
+			t.Errorf("sample %d: got %d mm %v, want %d mm %v", i+1,
+				out.RangeMillimeters, out.Status, want[i].rng, want[i].status)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package filter
+
+import (
+	"slices"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Median is a windowed median filter, which suppress single wild
+// spikes completely, unlike averaging.
+type Median struct {
+	mode   OutOfRangeMode
+	window []uint16
+	next   int
+	count  int
+	sorted []uint16
+}
+
+// NewMedian creates median filter over last size samples.
+// Odd window size is recommended.
+func NewMedian(size int, mode OutOfRangeMode) *Median {
+	if size < 1 {
+		size = 1
+	}
+	v := &Median{mode: mode, window: make([]uint16, size),
+		sorted: make([]uint16, 0, size)}
+	return v
+}
+
+// Process add sample to the window and returns measurement with range
+// replaced by median of the window, which status is OutOfRange, if
+// out-of-range samples dominate the window, and RangeValid otherwise.
+// Returns false, if no output produced. Measurements with error
+// pass through unchanged.
+func (v *Median) Process(m vl53l0x.Measurement) (vl53l0x.Measurement, bool) {
+	if m.Err != nil {
+		return m, true
+	}
+	if !m.Valid() {
+		switch v.mode {
+		case DropOutOfRange:
+			return m, false
+		case IgnoreOutOfRange:
+			if v.count == 0 {
+				return m, true
+			}
+			m.RangeMillimeters = v.median()
+			m.Status = vl53l0x.RangeValid
+			return m, true
+		}
+	}
+	v.window[v.next] = m.RangeMillimeters
+	v.next = (v.next + 1) % len(v.window)
+	if v.count < len(v.window) {
+		v.count++
+	}
+	m.RangeMillimeters = v.median()
+	m.Status = vl53l0x.RangeValid
+	if m.RangeMillimeters >= outOfRangeMillimeters {
+		m.Status = vl53l0x.OutOfRange
+	}
+	return m, true
+}
+
+// Wrap returns source producing filtered measurements.
+func (v *Median) Wrap(src Source) Source {
+	return wrap(src, v.Process)
+}
+
+// Reset clears the window.
+func (v *Median) Reset() {
+	v.next = 0
+	v.count = 0
+}
+
+// Calculate median of collected samples.
+func (v *Median) median() uint16 {
+	v.sorted = append(v.sorted[:0], v.window[:v.count]...)
+	slices.Sort(v.sorted)
+	n := len(v.sorted)
+	if n%2 == 1 {
+		return v.sorted[n/2]
+	}
+	lo, hi := v.sorted[n/2-1], v.sorted[n/2]
+	if hi >= outOfRangeMillimeters {
+		// out-of-range samples make half of the window and don't
+		// dominate it, so they are not mixed into distance
+		return lo
+	}
+	return uint16((uint32(lo) + uint32(hi)) / 2)
+}
@@ -0,0 +1,36 @@
+package filter
+
+import (
+	"testing"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name string
+		mode OutOfRangeMode
+		in   []vl53l0x.Measurement
+		want []output
+	}{
+		{"keep", KeepOutOfRange,
+			[]vl53l0x.Measurement{valid(500), outOfRange(), outOfRange(),
+				valid(500), failed(), valid(500)},
+			[]output{wantValid(500), wantValid(500), wantOutOfRange(),
+				wantOutOfRange(), wantNone, wantValid(500)}},
+		{"ignore", IgnoreOutOfRange,
+			[]vl53l0x.Measurement{outOfRange(), valid(500), outOfRange(),
+				valid(600), failed(), valid(700)},
+			[]output{wantOutOfRange(), wantValid(500), wantValid(500),
+				wantValid(550), wantNone, wantValid(600)}},
+		{"drop", DropOutOfRange,
+			[]vl53l0x.Measurement{valid(500), outOfRange(), valid(700),
+				failed(), valid(900)},
+			[]output{wantValid(500), wantNone, wantValid(600), wantNone, wantValid(700)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkFilter(t, NewMedian(3, test.mode).Process, test.in, test.want)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package filter
+
+import (
+	"slices"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Default scale factor to make MAD consistent
+// with standard deviation of normal distribution.
+const madScale = 1.4826
+
+// Outlier rejects samples deviating from the median of recent window by more
+// than k·MAD (median absolute deviation). Unlike median filter it doesn't
+// modify accepted samples, so it's intended to be placed before averaging.
+type Outlier struct {
+	k       float64
+	minDev  uint16
+	minFill int
+	window  []uint16
+	next    int
+	count   int
+	sorted  []uint16
+}
+
+// NewOutlier creates outlier rejection stage over last size samples,
+// rejecting samples more than k·MAD away from the window median.
+// Until window collects at least half of size samples, everything is accepted.
+func NewOutlier(size int, k float64) *Outlier {
+	if size < 3 {
+		size = 3
+	}
+	v := &Outlier{k: k, minDev: 1, minFill: (size + 1) / 2,
+		window: make([]uint16, size), sorted: make([]uint16, 0, size)}
+	return v
+}
+
+// SetMinDeviation set lower bound for allowed deviation in millimeters.
+// Without it, steady readings (MAD close to zero) make the stage
+// reject any change of distance. Default is 1 mm.
+func (v *Outlier) SetMinDeviation(minDev uint16) {
+	v.minDev = minDev
+}
+
+// Process returns false, if measurement is an outlier. Rejected samples
+// are still added to the window, so real jump of distance is accepted
+// once it dominates the window. Out-of-range samples and measurements
+// with error pass through and don't affect the window.
+func (v *Outlier) Process(m vl53l0x.Measurement) (vl53l0x.Measurement, bool) {
+	if !m.Valid() {
+		return m, true
+	}
+	accept := true
+	if v.count >= v.minFill {
+		med, mad := v.stats()
+		dev := float64(m.RangeMillimeters) - float64(med)
+		if dev < 0 {
+			dev = -dev
+		}
+		limit := v.k * madScale * float64(mad)
+		if limit < float64(v.minDev) {
+			limit = float64(v.minDev)
+		}
+		accept = dev <= limit
+	}
+	v.window[v.next] = m.RangeMillimeters
+	v.next = (v.next + 1) % len(v.window)
+	if v.count < len(v.window) {
+		v.count++
+	}
+	return m, accept
+}
+
+// Wrap returns source producing only accepted measurements.
+func (v *Outlier) Wrap(src Source) Source {
+	return wrap(src, v.Process)
+}
+
+// Reset clears the window.
+func (v *Outlier) Reset() {
+	v.next = 0
+	v.count = 0
+}
+
+// Calculate median and median absolute deviation of collected samples.
+func (v *Outlier) stats() (uint16, uint16) {
+	v.sorted = append(v.sorted[:0], v.window[:v.count]...)
+	slices.Sort(v.sorted)
+	med := v.sorted[len(v.sorted)/2]
+	for i, item := range v.sorted {
+		if item > med {
+			v.sorted[i] = item - med
+		} else {
+			v.sorted[i] = med - item
+		}
+	}
+	slices.Sort(v.sorted)
+	return med, v.sorted[len(v.sorted)/2]
+}
@@ -0,0 +1,32 @@
+package vl53l0x
+
+import (
+	"math"
+)
+
+// FieldOfView is full cone angle of sensor receiver in degrees. Sensor
+// measures distance to the target, which covers the cone, so target
+// smaller than the spot at its distance gives mixed reading of target
+// and background.
+const FieldOfView = 25.0
+
+// Tangent of half field of view.
+var fovTan = math.Tan(FieldOfView / 2 * math.Pi / 180)
+
+// SpotDiameter returns diameter of area covered by field of view
+// at given distance, about 44 cm at 1 m.
+func SpotDiameter(distance Distance) Distance {
+	return 2 * distance * Distance(fovTan)
+}
+
+// TargetFillsSpot reports whether target of given size (smallest
+// dimension facing the sensor) covers whole field of view at distance.
+func TargetFillsSpot(distance, targetSize Distance) bool {
+	return targetSize >= SpotDiameter(distance)
+}
+
+// MaxTargetDistance returns the farthest distance, where target
+// of given size still covers whole field of view.
+func MaxTargetDistance(targetSize Distance) Distance {
+	return targetSize / Distance(2*fovTan)
+}
@@ -0,0 +1,98 @@
+package vl53l0x
+
+import (
+	"time"
+)
+
+// Frame keeps results of all sensors of Array, gathered
+// by single trigger call. Measurements follow the order
+// of Array.Sensors().
+type Frame struct {
+	// Time when trigger started.
+	Time time.Time
+	// Time spent to gather all results.
+	Duration     time.Duration
+	Measurements []Measurement
+}
+
+// Valid returns true, if measurements of all sensors are valid.
+func (v *Frame) Valid() bool {
+	for _, m := range v.Measurements {
+		if !m.Valid() {
+			return false
+		}
+	}
+	return true
+}
+
+// TriggerSync starts single-shot measurements of all sensors as close
+// to simultaneously as possible, then waits for each result. Sensors,
+// which don't implement TriggeredSensor, are measured one by one while
+// others are ranging. Sensors must be brought up by BringUp and not
+// ranging in continuous mode.
+// Failures of particular sensors are reported in Measurement.Err.
+func (v *Array) TriggerSync() *Frame {
+	frame := &Frame{Time: time.Now(),
+		Measurements: make([]Measurement, len(v.sensors))}
+	started := make([]bool, len(v.sensors))
+	for i, s := range v.sensors {
+		if s.Device == nil {
+			frame.Measurements[i].Err = errNotBroughtUp
+			continue
+		}
+		t, ok := s.Device.(TriggeredSensor)
+		if !ok {
+			continue
+		}
+		err := t.StartRange()
+		if err != nil {
+			frame.Measurements[i].Err = err
+			continue
+		}
+		started[i] = true
+	}
+	for i, s := range v.sensors {
+		if s.Device == nil || frame.Measurements[i].Err != nil {
+			continue
+		}
+		var m Measurement
+		var err error
+		if started[i] {
+			m, err = s.Device.(TriggeredSensor).FinishRange()
+		} else {
+			m, err = s.Device.ReadRange()
+		}
+		if err != nil {
+			m.Err = err
+		}
+		frame.Measurements[i] = m
+	}
+	frame.Duration = time.Since(frame.Time)
+	return frame
+}
+
+// TriggerStaggered performs single-shot measurements of sensors one
+// after another, starting next sensor only when previous one is complete,
+// plus gap specified. Use it to avoid crosstalk between sensors
+// with overlapping field of view.
+// Failures of particular sensors are reported in Measurement.Err.
+func (v *Array) TriggerStaggered(gap time.Duration) *Frame {
+	frame := &Frame{Time: time.Now(),
+		Measurements: make([]Measurement, len(v.sensors))}
+	for i, s := range v.sensors {
+		if s.Device == nil {
+			frame.Measurements[i].Err = errNotBroughtUp
+			continue
+		}
+		if i > 0 && gap > 0 {
+			time.Sleep(gap)
+		}
+		m, err := s.Device.ReadRange()
+		if err != nil {
+			m.Err = err
+		}
+		frame.Measurements[i] = m
+	}
+	frame.Duration = time.Since(frame.Time)
+	return frame
+}
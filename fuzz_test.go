@@ -0,0 +1,125 @@
+package vl53l0x
+
+import (
+	"math"
+	"testing"
+)
+
+// Fuzz tests of timeout encoding and conversions. Seed corpus runs with
+// regular "go test"; to explore the full input range run, for instance:
+//
+//	go test -run XXX -fuzz FuzzTimeoutEncoding -fuzztime 30s
+
+// Valid VCSEL periods are even numbers from 8 to 18 PCLKs.
+func fuzzVcselPeriod(period uint8) uint16 {
+	return 8 + 2*uint16(period%6)
+}
+
+// Encoding keeps 8 significant bits, so decoded value is never bigger,
+// than encoded one, and differs less than by 2^MSByte, which is below 1/128
+// of the value. Encoded value is normalized: LSByte uses all 8 bits,
+// unless MSByte is zero.
+func FuzzTimeoutEncoding(f *testing.F) {
+	for _, mclks := range []uint16{0, 1, 2, 255, 256, 257, 511, 512, 1000, 0x7FFF, 0x8000, math.MaxUint16} {
+		f.Add(mclks)
+	}
+	v := NewVl53l0x()
+	f.Fuzz(func(t *testing.T, mclks uint16) {
+		encoded := v.encodeTimeout(mclks)
+		if mclks == 0 {
+			if encoded != 0 {
+				t.Fatalf("encodeTimeout(0) = 0x%04x, want 0", encoded)
+			}
+			return
+		}
+		msByte, lsByte := encoded>>8, encoded&0xFF
+		if msByte > 0 && lsByte < 0x80 {
+			t.Fatalf("encodeTimeout(%d) = 0x%04x is not normalized", mclks, encoded)
+		}
+		decoded := v.decodeTimeout(encoded)
+		if decoded > mclks {
+			t.Fatalf("decodeTimeout(encodeTimeout(%d)) = %d is bigger", mclks, decoded)
+		}
+		if uint32(mclks-decoded) >= 1<<msByte {
+			t.Fatalf("decodeTimeout(encodeTimeout(%d)) = %d, error exceeds 2^%d",
+				mclks, decoded, msByte)
+		}
+		if msByte > 0 && uint32(mclks-decoded)*128 > uint32(mclks) {
+			t.Fatalf("decodeTimeout(encodeTimeout(%d)) = %d, error exceeds 1/128",
+				mclks, decoded)
+		}
+	})
+}
+
+// Any register value decodes either exactly, or saturates to uint16
+// range, and decoded value is encoded back without loss.
+func FuzzTimeoutDecoding(f *testing.F) {
+	for _, reg := range []uint16{0x0000, 0x0001, 0x00FF, 0x0180, 0x07FF, 0x08FF, 0x0880, 0x0F01, 0x10FF, 0xFFFF} {
+		f.Add(reg)
+	}
+	v := NewVl53l0x()
+	f.Fuzz(func(t *testing.T, reg uint16) {
+		decoded := v.decodeTimeout(reg)
+		lsByte, msByte := uint64(reg&0xFF), uint(reg>>8)
+		if lsByte != 0 && (msByte >= 16 || lsByte<<msByte >= math.MaxUint16) {
+			if decoded != math.MaxUint16 {
+				t.Fatalf("decodeTimeout(0x%04x) = %d, want saturated %d",
+					reg, decoded, math.MaxUint16)
+			}
+			return
+		}
+		if uint64(decoded) != lsByte<<msByte+1 {
+			t.Fatalf("decodeTimeout(0x%04x) = %d, want %d", reg, decoded, lsByte<<msByte+1)
+		}
+		if v.decodeTimeout(v.encodeTimeout(decoded)) != decoded {
+			t.Fatalf("decoded %d isn't encoded back without loss", decoded)
+		}
+	})
+}
+
+// Timeout in MCLKs is converted to microseconds as by ST API, adding half
+// of macro period before truncation, so converted back it's the same or
+// one MCLK longer, for any VCSEL period and without 32-bit overflow.
+func FuzzTimeoutMclksConversion(f *testing.F) {
+	for _, mclks := range []uint16{0, 1, 100, 0x7FFF, math.MaxUint16} {
+		for _, period := range []uint8{0, 5} {
+			f.Add(mclks, period)
+		}
+	}
+	v := NewVl53l0x()
+	f.Fuzz(func(t *testing.T, mclks uint16, period uint8) {
+		pclks := fuzzVcselPeriod(period)
+		macroPeriodNsec := uint64(v.calcMacroPeriod(pclks))
+		usec := v.timeoutMclksToMicroseconds(mclks, pclks)
+		exact := uint64(mclks)*macroPeriodNsec + macroPeriodNsec/2
+		if nsec := uint64(usec) * 1000; nsec > exact || exact-nsec >= 1000 {
+			t.Fatalf("%d MCLKs at %d PCLKs is %d ns, got %d us", mclks, pclks, exact, usec)
+		}
+		back := v.timeoutMicrosecondsToMclks(usec, pclks)
+		if back != uint32(mclks) && back != uint32(mclks)+1 {
+			t.Fatalf("%d MCLKs at %d PCLKs converted to %d us and back to %d MCLKs",
+				mclks, pclks, usec, back)
+		}
+	})
+}
+
+// Timeout in microseconds converted to MCLKs is rounded to the nearest
+// macro period for any value, including ones overflowing 32-bit nanoseconds.
+func FuzzTimeoutMicrosecondsConversion(f *testing.F) {
+	for _, usec := range []uint32{0, 1, 33000, 4294967, 4294968, 200000000, math.MaxUint32} {
+		for _, period := range []uint8{0, 5} {
+			f.Add(usec, period)
+		}
+	}
+	v := NewVl53l0x()
+	f.Fuzz(func(t *testing.T, usec uint32, period uint8) {
+		pclks := fuzzVcselPeriod(period)
+		macroPeriodNsec := int64(v.calcMacroPeriod(pclks))
+		mclks := v.timeoutMicrosecondsToMclks(usec, pclks)
+		diff := int64(mclks)*macroPeriodNsec - int64(usec)*1000
+		if diff > macroPeriodNsec/2 || diff < -macroPeriodNsec/2 {
+			t.Fatalf("%d us at %d PCLKs converted to %d MCLKs, off by %d ns",
+				usec, pclks, mclks, diff)
+		}
+	})
+}
@@ -0,0 +1,363 @@
+package gpio
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/warthog618/gpiod"
+)
+
+// ErrNack is returned by BitBangBus transactions, when device
+// doesn't acknowledge address or data byte.
+var ErrNack = errors.New("no acknowledge from device")
+
+// TxError returned, when BitBangBus transaction with device fails.
+// Use errors.Is to check for ErrNack.
+type TxError struct {
+	Addr byte
+	Err  error
+}
+
+// Error implement error interface.
+func (e *TxError) Error() string {
+	return spew.Sprintf("device 0x%x: %v", e.Addr, e.Err)
+}
+
+// Unwrap returns error, which failed transaction.
+func (e *TxError) Unwrap() error {
+	return e.Err
+}
+
+// Longest clock stretching accepted from device.
+const stretchTimeout = 10 * time.Millisecond
+
+// BitBangBus is software I2C master driving two GPIO lines in open-drain
+// mode, for boards, which hardware I2C pins are occupied or broken. Both
+// lines need pull-up resistors, as with hardware I2C, and GPIO driver
+// should report actual level of open-drain line driven high. Bus is much
+// slower, than hardware one, and consumes CPU while transaction is in
+// progress, but needs neither kernel overlays nor i2c-gpio driver.
+// Use Device method to get connection implementing vl53l0x.Bus.
+type BitBangBus struct {
+	sync.Mutex
+	scl, sda *gpiod.Line
+	// quarter of clock period
+	delay time.Duration
+}
+
+// NewBitBangBus request lines scl and sda of GPIO chip (for instance
+// "gpiochip0") and create bus clocked at frequency in Hz, 100 kHz
+// if zero. Effective frequency is lower because of GPIO access overhead.
+func NewBitBangBus(chip string, scl, sda int, frequency int) (*BitBangBus, error) {
+	if frequency <= 0 {
+		frequency = 100000
+	}
+	sclLine, err := gpiod.RequestLine(chip, scl, gpiod.AsOutput(1), gpiod.AsOpenDrain)
+	if err != nil {
+		return nil, err
+	}
+	sdaLine, err := gpiod.RequestLine(chip, sda, gpiod.AsOutput(1), gpiod.AsOpenDrain)
+	if err != nil {
+		sclLine.Close()
+		return nil, err
+	}
+	v := &BitBangBus{scl: sclLine, sda: sdaLine,
+		delay: time.Second / time.Duration(4*frequency)}
+	err = v.recover()
+	if err != nil {
+		v.Close()
+		return nil, err
+	}
+	return v, nil
+}
+
+// Device return connection to device with address given.
+func (v *BitBangBus) Device(addr byte) *BitBangDevice {
+	return &BitBangDevice{bus: v, addr: addr}
+}
+
+// Close release GPIO lines.
+func (v *BitBangBus) Close() error {
+	err := v.scl.Close()
+	err2 := v.sda.Close()
+	if err == nil {
+		err = err2
+	}
+	return err
+}
+
+// Release bus, which device holds SDA low, for instance after master
+// was interrupted in the middle of read: clock out up to 9 bits
+// until device releases SDA, then generate stop condition.
+func (v *BitBangBus) recover() error {
+	for i := 0; i < 9; i++ {
+		level, err := v.sda.Value()
+		if err != nil {
+			return err
+		}
+		if level == 1 {
+			break
+		}
+		err = v.scl.SetValue(0)
+		if err != nil {
+			return err
+		}
+		err = v.clockPulse()
+		if err != nil {
+			return err
+		}
+	}
+	return v.stop()
+}
+
+// Busy-wait quarter of clock period: sleep granularity is far too
+// coarse for I2C timing.
+func (v *BitBangBus) wait() {
+	for start := time.Now(); time.Since(start) < v.delay; {
+	}
+}
+
+// Release SCL and wait, while device stretches clock.
+func (v *BitBangBus) releaseSCL() error {
+	err := v.scl.SetValue(1)
+	if err != nil {
+		return err
+	}
+	for start := time.Now(); ; {
+		level, err := v.scl.Value()
+		if err != nil {
+			return err
+		}
+		if level == 1 {
+			return nil
+		}
+		if time.Since(start) > stretchTimeout {
+			return errors.New("SCL is held low by device")
+		}
+	}
+}
+
+// Generate single SCL pulse, SCL is expected to be low.
+func (v *BitBangBus) clockPulse() error {
+	v.wait()
+	err := v.releaseSCL()
+	if err != nil {
+		return err
+	}
+	v.wait()
+	v.wait()
+	err = v.scl.SetValue(0)
+	v.wait()
+	return err
+}
+
+// Generate start (or repeated start) condition: SDA falls while SCL is
+// high. Both lines are left low.
+func (v *BitBangBus) start() error {
+	err := v.sda.SetValue(1)
+	if err != nil {
+		return err
+	}
+	v.wait()
+	err = v.releaseSCL()
+	if err != nil {
+		return err
+	}
+	v.wait()
+	err = v.sda.SetValue(0)
+	if err != nil {
+		return err
+	}
+	v.wait()
+	err = v.scl.SetValue(0)
+	v.wait()
+	return err
+}
+
+// Generate stop condition: SDA rises while SCL is high.
+func (v *BitBangBus) stop() error {
+	err := v.sda.SetValue(0)
+	if err != nil {
+		return err
+	}
+	v.wait()
+	err = v.releaseSCL()
+	if err != nil {
+		return err
+	}
+	v.wait()
+	err = v.sda.SetValue(1)
+	v.wait()
+	return err
+}
+
+// Write bit, SCL is expected to be low.
+func (v *BitBangBus) writeBit(bit bool) error {
+	level := 0
+	if bit {
+		level = 1
+	}
+	err := v.sda.SetValue(level)
+	if err != nil {
+		return err
+	}
+	return v.clockPulse()
+}
+
+// Read bit, SCL is expected to be low.
+func (v *BitBangBus) readBit() (bool, error) {
+	err := v.sda.SetValue(1)
+	if err != nil {
+		return false, err
+	}
+	v.wait()
+	err = v.releaseSCL()
+	if err != nil {
+		return false, err
+	}
+	v.wait()
+	level, err := v.sda.Value()
+	if err != nil {
+		return false, err
+	}
+	v.wait()
+	err = v.scl.SetValue(0)
+	v.wait()
+	return level == 1, err
+}
+
+// Write byte MSB first and check acknowledge.
+func (v *BitBangBus) writeByte(b byte) error {
+	for i := 7; i >= 0; i-- {
+		err := v.writeBit(b&(1<<i) != 0)
+		if err != nil {
+			return err
+		}
+	}
+	nack, err := v.readBit()
+	if err != nil {
+		return err
+	}
+	if nack {
+		return ErrNack
+	}
+	return nil
+}
+
+// Read byte MSB first, acknowledging it, unless it's the last one.
+func (v *BitBangBus) readByte(ack bool) (byte, error) {
+	var b byte
+	for i := 0; i < 8; i++ {
+		bit, err := v.readBit()
+		if err != nil {
+			return 0, err
+		}
+		b <<= 1
+		if bit {
+			b |= 1
+		}
+	}
+	return b, v.writeBit(!ack)
+}
+
+// Run transaction: optional write of wbuf followed by optional read
+// to rbuf after repeated start. Stop condition is generated anyway.
+func (v *BitBangBus) tx(addr byte, wbuf, rbuf []byte) error {
+	v.Lock()
+	defer v.Unlock()
+	err := v.txLocked(addr, wbuf, rbuf)
+	err2 := v.stop()
+	if err != nil {
+		return &TxError{Addr: addr, Err: err}
+	}
+	return err2
+}
+
+// Transaction body, run with bus locked.
+func (v *BitBangBus) txLocked(addr byte, wbuf, rbuf []byte) error {
+	if len(wbuf) > 0 || len(rbuf) == 0 {
+		err := v.start()
+		if err != nil {
+			return err
+		}
+		err = v.writeByte(addr << 1)
+		if err != nil {
+			return err
+		}
+		for _, b := range wbuf {
+			err = v.writeByte(b)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(rbuf) == 0 {
+		return nil
+	}
+	err := v.start()
+	if err != nil {
+		return err
+	}
+	err = v.writeByte(addr<<1 | 1)
+	if err != nil {
+		return err
+	}
+	for i := range rbuf {
+		rbuf[i], err = v.readByte(i < len(rbuf)-1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BitBangDevice is connection to device on BitBangBus,
+// which implements vl53l0x.Bus interface.
+type BitBangDevice struct {
+	bus  *BitBangBus
+	addr byte
+}
+
+// ReadRegU8 implement vl53l0x.Bus interface.
+func (v *BitBangDevice) ReadRegU8(reg byte) (byte, error) {
+	var buf [1]byte
+	err := v.bus.tx(v.addr, []byte{reg}, buf[:])
+	return buf[0], err
+}
+
+// WriteRegU8 implement vl53l0x.Bus interface.
+func (v *BitBangDevice) WriteRegU8(reg byte, value byte) error {
+	return v.bus.tx(v.addr, []byte{reg, value}, nil)
+}
+
+// ReadReg implement vl53l0x.RegisterReader interface:
+// register index is written and data read in single transaction.
+func (v *BitBangDevice) ReadReg(reg byte, buf []byte) error {
+	return v.bus.tx(v.addr, []byte{reg}, buf)
+}
+
+// ReadBytes implement vl53l0x.Bus interface.
+func (v *BitBangDevice) ReadBytes(buf []byte) (int, error) {
+	err := v.bus.tx(v.addr, nil, buf)
+	if err != nil {
+		return 0, err
+	}
+	return len(buf), nil
+}
+
+// WriteBytes implement vl53l0x.Bus interface.
+func (v *BitBangDevice) WriteBytes(buf []byte) (int, error) {
+	err := v.bus.tx(v.addr, buf, nil)
+	if err != nil {
+		return 0, err
+	}
+	return len(buf), nil
+}
+
+// OpenAddress implement vl53l0x.AddressableBus interface.
+func (v *BitBangDevice) OpenAddress(addr byte) (vl53l0x.Bus, error) {
+	return v.bus.Device(addr), nil
+}
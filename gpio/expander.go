@@ -0,0 +1,155 @@
+package gpio
+
+import (
+	"errors"
+	"sync"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// Expander is I2C GPIO expander, which pins could drive sensor XSHUT lines,
+// when board has not enough free native GPIOs. Expander is usually attached
+// to the same I2C-bus as sensors: PCF8574 and MCP23017 occupy addresses
+// 0x20..0x27 (PCF8574A 0x38..0x3F), which don't collide with VL53L0X ones.
+type Expander interface {
+	// Pin configure pin n as output driven high and return it.
+	Pin(n int) (*ExpanderPin, error)
+}
+
+// ExpanderPin drives single output pin of I2C GPIO expander.
+// Use SetLevel method with vl53l0x.NewXShutController.
+type ExpanderPin struct {
+	set func(n int, high bool) error
+	n   int
+}
+
+// SetLevel drive pin high or low.
+func (v *ExpanderPin) SetLevel(high bool) error {
+	return v.set(v.n, high)
+}
+
+// PCF8574 is 8-bit quasi-bidirectional I/O expander (PCF8574, PCF8574A).
+// It has no registers: single byte written sets level of all 8 pins,
+// so state of pins is kept in shadow byte.
+type PCF8574 struct {
+	sync.Mutex
+	i2c   vl53l0x.Bus
+	state byte
+}
+
+// NewPCF8574 create PCF8574 driver over I2C-connection to the expander.
+// All pins are driven high, so attached sensors stay powered on, which
+// is PCF8574 power-on state as well.
+func NewPCF8574(i2c vl53l0x.Bus) (*PCF8574, error) {
+	v := &PCF8574{i2c: i2c, state: 0xFF}
+	_, err := i2c.WriteBytes([]byte{v.state})
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Pin return pin n (0..7). PCF8574 pins need no direction setup:
+// pin driven high is weak pull-up, which releases XSHUT.
+func (v *PCF8574) Pin(n int) (*ExpanderPin, error) {
+	if n < 0 || n > 7 {
+		return nil, errors.New(spew.Sprintf("PCF8574 has no pin %d, expected 0..7", n))
+	}
+	pin := &ExpanderPin{set: v.setPin, n: n}
+	return pin, nil
+}
+
+// Update shadow state and write it to expander.
+func (v *PCF8574) setPin(n int, high bool) error {
+	v.Lock()
+	defer v.Unlock()
+	state := v.state &^ (1 << n)
+	if high {
+		state |= 1 << n
+	}
+	_, err := v.i2c.WriteBytes([]byte{state})
+	if err != nil {
+		return err
+	}
+	v.state = state
+	return nil
+}
+
+// MCP23017 registers, in default IOCON.BANK = 0 layout.
+const (
+	mcp23017IODIRA = 0x00
+	mcp23017OLATA  = 0x14
+)
+
+// MCP23017 is 16-bit I/O expander. Pins 0..7 are port A (GPA0..GPA7),
+// pins 8..15 are port B (GPB0..GPB7).
+type MCP23017 struct {
+	sync.Mutex
+	i2c vl53l0x.Bus
+	// shadow of IODIRA/IODIRB and OLATA/OLATB registers
+	iodir [2]byte
+	olat  [2]byte
+}
+
+// NewMCP23017 create MCP23017 driver over I2C-connection to the expander.
+// Pins not requested by Pin keep their configuration, so the rest of
+// expander could be used for other purposes.
+func NewMCP23017(i2c vl53l0x.Bus) (*MCP23017, error) {
+	v := &MCP23017{i2c: i2c}
+	for port := byte(0); port < 2; port++ {
+		u8, err := i2c.ReadRegU8(mcp23017IODIRA + port)
+		if err != nil {
+			return nil, err
+		}
+		v.iodir[port] = u8
+		u8, err = i2c.ReadRegU8(mcp23017OLATA + port)
+		if err != nil {
+			return nil, err
+		}
+		v.olat[port] = u8
+	}
+	return v, nil
+}
+
+// Pin configure pin n (0..15) as output. Output latch is set high
+// before direction is switched, so attached sensor isn't reset.
+func (v *MCP23017) Pin(n int) (*ExpanderPin, error) {
+	if n < 0 || n > 15 {
+		return nil, errors.New(spew.Sprintf("MCP23017 has no pin %d, expected 0..15", n))
+	}
+	err := v.setPin(n, true)
+	if err != nil {
+		return nil, err
+	}
+	v.Lock()
+	defer v.Unlock()
+	port, mask := n/8, byte(1<<(n%8))
+	if v.iodir[port]&mask != 0 {
+		iodir := v.iodir[port] &^ mask
+		err = v.i2c.WriteRegU8(mcp23017IODIRA+byte(port), iodir)
+		if err != nil {
+			return nil, err
+		}
+		v.iodir[port] = iodir
+	}
+	pin := &ExpanderPin{set: v.setPin, n: n}
+	return pin, nil
+}
+
+// Update output latch of pin port.
+func (v *MCP23017) setPin(n int, high bool) error {
+	v.Lock()
+	defer v.Unlock()
+	port, mask := n/8, byte(1<<(n%8))
+	olat := v.olat[port] &^ mask
+	if high {
+		olat |= mask
+	}
+	err := v.i2c.WriteRegU8(mcp23017OLATA+byte(port), olat)
+	if err != nil {
+		return err
+	}
+	v.olat[port] = olat
+	return nil
+}
@@ -0,0 +1,90 @@
+// Package gpio provides sensor GPIO1 data ready interrupt support,
+// XSHUT pin control and general purpose output lines via Linux GPIO
+// character device, XSHUT pin control via I2C GPIO expanders (PCF8574,
+// MCP23017) as well, to be used with vl53l0x.SetInterruptWaiter,
+// vl53l0x.NewXShutController and event.NewActuator. BitBangBus provides
+// software I2C-bus over GPIO lines implementing vl53l0x.Bus.
+package gpio
+
+import (
+	"time"
+
+	"github.com/warthog618/gpiod"
+)
+
+// Edge specify which signal transition is treated as interrupt.
+type Edge int
+
+const (
+	// FallingEdge matches sensor default configuration (active low).
+	FallingEdge Edge = iota + 1
+	// RisingEdge should be used if sensor GPIO1 configured active high.
+	RisingEdge
+)
+
+// String implement Stringer interface.
+func (v Edge) String() string {
+	switch v {
+	case FallingEdge:
+		return "FallingEdge"
+	case RisingEdge:
+		return "RisingEdge"
+	default:
+		return "<unknown>"
+	}
+}
+
+// Interrupt watches GPIO line connected to sensor GPIO1 pin
+// and implements vl53l0x.InterruptWaiter interface.
+type Interrupt struct {
+	line   *gpiod.Line
+	events chan struct{}
+}
+
+// NewInterrupt request line offset of GPIO chip (for instance "gpiochip0")
+// as input with edge detection.
+func NewInterrupt(chip string, offset int, edge Edge) (*Interrupt, error) {
+	v := &Interrupt{events: make(chan struct{}, 1)}
+	var edgeOption gpiod.LineReqOption
+	switch edge {
+	case RisingEdge:
+		edgeOption = gpiod.WithRisingEdge
+	default:
+		edgeOption = gpiod.WithFallingEdge
+	}
+	line, err := gpiod.RequestLine(chip, offset, gpiod.AsInput, gpiod.WithPullUp,
+		edgeOption, gpiod.WithEventHandler(v.handleEvent))
+	if err != nil {
+		return nil, err
+	}
+	v.line = line
+	return v, nil
+}
+
+// Keep single pending event, since sensor holds interrupt
+// until cleared, so repeated events carry no extra information.
+func (v *Interrupt) handleEvent(evt gpiod.LineEvent) {
+	select {
+	case v.events <- struct{}{}:
+	default:
+	}
+}
+
+// WaitInterrupt implement vl53l0x.InterruptWaiter interface.
+func (v *Interrupt) WaitInterrupt(timeout time.Duration) (bool, error) {
+	if timeout <= 0 {
+		<-v.events
+		return true, nil
+	}
+	select {
+	case <-v.events:
+		return true, nil
+	case <-time.After(timeout):
+		return false, nil
+	}
+}
+
+// Close release GPIO line.
+func (v *Interrupt) Close() error {
+	return v.line.Close()
+}
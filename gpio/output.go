@@ -0,0 +1,39 @@
+package gpio
+
+import (
+	"github.com/warthog618/gpiod"
+)
+
+// OutputLine drives general purpose GPIO output line, for instance relay or
+// LED. Use SetLevel method with event.NewActuator.
+type OutputLine struct {
+	line *gpiod.Line
+}
+
+// NewOutputLine request line offset of GPIO chip (for instance "gpiochip0")
+// as output, driven to initial level given.
+func NewOutputLine(chip string, offset int, high bool) (*OutputLine, error) {
+	value := 0
+	if high {
+		value = 1
+	}
+	line, err := gpiod.RequestLine(chip, offset, gpiod.AsOutput(value))
+	if err != nil {
+		return nil, err
+	}
+	v := &OutputLine{line: line}
+	return v, nil
+}
+
+// SetLevel drive line high or low.
+func (v *OutputLine) SetLevel(high bool) error {
+	if high {
+		return v.line.SetValue(1)
+	}
+	return v.line.SetValue(0)
+}
+
+// Close release GPIO line.
+func (v *OutputLine) Close() error {
+	return v.line.Close()
+}
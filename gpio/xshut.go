@@ -0,0 +1,35 @@
+package gpio
+
+import (
+	"github.com/warthog618/gpiod"
+)
+
+// XShutLine drives GPIO line connected to sensor XSHUT pin.
+// Use SetLevel method with vl53l0x.NewXShutController.
+type XShutLine struct {
+	line *gpiod.Line
+}
+
+// NewXShutLine request line offset of GPIO chip (for instance "gpiochip0")
+// as output. Line is initially driven high, so sensor stays powered on.
+func NewXShutLine(chip string, offset int) (*XShutLine, error) {
+	line, err := gpiod.RequestLine(chip, offset, gpiod.AsOutput(1))
+	if err != nil {
+		return nil, err
+	}
+	v := &XShutLine{line: line}
+	return v, nil
+}
+
+// SetLevel drive line high or low.
+func (v *XShutLine) SetLevel(high bool) error {
+	if high {
+		return v.line.SetValue(1)
+	}
+	return v.line.SetValue(0)
+}
+
+// Close release GPIO line.
+func (v *XShutLine) Close() error {
+	return v.line.Close()
+}
@@ -0,0 +1,206 @@
+//go:build hil
+
+package vl53l0x
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	i2c "github.com/d2r2/go-i2c"
+	logger "github.com/d2r2/go-logger"
+)
+
+// Hardware-in-the-loop tests run against real sensor, validating driver
+// on new kernels and boards. They are excluded from regular builds by
+// "hil" build tag, and configured with environment variables:
+//
+//	VL53L0X_BUS=1 VL53L0X_ADDR=0x29 go test -tags hil -run HIL -v
+//
+// Keep target (wall, ceiling) within 2 meters in front of the sensor;
+// VL53L0X_TARGET_MM optionally asserts its distance with 5% tolerance.
+
+// Distance tolerance of target distance check.
+const hilTolerance = 0.05
+
+// Open connection to sensor configured by environment, or skip test.
+func openHIL(t *testing.T) *i2c.I2C {
+	t.Helper()
+	logger.ChangePackageLogLevel("i2c", logger.InfoLevel)
+	SetLogLevel(logger.InfoLevel)
+	busEnv := os.Getenv("VL53L0X_BUS")
+	if busEnv == "" {
+		t.Skip("VL53L0X_BUS is not set")
+	}
+	bus, err := strconv.Atoi(busEnv)
+	if err != nil {
+		t.Fatalf("VL53L0X_BUS: %s", err)
+	}
+	addr := uint64(defaultAddress)
+	if env := os.Getenv("VL53L0X_ADDR"); env != "" {
+		addr, err = strconv.ParseUint(env, 0, 8)
+		if err != nil {
+			t.Fatalf("VL53L0X_ADDR: %s", err)
+		}
+	}
+	conn, err := i2c.NewI2C(uint8(addr), bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// Reset and initialize sensor.
+func initHIL(t *testing.T) (*Vl53l0x, *i2c.I2C) {
+	t.Helper()
+	conn := openHIL(t)
+	v := NewVl53l0x()
+	err := v.Reset(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = v.Init(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v, conn
+}
+
+// Check plausibility invariants of measurement.
+func checkPlausible(t *testing.T, m Measurement) {
+	t.Helper()
+	if m.Err != nil {
+		t.Fatalf("measurement error: %s", m.Err)
+	}
+	if m.Timestamp.IsZero() {
+		t.Error("measurement has no timestamp")
+	}
+	if m.Confidence < 0 || m.Confidence > 1 {
+		t.Errorf("confidence %v is out of [0..1]", m.Confidence)
+	}
+	if m.SignalRateMcps < 0 || m.AmbientRateMcps < 0 {
+		t.Errorf("negative rates: signal %v, ambient %v MCPS", m.SignalRateMcps, m.AmbientRateMcps)
+	}
+	if !m.Valid() {
+		t.Logf("invalid measurement: %v", m.Status)
+		return
+	}
+	if m.RangeMillimeters == 0 || m.RangeMillimeters >= outOfRangeMillimeters {
+		t.Errorf("valid measurement with implausible range %d mm", m.RangeMillimeters)
+	}
+	if m.Confidence == 0 {
+		t.Error("valid measurement with zero confidence")
+	}
+	if env := os.Getenv("VL53L0X_TARGET_MM"); env != "" {
+		target, err := strconv.ParseFloat(env, 64)
+		if err != nil {
+			t.Fatalf("VL53L0X_TARGET_MM: %s", err)
+		}
+		if diff := float64(m.RangeMillimeters) - target; diff > target*hilTolerance ||
+			diff < -target*hilTolerance {
+			t.Errorf("measured %d mm, target is at %v mm", m.RangeMillimeters, target)
+		}
+	}
+}
+
+func TestHILInit(t *testing.T) {
+	v, conn := initHIL(t)
+	model, err := DetectModelOn(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model != ModelVl53l0x {
+		t.Fatalf("detected %v, want %v", model, ModelVl53l0x)
+	}
+	if v.refSpadInfo.Count == 0 {
+		t.Error("no reference SPADs enabled")
+	}
+	budget, err := v.GetMeasurementTimingBudget(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget < 20000 || budget > 40000 {
+		t.Errorf("default timing budget %d us, expected about 33 ms", budget)
+	}
+}
+
+func TestHILConfig(t *testing.T) {
+	v, conn := initHIL(t)
+	tests := []struct {
+		rng    RangeSpec
+		speed  SpeedAccuracySpec
+		budget uint32
+	}{
+		{RegularRange, HighSpeed, 20000},
+		{LongRange, RegularAccuracy, 33000},
+		{RegularRange, HighAccuracy, 100000},
+	}
+	for _, test := range tests {
+		err := v.Config(conn, test.rng, test.speed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		budget, err := v.GetMeasurementTimingBudget(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// budget is quantized to MCLKs, read back value is a bit lower
+		if budget > test.budget || test.budget-budget > test.budget/50 {
+			t.Errorf("%v %v: timing budget %d us, want about %d", test.rng, test.speed,
+				budget, test.budget)
+		}
+		m, err := v.ReadMeasurementSingle(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkPlausible(t, m)
+	}
+}
+
+func TestHILSingle(t *testing.T) {
+	v, conn := initHIL(t)
+	budget := v.MeasurementTimingBudget()
+	for i := 0; i < 10; i++ {
+		start := time.Now()
+		m, err := v.ReadMeasurementSingle(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		elapsed := time.Since(start)
+		checkPlausible(t, m)
+		if elapsed < budget/2 || elapsed > budget*3 {
+			t.Errorf("single-shot measurement took %v with timing budget %v", elapsed, budget)
+		}
+	}
+}
+
+func TestHILContinuous(t *testing.T) {
+	v, conn := initHIL(t)
+	const period = 50 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ch, err := v.Stream(ctx, conn, period)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prev time.Time
+	count := 0
+	for m := range ch {
+		checkPlausible(t, m)
+		if !prev.IsZero() && !m.Timestamp.After(prev) {
+			t.Errorf("timestamps are not increasing: %v, %v", prev, m.Timestamp)
+		}
+		prev = m.Timestamp
+		count++
+	}
+	// about 20 measurements expected within a second
+	if count < 10 || count > 30 {
+		t.Errorf("got %d measurements within a second at %v period", count, period)
+	}
+	if dropped := v.StreamDropped(); dropped != 0 {
+		t.Errorf("%d measurements dropped", dropped)
+	}
+}
@@ -0,0 +1,153 @@
+package vl53l0x
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Default subscriber buffer size of Hub.
+const defaultHubBuffer = 16
+
+// Hub is an in-process publish/subscribe hub, distributing measurements
+// of single stream to independent subscribers (logger, MQTT sink, event
+// detector, UI), each with its own buffer, so slow subscriber doesn't
+// stall others or acquisition loop:
+//
+//	hub := vl53l0x.NewHub()
+//	log := hub.Subscribe(64)
+//	ui := hub.Subscribe(1)
+//	ch, err := sensor.Stream(ctx, i2c, 0)
+//	...
+//	go hub.Run(ch)
+//	for m := range ui.C {
+//		...
+//	}
+//
+// When subscriber buffer is full, the oldest measurement is dropped
+// to make room for the new one, and drop is accounted.
+type Hub = EventHub[Measurement]
+
+// Subscription receives measurements published to Hub over channel C,
+// which is closed, when hub is closed or subscription cancelled.
+type Subscription = EventSubscription[Measurement]
+
+// NewHub creates hub without subscribers.
+func NewHub() *Hub {
+	return NewEventHub[Measurement]()
+}
+
+// EventHub is publish/subscribe hub of events of any type, for instance
+// health events of event.HealthMonitor, which works the same way as Hub
+// does for measurements.
+type EventHub[T any] struct {
+	sync.Mutex
+	subs   map[*EventSubscription[T]]struct{}
+	closed bool
+}
+
+// EventSubscription receives events published to EventHub over channel C,
+// which is closed, when hub is closed or subscription cancelled.
+type EventSubscription[T any] struct {
+	C       <-chan T
+	ch      chan T
+	hub     *EventHub[T]
+	dropped atomic.Uint64
+}
+
+// NewEventHub creates hub of events without subscribers.
+func NewEventHub[T any]() *EventHub[T] {
+	v := &EventHub[T]{subs: make(map[*EventSubscription[T]]struct{})}
+	return v
+}
+
+// Subscribe creates subscription with buffer size given; zero
+// stands for default of 16. Subscription of closed hub gets
+// closed channel.
+func (v *EventHub[T]) Subscribe(buffer int) *EventSubscription[T] {
+	if buffer <= 0 {
+		buffer = defaultHubBuffer
+	}
+	ch := make(chan T, buffer)
+	s := &EventSubscription[T]{C: ch, ch: ch, hub: v}
+	v.Lock()
+	defer v.Unlock()
+	if v.closed {
+		close(ch)
+		return s
+	}
+	v.subs[s] = struct{}{}
+	return s
+}
+
+// Publish deliver event to all subscribers without blocking.
+func (v *EventHub[T]) Publish(e T) {
+	v.Lock()
+	defer v.Unlock()
+	for s := range v.subs {
+		s.send(e)
+	}
+}
+
+// Run publish events from stream, for instance measurements returned
+// by Vl53l0x.Stream, until it's closed, then close hub.
+func (v *EventHub[T]) Run(in <-chan T) {
+	for e := range in {
+		v.Publish(e)
+	}
+	v.Close()
+}
+
+// Close cancel all subscriptions, closing their channels.
+// Subscribers read buffered events before channel close.
+func (v *EventHub[T]) Close() {
+	v.Lock()
+	defer v.Unlock()
+	if v.closed {
+		return
+	}
+	v.closed = true
+	for s := range v.subs {
+		close(s.ch)
+		delete(v.subs, s)
+	}
+}
+
+// Subscribers returns number of active subscriptions.
+func (v *EventHub[T]) Subscribers() int {
+	v.Lock()
+	defer v.Unlock()
+	return len(v.subs)
+}
+
+// Send event, dropping the oldest one, if buffer is full.
+// Call with hub locked.
+func (v *EventSubscription[T]) send(e T) {
+	for {
+		select {
+		case v.ch <- e:
+			return
+		default:
+		}
+		select {
+		case <-v.ch:
+			v.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// Unsubscribe cancel subscription, closing its channel.
+func (v *EventSubscription[T]) Unsubscribe() {
+	v.hub.Lock()
+	defer v.hub.Unlock()
+	if _, ok := v.hub.subs[v]; ok {
+		close(v.ch)
+		delete(v.hub.subs, v)
+	}
+}
+
+// Dropped returns number of events dropped,
+// because subscriber didn't keep up.
+func (v *EventSubscription[T]) Dropped() uint64 {
+	return v.dropped.Load()
+}
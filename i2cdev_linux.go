@@ -0,0 +1,152 @@
+//go:build linux
+
+package vl53l0x
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// Linux I2C character device constants from <linux/i2c-dev.h>
+// and <linux/i2c.h>.
+const (
+	i2cRdwr = 0x0707 // I2C_RDWR
+	i2cMRd  = 0x0001 // I2C_M_RD
+	// I2C_RDWR_IOCTL_MAX_MSGS
+	i2cRdwrMaxMsgs = 42
+)
+
+// Mirror of struct i2c_msg.
+type i2cMsg struct {
+	addr  uint16
+	flags uint16
+	len   uint16
+	buf   *byte
+}
+
+// Mirror of struct i2c_rdwr_ioctl_data.
+type i2cRdwrData struct {
+	msgs  *i2cMsg
+	nmsgs uint32
+}
+
+// I2CDev is a Bus over Linux I2C character device (/dev/i2c-N), performing
+// every transfer with I2C_RDWR ioctl. Register reads are done in single
+// combined transaction with repeated start (RegisterReader), and long
+// register sequences in single ioctl call (BatchBus), which is faster
+// and works with clock-stretching setups and bridges, failing on split
+// write and read transactions of *i2c.I2C.
+type I2CDev struct {
+	file *os.File
+	bus  int
+	addr byte
+}
+
+// OpenI2CDev opens connection to device with address given
+// on Linux I2C-bus /dev/i2c-<bus>.
+func OpenI2CDev(bus int, addr byte) (*I2CDev, error) {
+	f, err := os.OpenFile(fmt.Sprintf("/dev/i2c-%d", bus), os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	v := &I2CDev{file: f, bus: bus, addr: addr}
+	return v, nil
+}
+
+// GetBus returns I2C-bus number.
+func (v *I2CDev) GetBus() int {
+	return v.bus
+}
+
+// GetAddr returns device address.
+func (v *I2CDev) GetAddr() byte {
+	return v.addr
+}
+
+// Close connection.
+func (v *I2CDev) Close() error {
+	return v.file.Close()
+}
+
+// Build message of transfer.
+func (v *I2CDev) msg(buf []byte, flags uint16) i2cMsg {
+	return i2cMsg{addr: uint16(v.addr), flags: flags,
+		len: uint16(len(buf)), buf: unsafe.SliceData(buf)}
+}
+
+// Perform messages in single I2C_RDWR ioctl call.
+func (v *I2CDev) transfer(msgs []i2cMsg) error {
+	data := i2cRdwrData{msgs: unsafe.SliceData(msgs), nmsgs: uint32(len(msgs))}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, v.file.Fd(),
+		i2cRdwr, uintptr(unsafe.Pointer(&data)))
+	if errno != 0 {
+		return errors.New(spew.Sprintf("I2C_RDWR transfer to 0x%x failed: %s",
+			v.addr, errno))
+	}
+	return nil
+}
+
+// ReadReg implement RegisterReader interface.
+func (v *I2CDev) ReadReg(reg byte, buf []byte) error {
+	index := [1]byte{reg}
+	return v.transfer([]i2cMsg{v.msg(index[:], 0), v.msg(buf, i2cMRd)})
+}
+
+// ReadRegU8 implement Bus interface.
+func (v *I2CDev) ReadRegU8(reg byte) (byte, error) {
+	var buf [1]byte
+	err := v.ReadReg(reg, buf[:])
+	return buf[0], err
+}
+
+// WriteRegU8 implement Bus interface.
+func (v *I2CDev) WriteRegU8(reg byte, value byte) error {
+	buf := [2]byte{reg, value}
+	return v.transfer([]i2cMsg{v.msg(buf[:], 0)})
+}
+
+// ReadBytes implement Bus interface.
+func (v *I2CDev) ReadBytes(buf []byte) (int, error) {
+	err := v.transfer([]i2cMsg{v.msg(buf, i2cMRd)})
+	if err != nil {
+		return 0, err
+	}
+	return len(buf), nil
+}
+
+// WriteBytes implement Bus interface.
+func (v *I2CDev) WriteBytes(buf []byte) (int, error) {
+	err := v.transfer([]i2cMsg{v.msg(buf, 0)})
+	if err != nil {
+		return 0, err
+	}
+	return len(buf), nil
+}
+
+// WriteBatch implement BatchBus interface. Messages are split
+// into several ioctl calls, if kernel limit is exceeded.
+func (v *I2CDev) WriteBatch(msgs [][]byte) error {
+	for len(msgs) > 0 {
+		n := min(len(msgs), i2cRdwrMaxMsgs)
+		batch := make([]i2cMsg, n)
+		for i, m := range msgs[:n] {
+			batch[i] = v.msg(m, 0)
+		}
+		err := v.transfer(batch)
+		if err != nil {
+			return err
+		}
+		msgs = msgs[n:]
+	}
+	return nil
+}
+
+// OpenAddress implement AddressableBus interface.
+func (v *I2CDev) OpenAddress(addr byte) (Bus, error) {
+	return OpenI2CDev(v.bus, addr)
+}
@@ -0,0 +1,106 @@
+package vl53l0x
+
+import (
+	"time"
+)
+
+// InterruptWaiter waits for data ready signal on the sensor GPIO1 pin.
+// Sensor is configured by Init to drive GPIO1 active low, when new
+// measurement is available. Implementation based on Linux GPIO
+// character device could be found in "gpio" subpackage.
+type InterruptWaiter interface {
+	// WaitInterrupt blocks until interrupt signal is detected, or timeout
+	// expired. Returns false on timeout.
+	WaitInterrupt(timeout time.Duration) (bool, error)
+}
+
+// SetInterruptWaiter enable waiting for data ready on GPIO1 interrupt line
+// instead of polling RESULT_INTERRUPT_STATUS register over I2C-bus, which cut
+// bus traffic and latency in continuous mode. Pass nil to return to polling.
+// Driver falls back to polling, if interrupt is missed or waiter fails.
+func (v *Vl53l0x) SetInterruptWaiter(waiter InterruptWaiter) {
+	v.interrupt = waiter
+}
+
+// Check data ready bits of RESULT_INTERRUPT_STATUS register.
+func (v *Vl53l0x) isDataReady(checkReg byte) bool {
+	return checkReg&0x07 != 0
+}
+
+// Wait until new measurement is available, using interrupt line if configured,
+// and read block of RESULT_INTERRUPT_STATUS and result registers.
+// Returns the moment when data ready condition observed.
+func (v *Vl53l0x) waitDataReady(i2c Bus, block *[resultBlockSize + 1]byte) (time.Time, error) {
+	if v.interrupt == nil {
+		v.sleepUntilAlmostReady()
+	} else {
+		// Status register is checked anyway after interrupt wait,
+		// so stale or missed interrupt doesn't break measurement.
+		ok, err := v.interrupt.WaitInterrupt(v.ioTimeout)
+		if err != nil {
+			warnf("Interrupt wait failed, fall back to polling: %s", err)
+		} else if !ok {
+			debug("Interrupt missed, fall back to polling")
+		}
+	}
+	// measurement is most likely complete here, so try to get status
+	// together with result, before falling back to status polling
+	err := v.readRegBytes(i2c, RESULT_INTERRUPT_STATUS, block[:])
+	if err != nil {
+		return time.Time{}, err
+	}
+	if v.isDataReady(block[0]) {
+		return time.Now(), nil
+	}
+	err = v.waitUntilOrTimeout(i2c, WaitDataReady, RESULT_INTERRUPT_STATUS,
+		func(checkReg byte, err error) (bool, error) {
+			return v.isDataReady(checkReg), err
+		})
+	if err != nil {
+		return time.Time{}, err
+	}
+	ts := time.Now()
+	err = v.readRegBytes(i2c, RESULT_RANGE_STATUS, block[1:])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts, nil
+}
+
+// Part of timing budget, which is not slept through before polling
+// starts, since real measurement time slightly differs from estimated one.
+const dataReadyMarginPercent = 10
+
+// Expected duration of measurement started at v.rangingStarted:
+// timing budget, or inter-measurement period in continuous timed mode,
+// if it's longer.
+func (v *Vl53l0x) expectedMeasurementTime() time.Duration {
+	d := time.Duration(v.measurementTimingBudgetUsec) * time.Microsecond
+	if v.settings.continuous {
+		d = max(d, time.Duration(v.settings.periodMs)*time.Millisecond)
+	}
+	return d
+}
+
+// Sleep until measurement is almost done (timing budget minus margin since
+// ranging started), instead of polling RESULT_INTERRUPT_STATUS all the
+// time, which costs hundreds of pointless I2C-bus reads per measurement
+// with long timing budgets.
+func (v *Vl53l0x) sleepUntilAlmostReady() {
+	if v.rangingStarted.IsZero() {
+		return
+	}
+	d := v.expectedMeasurementTime()
+	d -= d * dataReadyMarginPercent / 100
+	wait := time.Until(v.rangingStarted.Add(d))
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// Remember the moment when ranging started: single-shot measurement
+// triggered, continuous mode started, or previous result read
+// in continuous mode.
+func (v *Vl53l0x) markRangingStarted() {
+	v.rangingStarted = time.Now()
+}
@@ -0,0 +1,202 @@
+package vl53l0x
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// Transaction is a type of register transaction,
+// latency of which is accounted separately.
+type Transaction int
+
+const (
+	// TransactionReadU8 is a single register read.
+	TransactionReadU8 Transaction = iota
+	// TransactionReadBlock is a multi-byte read: 16-bit and 32-bit
+	// registers, result block, SPAD map and so on.
+	TransactionReadBlock
+	// TransactionWriteU8 is a single register write.
+	TransactionWriteU8
+	// TransactionWriteBlock is a multi-byte write.
+	TransactionWriteBlock
+	// TransactionWriteBatch is a set of writes sent in single
+	// call to BatchBus.
+	TransactionWriteBatch
+	// number of transaction types
+	transactionTypes
+)
+
+// String implement Stringer interface.
+func (v Transaction) String() string {
+	switch v {
+	case TransactionReadU8:
+		return "ReadU8"
+	case TransactionReadBlock:
+		return "ReadBlock"
+	case TransactionWriteU8:
+		return "WriteU8"
+	case TransactionWriteBlock:
+		return "WriteBlock"
+	case TransactionWriteBatch:
+		return "WriteBatch"
+	default:
+		return "<unknown>"
+	}
+}
+
+// LatencyStats keeps latency statistics of single transaction type,
+// including failed transactions. P99 is approximated by histogram
+// with resolution of about 12%.
+type LatencyStats struct {
+	Count uint64
+	Min   time.Duration
+	Avg   time.Duration
+	P99   time.Duration
+}
+
+// String implement Stringer interface.
+func (v LatencyStats) String() string {
+	return spew.Sprintf("count=%d, min=%v, avg=%v, p99=%v",
+		v.Count, v.Min, v.Avg, v.P99)
+}
+
+// IoStats keeps I2C-bus transaction counters of the sensor.
+type IoStats struct {
+	Reads  uint64
+	Writes uint64
+	// failed transactions, both reads and writes
+	Errors uint64
+	// latency by transaction type, only types seen are present;
+	// allows to quantify, how much bus contention or kernel
+	// scheduling affects measurement jitter
+	Latency map[Transaction]LatencyStats
+}
+
+// Transaction counters, updated atomically, so they could
+// be read from other goroutines, for instance by metrics exporters.
+type ioCounters struct {
+	reads   atomic.Uint64
+	writes  atomic.Uint64
+	errors  atomic.Uint64
+	latency [transactionTypes]latencyHistogram
+}
+
+// Latency histogram has linear buckets of 1 µs up to 8 µs, and then
+// 8 buckets per power of 2, up to 2^latencyMaxPower µs (about 33 s).
+const (
+	latencySubBuckets = 8
+	latencyMaxPower   = 25
+	latencyBuckets    = (latencyMaxPower - 2) * latencySubBuckets
+)
+
+// Lock-free latency histogram.
+type latencyHistogram struct {
+	count atomic.Uint64
+	// sum of latencies in nanoseconds
+	sum atomic.Int64
+	// minimal latency in nanoseconds plus 1, so zero means "not set"
+	min     atomic.Int64
+	buckets [latencyBuckets]atomic.Uint64
+}
+
+// Returns bucket index of latency.
+func latencyBucket(d time.Duration) int {
+	us := uint64(max(d, 0) / time.Microsecond)
+	if us < latencySubBuckets {
+		return int(us)
+	}
+	// us is in [2^p, 2^(p+1)), take 3 bits after the leading one
+	p := bits.Len64(us) - 1
+	sub := int(us>>(p-3)) & (latencySubBuckets - 1)
+	return min((p-2)*latencySubBuckets+sub, latencyBuckets-1)
+}
+
+// Returns upper bound of bucket with index given.
+func latencyBucketBound(i int) time.Duration {
+	if i < latencySubBuckets {
+		return time.Duration(i+1) * time.Microsecond
+	}
+	p := i/latencySubBuckets + 2
+	sub := i % latencySubBuckets
+	return time.Duration(latencySubBuckets+sub+1) << (p - 3) * time.Microsecond
+}
+
+// Account latency of transaction.
+func (v *latencyHistogram) observe(d time.Duration) {
+	v.count.Add(1)
+	v.sum.Add(int64(d))
+	for {
+		m := v.min.Load()
+		if m != 0 && m-1 <= int64(d) {
+			break
+		}
+		if v.min.CompareAndSwap(m, int64(d)+1) {
+			break
+		}
+	}
+	v.buckets[latencyBucket(d)].Add(1)
+}
+
+// Returns latency statistics. Histogram could be updated concurrently,
+// so values are consistent only approximately.
+func (v *latencyHistogram) stats() LatencyStats {
+	count := v.count.Load()
+	if count == 0 {
+		return LatencyStats{}
+	}
+	s := LatencyStats{Count: count, Min: time.Duration(v.min.Load() - 1),
+		Avg: time.Duration(v.sum.Load() / int64(count))}
+	rank := uint64(math.Ceil(float64(count) * 0.99))
+	var seen uint64
+	for i := range v.buckets {
+		seen += v.buckets[i].Load()
+		if seen >= rank {
+			s.P99 = max(latencyBucketBound(i), s.Min)
+			break
+		}
+	}
+	return s
+}
+
+// GetIoStats returns I2C-bus transaction counters and latencies since
+// sensor instance created. It's safe to call it from any goroutine.
+func (v *Vl53l0x) GetIoStats() IoStats {
+	s := IoStats{Reads: v.io.reads.Load(), Writes: v.io.writes.Load(),
+		Errors: v.io.errors.Load(), Latency: make(map[Transaction]LatencyStats)}
+	for tx := range transactionTypes {
+		if l := v.io.latency[tx].stats(); l.Count > 0 {
+			s.Latency[tx] = l
+		}
+	}
+	return s
+}
+
+// MeasurementTimingBudget returns measurement timing budget
+// cached by driver, without I2C-bus access.
+func (v *Vl53l0x) MeasurementTimingBudget() time.Duration {
+	return time.Duration(v.measurementTimingBudgetUsec) * time.Microsecond
+}
+
+// Account read transaction started at the moment given, passing error through.
+func (v *Vl53l0x) countRead(tx Transaction, start time.Time, err error) error {
+	v.io.latency[tx].observe(time.Since(start))
+	v.io.reads.Add(1)
+	if err != nil {
+		v.io.errors.Add(1)
+	}
+	return err
+}
+
+// Account write transaction started at the moment given, passing error through.
+func (v *Vl53l0x) countWrite(tx Transaction, start time.Time, err error) error {
+	v.io.latency[tx].observe(time.Since(start))
+	v.io.writes.Add(1)
+	if err != nil {
+		v.io.errors.Add(1)
+	}
+	return err
+}
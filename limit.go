@@ -0,0 +1,91 @@
+package vl53l0x
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrLimitReached returned by continuous mode reads, once ranging limit
+// set by SetRangingLimit is reached and continuous mode is stopped.
+var ErrLimitReached = errors.New("ranging limit reached")
+
+// RangingLimit stops continuous mode after number of measurements or
+// duration, whichever comes first. Zero fields stand for no limit.
+type RangingLimit struct {
+	// number of measurements read, those discarded during warm-up included
+	Count int
+	// time since continuous mode started
+	Duration time.Duration
+}
+
+// State of ranging limit of current continuous mode session.
+type rangingLimitState struct {
+	active    bool
+	remaining int
+	deadline  time.Time
+	reached   bool
+}
+
+// SetRangingLimit make continuous mode started by StartContinuous, Stream,
+// Run, Listen and so on stop by itself after limit given, which simplifies
+// capture scripts and tests. Once limit is reached, driver stops ranging,
+// continuous reads return ErrLimitReached and Stream closes channel without
+// error measurement. Duration limit is checked on the next read. Takes effect
+// on the next StartContinuous call; zero limit, which is default, disables it.
+func (v *Vl53l0x) SetRangingLimit(limit RangingLimit) {
+	v.rangingLimit = limit
+}
+
+// Arm ranging limit at the start of continuous mode session.
+func (v *Vl53l0x) armRangingLimit() {
+	limit := v.rangingLimit
+	v.limitState = rangingLimitState{
+		active:    limit.Count > 0 || limit.Duration > 0,
+		remaining: limit.Count,
+	}
+	if limit.Duration > 0 {
+		v.limitState.deadline = time.Now().Add(limit.Duration)
+	}
+}
+
+// Restart continuous mode within the same session, for instance after
+// recovery or reconfiguration, so ranging limit isn't re-armed.
+func (v *Vl53l0x) restartContinuous(i2c Bus, periodMs uint32) error {
+	limit := v.limitState
+	err := v.StartContinuous(i2c, periodMs)
+	v.limitState = limit
+	return err
+}
+
+// Read measurement in continuous mode, stopping ranging,
+// once limit is reached.
+func (v *Vl53l0x) readContinuous(i2c Bus) (Measurement, error) {
+	state := &v.limitState
+	if !state.active {
+		return v.withRecovery(i2c, v.readMeasurement)
+	}
+	if !state.reached && !state.deadline.IsZero() && !time.Now().Before(state.deadline) {
+		debug("Ranging duration limit reached")
+		state.reached = true
+		err := v.stopContinuousAndClear(i2c)
+		if err != nil {
+			return Measurement{}, err
+		}
+	}
+	if state.reached {
+		return Measurement{}, ErrLimitReached
+	}
+	m, err := v.withRecovery(i2c, v.readMeasurement)
+	if err != nil {
+		return m, err
+	}
+	if state.remaining > 0 {
+		state.remaining--
+		if state.remaining == 0 {
+			debug("Ranging count limit reached")
+			state.reached = true
+			err = v.stopContinuousAndClear(i2c)
+		}
+	}
+	return m, err
+}
@@ -0,0 +1,95 @@
+package vl53l0x
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRangingLimit(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	v.SetRangingLimit(RangingLimit{Count: 3})
+	ch, err := v.Stream(context.Background(), bus, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for m := range ch {
+		if m.Err != nil {
+			t.Fatalf("stream failed: %v", m.Err)
+		}
+		count++
+	}
+	if count != 3 {
+		t.Fatalf("got %d measurements, want 3", count)
+	}
+	if v.settings.continuous {
+		t.Error("continuous mode isn't stopped")
+	}
+	v.SetRangingLimit(RangingLimit{Duration: time.Nanosecond})
+	err = v.Run(context.Background(), bus, 0, func(m Measurement) error {
+		return errors.New("measurement after duration limit")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRangingLimitSampleAtRate(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	v.SetRangingLimit(RangingLimit{Count: 2})
+	ch, err := v.SampleAtRate(context.Background(), bus, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for s := range ch {
+		if s.Err != nil {
+			t.Fatalf("sample %d failed: %v", count+1, s.Err)
+		}
+		count++
+	}
+	if count == 0 {
+		t.Fatal("no samples delivered")
+	}
+}
+
+// Restart within the same session, as on mode switch or budget
+// retune, shouldn't extend the limit.
+func TestRangingLimitRestart(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	v.SetRangingLimit(RangingLimit{Count: 3})
+	err := v.StartContinuous(bus, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		_, err = v.ReadMeasurementContinuous(bus)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			err = v.restartContinuous(bus, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	_, err = v.ReadMeasurementContinuous(bus)
+	if !errors.Is(err, ErrLimitReached) {
+		t.Fatalf("got error %v, want %v", err, ErrLimitReached)
+	}
+}
+
+func TestRangingLimitCapture(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	v.SetRangingLimit(RangingLimit{Count: 3})
+	list, err := v.Capture(bus, 5, 0)
+	if !errors.Is(err, ErrLimitReached) {
+		t.Fatalf("got error %v, want ErrLimitReached", err)
+	}
+	if len(list) != 3 {
+		t.Errorf("got %d measurements, want 3", len(list))
+	}
+}
@@ -1,6 +1,13 @@
 package vl53l0x
 
-import logger "github.com/d2r2/go-logger"
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	logger "github.com/d2r2/go-logger"
+)
 
 // You can manage verbosity of log output
 // in the package by changing last parameter value.
@@ -8,3 +15,139 @@ var lg = logger.NewPackageLogger("vl53l0x",
 	logger.DebugLevel,
 	// logger.InfoLevel,
 )
+
+// Set, when package log level is below debug one, so debug messages
+// are skipped without formatting their arguments.
+var debugOff atomic.Bool
+
+// SetLogLevel change log level of the package. Prefer it to
+// logger.ChangePackageLogLevel: when level is below logger.DebugLevel,
+// debug messages are dropped by cheap atomic check before formatting,
+// which matters for continuous ranging at high rate.
+func SetLogLevel(level logger.LogLevel) error {
+	debugOff.Store(level < logger.DebugLevel)
+	return logger.ChangePackageLogLevel("vl53l0x", level)
+}
+
+// Report whether debug messages are logged.
+func debugEnabled() bool {
+	return !debugOff.Load()
+}
+
+// Log debug message, if enabled.
+func debug(args ...interface{}) {
+	if debugEnabled() {
+		lg.Debug(args...)
+	}
+}
+
+// Log formatted debug message, if enabled. Arguments, which are
+// expensive to pass (structures boxed to interface), should be
+// guarded by debugEnabled at call site instead.
+func debugf(format string, args ...interface{}) {
+	if debugEnabled() {
+		lg.Debugf(format, args...)
+	}
+}
+
+// Warnings and errors are rate limited, so flapping sensor doesn't flood
+// log with the same message: messages sharing format are logged up to
+// burst times per interval, the rest is suppressed and counted.
+var logLimit = newLogLimiter(time.Minute, 5)
+
+// SetLogRateLimit change rate limit of warning and error messages logged
+// by the package: up to burst messages sharing format are logged per
+// interval, and number of suppressed ones is appended to the first
+// message of the next interval. Default is 5 messages per minute;
+// zero interval disables rate limiting.
+func SetLogRateLimit(interval time.Duration, burst int) {
+	logLimit.Lock()
+	defer logLimit.Unlock()
+	logLimit.interval = interval
+	logLimit.burst = burst
+	clear(logLimit.entries)
+}
+
+// SuppressedLogMessages returns number of warning and error messages
+// suppressed by rate limit since start, by message format.
+func SuppressedLogMessages() map[string]uint64 {
+	logLimit.Lock()
+	defer logLimit.Unlock()
+	return maps.Clone(logLimit.suppressed)
+}
+
+// Rate limiter of log messages.
+type logLimiter struct {
+	sync.Mutex
+	interval time.Duration
+	burst    int
+	// current interval state by message format
+	entries map[string]*logEntry
+	// total suppressed messages by message format
+	suppressed map[string]uint64
+}
+
+// State of message format within current interval.
+type logEntry struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+func newLogLimiter(interval time.Duration, burst int) *logLimiter {
+	v := &logLimiter{interval: interval, burst: burst,
+		entries: make(map[string]*logEntry), suppressed: make(map[string]uint64)}
+	return v
+}
+
+// Decide whether message should be logged at time now. Returns number
+// of messages suppressed within previous interval, when new one starts.
+func (v *logLimiter) allow(format string, now time.Time) (bool, int) {
+	v.Lock()
+	defer v.Unlock()
+	if v.interval <= 0 {
+		return true, 0
+	}
+	e := v.entries[format]
+	if e == nil || now.Sub(e.start) >= v.interval {
+		var suppressed int
+		if e != nil {
+			suppressed = e.suppressed
+		}
+		v.entries[format] = &logEntry{start: now, count: 1}
+		return true, suppressed
+	}
+	if e.count < v.burst {
+		e.count++
+		return true, 0
+	}
+	e.suppressed++
+	v.suppressed[format]++
+	return false, 0
+}
+
+// Prepare rate limited message, returns false if it's suppressed.
+func limitLog(format string, args []interface{}) (string, []interface{}, bool) {
+	ok, suppressed := logLimit.allow(format, time.Now())
+	if ok && suppressed > 0 {
+		format += " (%d similar messages suppressed)"
+		args = append(args, suppressed)
+	}
+	return format, args, ok
+}
+
+// Log rate limited warning.
+func warnf(format string, args ...interface{}) {
+	format, args, ok := limitLog(format, args)
+	if ok {
+		lg.Warnf(format, args...)
+	}
+}
+
+// Log rate limited error.
+func errorf(format string, args ...interface{}) {
+	format, args, ok := limitLog(format, args)
+	if ok {
+		lg.Errorf(format, args...)
+	}
+}
@@ -0,0 +1,27 @@
+package vl53l0x
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLogRateLimit(t *testing.T) {
+	v := newLogLimiter(time.Minute, 2)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		ok, suppressed := v.allow("timeout", start.Add(time.Duration(i)*time.Second))
+		if ok != (i < 2) || suppressed != 0 {
+			t.Fatalf("message %d: allowed %v, suppressed %d", i, ok, suppressed)
+		}
+	}
+	if ok, _ := v.allow("other", start); !ok {
+		t.Fatal("message of other format is suppressed")
+	}
+	ok, suppressed := v.allow("timeout", start.Add(time.Minute))
+	if !ok || suppressed != 3 {
+		t.Fatalf("next interval: allowed %v, suppressed %d, want 3", ok, suppressed)
+	}
+	if n := v.suppressed["timeout"]; n != 3 {
+		t.Fatalf("suppression counter is %d, want 3", n)
+	}
+}
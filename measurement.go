@@ -0,0 +1,332 @@
+package vl53l0x
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	"time"
+)
+
+// RangeStatus describes measurement validity, decoded from device range
+// status similar to VL53L0X_get_pal_range_status().
+type RangeStatus int
+
+const (
+	// RangeValid means measured distance could be trusted.
+	RangeValid RangeStatus = iota
+	// SigmaFail means estimated standard deviation of measurement is too high.
+	SigmaFail
+	// SignalFail means return signal is too weak.
+	SignalFail
+	// MinRangeFail means target is too close, or reference signal clipped.
+	MinRangeFail
+	// PhaseFail means phase of return signal is out of valid limits.
+	PhaseFail
+	// HardwareFail means VCSEL or PLL failure reported by sensor.
+	HardwareFail
+	// OutOfRange means no target detected within measurable range;
+	// sensor returns distance of 8190 mm or more in this case.
+	OutOfRange
+	// RangeStatusNone means device reported no update.
+	RangeStatusNone
+)
+
+// String implement Stringer interface.
+func (v RangeStatus) String() string {
+	switch v {
+	case RangeValid:
+		return "RangeValid"
+	case SigmaFail:
+		return "SigmaFail"
+	case SignalFail:
+		return "SignalFail"
+	case MinRangeFail:
+		return "MinRangeFail"
+	case PhaseFail:
+		return "PhaseFail"
+	case HardwareFail:
+		return "HardwareFail"
+	case OutOfRange:
+		return "OutOfRange"
+	case RangeStatusNone:
+		return "RangeStatusNone"
+	default:
+		return "<unknown>"
+	}
+}
+
+// ErrOutOfRange returned by simple read functions,
+// when no target detected within measurable range.
+var ErrOutOfRange = errors.New("out of range: no target detected")
+
+// Measurement keeps result of single ranging measurement.
+type Measurement struct {
+	// measured distance in millimeters; value is meaningful
+	// only when Status is RangeValid
+	RangeMillimeters uint16
+	// validity of measurement
+	Status RangeStatus
+	// moment when data ready condition observed; keeps monotonic
+	// clock reading, so suitable for time intervals calculation
+	Timestamp time.Time
+	// return signal rate and ambient rate in MCPS
+	SignalRateMcps  float32
+	AmbientRateMcps float32
+	// number of SPADs used for measurement
+	EffectiveSpadCount float32
+	// estimated standard deviation of measured distance in millimeters
+	SigmaMillimeters float32
+	// normalized measurement confidence in range [0..1], combining range
+	// status, sigma, signal and ambient rates; zero for invalid measurements
+	Confidence float32
+	// acquisition error; set only for measurements delivered
+	// over channels, where no other way to return an error
+	Err error
+}
+
+// Range value returned by the sensor, when no target detected
+// or signal is too weak.
+const outOfRangeMillimeters = 8190
+
+// Size of result block starting from RESULT_RANGE_STATUS register.
+const resultBlockSize = 12
+
+// Valid returns true, if measurement holds real distance.
+func (m Measurement) Valid() bool {
+	return m.Err == nil && m.Status == RangeValid
+}
+
+// JSON representation of Measurement.
+type measurementJSON struct {
+	RangeMillimeters   uint16    `json:"range_mm"`
+	Status             string    `json:"status"`
+	Valid              bool      `json:"valid"`
+	Timestamp          time.Time `json:"timestamp"`
+	SignalRateMcps     float32   `json:"signal_rate_mcps"`
+	AmbientRateMcps    float32   `json:"ambient_rate_mcps"`
+	EffectiveSpadCount float32   `json:"effective_spad_count"`
+	SigmaMillimeters   float32   `json:"sigma_mm"`
+	Confidence         float32   `json:"confidence"`
+	Err                string    `json:"error,omitempty"`
+}
+
+// MarshalJSON implement json.Marshaler interface, used by
+// integrations publishing measurements as JSON.
+func (m Measurement) MarshalJSON() ([]byte, error) {
+	j := measurementJSON{RangeMillimeters: m.RangeMillimeters,
+		Status: m.Status.String(), Valid: m.Valid(), Timestamp: m.Timestamp,
+		SignalRateMcps: m.SignalRateMcps, AmbientRateMcps: m.AmbientRateMcps,
+		EffectiveSpadCount: m.EffectiveSpadCount,
+		SigmaMillimeters:   m.SigmaMillimeters, Confidence: m.Confidence}
+	if m.Err != nil {
+		j.Err = m.Err.Error()
+	}
+	return json.Marshal(j)
+}
+
+// Returns ErrOutOfRange, if no target detected.
+func (m Measurement) outOfRangeErr() error {
+	if m.Status == OutOfRange {
+		return ErrOutOfRange
+	}
+	return nil
+}
+
+// Decode range status from device range status and measured distance.
+// Based on VL53L0X_get_pal_range_status(), but without sigma and signal
+// limit checks, which are performed by the sensor itself.
+func decodeRangeStatus(deviceRangeStatus byte, rng uint16) RangeStatus {
+	switch deviceRangeStatus {
+	case 1, 2, 3:
+		return HardwareFail
+	}
+	if rng >= outOfRangeMillimeters {
+		return OutOfRange
+	}
+	switch deviceRangeStatus {
+	case 6, 9:
+		return PhaseFail
+	case 8, 10:
+		return MinRangeFail
+	case 4:
+		return SignalFail
+	case 11:
+		return RangeValid
+	default:
+		return RangeStatusNone
+	}
+}
+
+// Wait for measurement completion and read the result.
+func (v *Vl53l0x) readMeasurement(i2c Bus) (Measurement, error) {
+	var m Measurement
+	// block starts from RESULT_INTERRUPT_STATUS, which immediately
+	// precedes result registers, so data ready check and result
+	// are read in single transaction in most cases
+	block := &v.resultBuf
+	ts, err := v.waitDataReady(i2c, block)
+	if err != nil {
+		err = v.deviceResetCause(i2c, err)
+		v.recordError(err)
+		return m, err
+	}
+	err = v.writeRegU8(i2c, SYSTEM_INTERRUPT_CLEAR, 0x01)
+	if err != nil {
+		v.recordError(err)
+		return m, err
+	}
+	if v.settings.continuous {
+		// next measurement is in progress already
+		v.markRangingStarted()
+	} else {
+		v.rangingStarted = time.Time{}
+	}
+	result := (*[resultBlockSize]byte)(block[1:])
+	v.recordResult(ts, result[:])
+	v.parseResult(&m, result, ts, v.measurementTimingBudgetUsec)
+	err = v.checkDeviceResetIfDue(i2c, &m)
+	if err != nil {
+		v.recordError(err)
+		return m, err
+	}
+	return m, nil
+}
+
+// Decode result block read from RESULT_RANGE_STATUS register directly
+// into measurement and apply software checks and corrections. Timing budget
+// is passed explicitly, since replayed sessions keep their own one.
+func (v *Vl53l0x) parseResult(m *Measurement, buf *[resultBlockSize]byte,
+	ts time.Time, budgetUsec uint32) {
+
+	// assumptions: Linearity Corrective Gain is 1000 (default);
+	// fractional ranging is not enabled
+	rng := uint16(buf[10])<<8 | uint16(buf[11])
+	deviceRangeStatus := (buf[0] & 0x78) >> 3
+	*m = Measurement{RangeMillimeters: rng, Timestamp: ts,
+		Status: decodeRangeStatus(deviceRangeStatus, rng)}
+	// rates in Q9.7 fixed point format, SPAD count in Q8.8
+	m.SignalRateMcps = float32(uint16(buf[6])<<8|uint16(buf[7])) / (1 << 7)
+	m.AmbientRateMcps = float32(uint16(buf[8])<<8|uint16(buf[9])) / (1 << 7)
+	m.EffectiveSpadCount = float32(uint16(buf[2])<<8|uint16(buf[3])) / (1 << 8)
+	if m.Status == RangeValid && v.rangeIgnoreThreshold != 0 &&
+		m.SignalRateMcps < v.rangeIgnoreThreshold {
+		m.Status = SignalFail
+	}
+	if m.Status == RangeValid {
+		m.RangeMillimeters = v.correctRange(m.RangeMillimeters)
+	}
+	m.SigmaMillimeters = estimateSigma(m.SignalRateMcps, m.AmbientRateMcps,
+		budgetUsec)
+	m.Confidence = calcConfidence(m.Status, m.SigmaMillimeters,
+		m.SignalRateMcps, m.AmbientRateMcps)
+}
+
+// Measurements returns iterator over continuous mode readings, so it could be
+// used as "for m, err := range sensor.Measurements(ctx, i2c, period)".
+// Continuous mode is started with inter-measurement period given (0 stands
+// for back-to-back mode) once iteration begins, and stopped when loop is
+// terminated, context is done, ranging limit is reached or error occurs.
+// Iteration stops after the first error yielded.
+func (v *Vl53l0x) Measurements(ctx context.Context, i2c Bus,
+	period time.Duration) iter.Seq2[Measurement, error] {
+
+	return func(yield func(Measurement, error) bool) {
+		err := v.StartContinuousDuration(i2c, period)
+		if err != nil {
+			yield(Measurement{}, err)
+			return
+		}
+		defer v.stopContinuousQuietly(i2c)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			m, err := v.ReadMeasurementContinuous(i2c)
+			if errors.Is(err, ErrLimitReached) {
+				return
+			}
+			if err == nil && v.warmingUp(m) {
+				continue
+			}
+			if !yield(m, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ErrStopRun returned by Run handler to stop the loop without error.
+var ErrStopRun = errors.New("run stopped by handler")
+
+// Run start continuous mode with inter-measurement period given (0 stands
+// for back-to-back mode) and invoke handler for each measurement, until
+// context is done or handler returns an error. Continuous mode is stopped
+// and pending interrupt cleared on any exit, including handler panic, so
+// sensor is left ready for single-shot measurements or reconfiguration.
+// Returns nil, if context is done, handler returns ErrStopRun or ranging
+// limit set by SetRangingLimit is reached, otherwise the first error of
+// acquisition, handler or teardown.
+func (v *Vl53l0x) Run(ctx context.Context, i2c Bus, period time.Duration,
+	handler func(m Measurement) error) (err error) {
+
+	err = v.StartContinuousDuration(i2c, period)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err2 := v.stopContinuousAndClear(i2c)
+		if err == nil {
+			err = err2
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		var m Measurement
+		m, err = v.ReadMeasurementContinuous(i2c)
+		if errors.Is(err, ErrLimitReached) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if v.warmingUp(m) {
+			continue
+		}
+		err = handler(m)
+		if errors.Is(err, ErrStopRun) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Stop continuous mode and clear pending interrupt. Interrupt is cleared
+// even if stop fails; the first error is returned.
+func (v *Vl53l0x) stopContinuousAndClear(i2c Bus) error {
+	err := v.StopContinuous(i2c)
+	err2 := v.writeRegU8(i2c, SYSTEM_INTERRUPT_CLEAR, 0x01)
+	if err == nil {
+		err = err2
+	}
+	return err
+}
+
+// Stop continuous mode and clear pending interrupt. Used on exit paths,
+// which have no way to return an error, so errors are only logged.
+func (v *Vl53l0x) stopContinuousQuietly(i2c Bus) {
+	err := v.stopContinuousAndClear(i2c)
+	if err != nil {
+		warnf("Error stopping continuous measures: %s", err)
+	}
+}
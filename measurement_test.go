@@ -0,0 +1,31 @@
+package vl53l0x
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunTeardown(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	errHandler := errors.New("handler failed")
+	count := 0
+	err := v.Run(context.Background(), bus, 0, func(m Measurement) error {
+		count++
+		if count == 2 {
+			return errHandler
+		}
+		return nil
+	})
+	if !errors.Is(err, errHandler) {
+		t.Fatalf("got error %v, want handler one", err)
+	}
+	trace := bus.trace.String()
+	if !strings.HasSuffix(trace, "0b 01\n") {
+		t.Errorf("interrupt isn't cleared on exit:\n%s", trace)
+	}
+	if v.settings.continuous {
+		t.Error("continuous mode isn't stopped")
+	}
+}
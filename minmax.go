@@ -0,0 +1,96 @@
+package vl53l0x
+
+import (
+	"sync"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// Extremes keeps minimum and maximum distances seen by MinMaxTracker.
+type Extremes struct {
+	// no valid measurements seen since reset, if false
+	Valid bool
+	// distances in millimeters and time they were measured
+	Min     uint16
+	MinTime time.Time
+	Max     uint16
+	MaxTime time.Time
+	// time of the last reset
+	Since time.Time
+}
+
+// String implement Stringer interface.
+func (v Extremes) String() string {
+	if !v.Valid {
+		return "no valid measurements"
+	}
+	return spew.Sprintf("min=%d mm at %s, max=%d mm at %s",
+		v.Min, v.MinTime.Format(time.RFC3339), v.Max, v.MaxTime.Format(time.RFC3339))
+}
+
+// MinMaxTracker records minimum and maximum distance seen since the last
+// reset with their timestamps, for instance to log closest approach
+// in parking and docking applications. Safe for concurrent use.
+type MinMaxTracker struct {
+	sync.Mutex
+	extremes Extremes
+}
+
+// NewMinMaxTracker creates tracker with nothing seen yet.
+func NewMinMaxTracker() *MinMaxTracker {
+	v := &MinMaxTracker{extremes: Extremes{Since: time.Now()}}
+	return v
+}
+
+// Add measurement to tracker. Invalid measurements are ignored.
+func (v *MinMaxTracker) Add(m Measurement) {
+	if !m.Valid() {
+		return
+	}
+	ts := m.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	v.Lock()
+	defer v.Unlock()
+	e := &v.extremes
+	rng := m.RangeMillimeters
+	if !e.Valid || rng < e.Min {
+		e.Min, e.MinTime = rng, ts
+	}
+	if !e.Valid || rng > e.Max {
+		e.Max, e.MaxTime = rng, ts
+	}
+	e.Valid = true
+}
+
+// Attach returns channel repeating measurements from stream given,
+// adding each of them to tracker on the way.
+func (v *MinMaxTracker) Attach(in <-chan Measurement) <-chan Measurement {
+	out := make(chan Measurement, cap(in))
+	go func() {
+		defer close(out)
+		for m := range in {
+			v.Add(m)
+			out <- m
+		}
+	}()
+	return out
+}
+
+// Extremes returns minimum and maximum seen since the last reset.
+func (v *MinMaxTracker) Extremes() Extremes {
+	v.Lock()
+	defer v.Unlock()
+	return v.extremes
+}
+
+// Reset forgets extremes seen so far, returning them.
+func (v *MinMaxTracker) Reset() Extremes {
+	v.Lock()
+	defer v.Unlock()
+	e := v.extremes
+	v.extremes = Extremes{Since: time.Now()}
+	return e
+}
@@ -0,0 +1,275 @@
+// Package modbus exposes VL53L0X measurements and health as Modbus TCP
+// input registers, for industrial PLC environments, which could only
+// consume Modbus. Each sensor of an array is served under its own unit ID.
+//
+// Input register map (function code 0x04), all values are unsigned 16-bit:
+//
+//	0      distance in millimeters of last measurement
+//	1      range status (vl53l0x.RangeStatus)
+//	2      valid flag: 1 if last measurement is valid, 0 otherwise
+//	3      confidence multiplied by 1000
+//	4      return signal rate in MCPS multiplied by 100
+//	5      ambient rate in MCPS multiplied by 100
+//	6      health: 0 ok, 1 acquisition error, 2 stale (no data within timeout)
+//	7, 8   number of measurements (32-bit, high word first)
+//	9, 10  number of acquisition errors (32-bit, high word first)
+//	11     age of last measurement in milliseconds, saturated at 65535
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// Health values of register 6.
+const (
+	HealthOk    = 0
+	HealthError = 1
+	HealthStale = 2
+)
+
+// Number of input registers per unit.
+const registerCount = 12
+
+// Modbus function codes and exception codes.
+const (
+	funcReadInputRegisters = 0x04
+
+	exIllegalFunction     = 0x01
+	exIllegalDataAddress  = 0x02
+	exIllegalDataValue    = 0x03
+	exGatewayTargetFailed = 0x0B
+)
+
+// Default time after which unit without new measurements is reported stale.
+const defaultStaleTimeout = time.Second * 5
+
+// Unit keeps registers of single sensor.
+type Unit struct {
+	sync.Mutex
+	last         vl53l0x.Measurement
+	updated      time.Time
+	failing      bool
+	count        uint32
+	errors       uint32
+	staleTimeout time.Duration
+}
+
+// Update registers with measurement. Measurements with error
+// switch health to "error" and increment error counter.
+func (v *Unit) Update(m vl53l0x.Measurement) {
+	v.Lock()
+	defer v.Unlock()
+	v.updated = time.Now()
+	if m.Err != nil {
+		v.failing = true
+		v.errors++
+		return
+	}
+	v.failing = false
+	v.count++
+	v.last = m
+}
+
+// Run update registers from stream, for instance one returned
+// by Vl53l0x.Stream, until it's closed.
+func (v *Unit) Run(in <-chan vl53l0x.Measurement) {
+	for m := range in {
+		v.Update(m)
+	}
+}
+
+// SetStaleTimeout define time, after which unit without new measurements
+// is reported stale. Default is 5 seconds.
+func (v *Unit) SetStaleTimeout(timeout time.Duration) {
+	v.Lock()
+	defer v.Unlock()
+	v.staleTimeout = timeout
+}
+
+// Build input registers content.
+func (v *Unit) registers(now time.Time) [registerCount]uint16 {
+	v.Lock()
+	defer v.Unlock()
+	scale := func(f float32, k float64) uint16 {
+		return uint16(math.Min(math.MaxUint16, math.Max(0, float64(f)*k)))
+	}
+	var r [registerCount]uint16
+	r[0] = v.last.RangeMillimeters
+	r[1] = uint16(v.last.Status)
+	if v.last.Valid() {
+		r[2] = 1
+	}
+	r[3] = scale(v.last.Confidence, 1000)
+	r[4] = scale(v.last.SignalRateMcps, 100)
+	r[5] = scale(v.last.AmbientRateMcps, 100)
+	age := now.Sub(v.updated)
+	switch {
+	case v.updated.IsZero() || age > v.staleTimeout:
+		r[6] = HealthStale
+	case v.failing:
+		r[6] = HealthError
+	default:
+		r[6] = HealthOk
+	}
+	r[7], r[8] = uint16(v.count>>16), uint16(v.count)
+	r[9], r[10] = uint16(v.errors>>16), uint16(v.errors)
+	if v.updated.IsZero() {
+		r[11] = math.MaxUint16
+	} else {
+		r[11] = uint16(min(age.Milliseconds(), math.MaxUint16))
+	}
+	return r
+}
+
+// Server is a Modbus TCP server, serving input registers of units.
+type Server struct {
+	sync.Mutex
+	units    map[byte]*Unit
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+// NewServer creates Modbus TCP server without units.
+func NewServer() *Server {
+	v := &Server{units: make(map[byte]*Unit), conns: make(map[net.Conn]struct{})}
+	return v
+}
+
+// AddUnit creates unit with ID given. Modbus TCP default unit ID is 1;
+// use distinct IDs for each sensor of an array.
+func (v *Server) AddUnit(id byte) *Unit {
+	v.Lock()
+	defer v.Unlock()
+	u := &Unit{staleTimeout: defaultStaleTimeout}
+	v.units[id] = u
+	return u
+}
+
+// ListenAndServe listens on TCP address (for instance ":502") and serves
+// requests until Close called.
+func (v *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return v.Serve(l)
+}
+
+// Serve accept connections on listener until Close called.
+func (v *Server) Serve(l net.Listener) error {
+	v.Lock()
+	v.listener = l
+	v.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		v.Lock()
+		v.conns[conn] = struct{}{}
+		v.Unlock()
+		v.wg.Add(1)
+		go v.serveConn(conn)
+	}
+}
+
+// Close stops listening, closes client connections and waits
+// for their handlers to finish.
+func (v *Server) Close() error {
+	v.Lock()
+	var err error
+	if v.listener != nil {
+		err = v.listener.Close()
+	}
+	for conn := range v.conns {
+		conn.Close()
+	}
+	v.Unlock()
+	v.wg.Wait()
+	return err
+}
+
+// Serve requests of single client connection.
+func (v *Server) serveConn(conn net.Conn) {
+	defer v.wg.Done()
+	defer func() {
+		v.Lock()
+		delete(v.conns, conn)
+		v.Unlock()
+		conn.Close()
+	}()
+	var header [7]byte
+	for {
+		// MBAP header: transaction id, protocol id, length, unit id
+		_, err := io.ReadFull(conn, header[:])
+		if err != nil {
+			return
+		}
+		length := binary.BigEndian.Uint16(header[4:6])
+		if binary.BigEndian.Uint16(header[2:4]) != 0 || length < 2 || length > 254 {
+			return
+		}
+		pdu := make([]byte, length-1)
+		_, err = io.ReadFull(conn, pdu)
+		if err != nil {
+			return
+		}
+		resp := v.handle(header[6], pdu)
+		out := make([]byte, 7, 7+len(resp))
+		copy(out, header[:])
+		binary.BigEndian.PutUint16(out[4:6], uint16(len(resp)+1))
+		out = append(out, resp...)
+		_, err = conn.Write(out)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Handle request PDU and returns response PDU.
+func (v *Server) handle(unitId byte, pdu []byte) []byte {
+	function := pdu[0]
+	exception := func(code byte) []byte {
+		return []byte{function | 0x80, code}
+	}
+	if function != funcReadInputRegisters {
+		return exception(exIllegalFunction)
+	}
+	if len(pdu) != 5 {
+		return exception(exIllegalDataValue)
+	}
+	start := int(binary.BigEndian.Uint16(pdu[1:3]))
+	quantity := int(binary.BigEndian.Uint16(pdu[3:5]))
+	if quantity < 1 || quantity > 125 {
+		return exception(exIllegalDataValue)
+	}
+	if start+quantity > registerCount {
+		return exception(exIllegalDataAddress)
+	}
+	v.Lock()
+	u := v.units[unitId]
+	v.Unlock()
+	if u == nil {
+		return exception(exGatewayTargetFailed)
+	}
+	regs := u.registers(time.Now())
+	resp := make([]byte, 2, 2+quantity*2)
+	resp[0] = function
+	resp[1] = byte(quantity * 2)
+	for _, r := range regs[start : start+quantity] {
+		resp = binary.BigEndian.AppendUint16(resp, r)
+	}
+	return resp
+}
@@ -0,0 +1,133 @@
+package vl53l0x
+
+import (
+	"github.com/davecgh/go-spew/spew"
+)
+
+// ModuleInfo keeps module identification read from sensor NVM.
+type ModuleInfo struct {
+	ModuleId uint8
+	Revision uint8
+	// part unique identifier
+	PartUidUpper uint32
+	PartUidLower uint32
+}
+
+// Uid returns part unique identifier as hex string,
+// suitable to be used as a key for per module data.
+func (v *ModuleInfo) Uid() string {
+	return spew.Sprintf("%08x%08x", v.PartUidUpper, v.PartUidLower)
+}
+
+// GetModuleInfo reads module identification and part unique identifier
+// from sensor NVM (non-volatile memory). Don't call it while ranging.
+// Based on VL53L0X_get_info_from_device(), option 2.
+func (v *Vl53l0x) GetModuleInfo(i2c Bus) (*ModuleInfo, error) {
+
+	debug("Start getting module info")
+
+	err := v.writeRegValues(i2c, []RegBytePair{
+		{Reg: 0x80, Value: 0x01},
+		{Reg: 0xFF, Value: 0x01},
+		{Reg: 0x00, Value: 0x00},
+		{Reg: 0xFF, Value: 0x06},
+	}...)
+	if err != nil {
+		return nil, err
+	}
+	u8, err := v.readRegU8(i2c, 0x83)
+	if err != nil {
+		return nil, err
+	}
+	err = v.writeRegValues(i2c, []RegBytePair{
+		{Reg: 0x83, Value: u8 | 0x04},
+		{Reg: 0xFF, Value: 0x07},
+		{Reg: 0x81, Value: 0x01},
+		{Reg: 0x80, Value: 0x01},
+	}...)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ModuleInfo{}
+	info.ModuleId, err = v.readNvmU8(i2c, 0x02)
+	if err != nil {
+		return nil, err
+	}
+	info.Revision, err = v.readNvmU8(i2c, 0x7B)
+	if err != nil {
+		return nil, err
+	}
+	info.PartUidUpper, err = v.readNvmU32(i2c, 0x7B)
+	if err != nil {
+		return nil, err
+	}
+	info.PartUidLower, err = v.readNvmU32(i2c, 0x7C)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.writeRegValues(i2c, []RegBytePair{
+		{Reg: 0x81, Value: 0x00},
+		{Reg: 0xFF, Value: 0x06},
+	}...)
+	if err != nil {
+		return nil, err
+	}
+	u8, err = v.readRegU8(i2c, 0x83)
+	if err != nil {
+		return nil, err
+	}
+	err = v.writeRegValues(i2c, []RegBytePair{
+		{Reg: 0x83, Value: u8 & ^byte(0x04)},
+		{Reg: 0xFF, Value: 0x01},
+		{Reg: 0x00, Value: 0x01},
+		{Reg: 0xFF, Value: 0x00},
+		{Reg: 0x80, Value: 0x00},
+	}...)
+	if err != nil {
+		return nil, err
+	}
+	if debugEnabled() {
+		lg.Debugf("Module info = %#v", info)
+	}
+	return info, nil
+}
+
+// Select NVM location and wait until it's loaded to 0x90 register.
+// Based on VL53L0X_device_read_strobe().
+func (v *Vl53l0x) readNvmStrobe(i2c Bus, addr byte) error {
+	err := v.writeRegValues(i2c, []RegBytePair{
+		{Reg: 0x94, Value: addr},
+		{Reg: 0x83, Value: 0x00},
+	}...)
+	if err != nil {
+		return err
+	}
+	err = v.waitUntilOrTimeout(i2c, WaitModuleInfo, 0x83,
+		func(checkReg byte, err error) (bool, error) {
+			return checkReg != 0, err
+		})
+	if err != nil {
+		return err
+	}
+	return v.writeRegU8(i2c, 0x83, 0x01)
+}
+
+// Read byte from NVM location.
+func (v *Vl53l0x) readNvmU8(i2c Bus, addr byte) (uint8, error) {
+	err := v.readNvmStrobe(i2c, addr)
+	if err != nil {
+		return 0, err
+	}
+	return v.readRegU8(i2c, 0x90)
+}
+
+// Read 32-bit word from NVM location.
+func (v *Vl53l0x) readNvmU32(i2c Bus, addr byte) (uint32, error) {
+	err := v.readNvmStrobe(i2c, addr)
+	if err != nil {
+		return 0, err
+	}
+	return v.readRegU32(i2c, 0x90)
+}
@@ -0,0 +1,108 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+)
+
+// DefaultDiscoveryPrefix is a Home Assistant default discovery prefix.
+const DefaultDiscoveryPrefix = "homeassistant"
+
+// Device describes physical device in Home Assistant device registry.
+// Zero fields are filled with defaults.
+type Device struct {
+	// unique identifier, for instance module UID from Vl53l0x.GetModuleInfo;
+	// defaults to "vl53l0x_<bus>_<address>"
+	Identifier   string
+	Name         string
+	Manufacturer string
+	Model        string
+}
+
+// Home Assistant MQTT discovery payloads.
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+	SwVersion    string   `json:"sw_version"`
+}
+
+type discoveryConfig struct {
+	Name                string          `json:"name"`
+	UniqueId            string          `json:"unique_id"`
+	ObjectId            string          `json:"object_id"`
+	StateTopic          string          `json:"state_topic"`
+	ValueTemplate       string          `json:"value_template"`
+	UnitOfMeasurement   string          `json:"unit_of_measurement"`
+	DeviceClass         string          `json:"device_class"`
+	StateClass          string          `json:"state_class"`
+	AvailabilityTopic   string          `json:"availability_topic"`
+	PayloadAvailable    string          `json:"payload_available"`
+	PayloadNotAvailable string          `json:"payload_not_available"`
+	Device              discoveryDevice `json:"device"`
+}
+
+// PublishDiscovery emits Home Assistant MQTT discovery message, so sensor
+// appears automatically as distance sensor entity in millimeters,
+// available while publisher status is "online". Out-of-range and
+// invalid measurements are reported as unknown state. Empty prefix
+// stands for DefaultDiscoveryPrefix.
+func (v *Publisher) PublishDiscovery(prefix string, device Device) error {
+	if prefix == "" {
+		prefix = DefaultDiscoveryPrefix
+	}
+	id := device.Identifier
+	if id == "" {
+		id = "vl53l0x_" + strconv.Itoa(v.config.Bus) + "_" +
+			strconv.FormatUint(uint64(v.config.Address), 16)
+	}
+	id = sanitizeId(id)
+	if device.Name == "" {
+		device.Name = v.config.Name
+	}
+	if device.Manufacturer == "" {
+		device.Manufacturer = "STMicroelectronics"
+	}
+	if device.Model == "" {
+		device.Model = "VL53L0X"
+	}
+	config := discoveryConfig{
+		Name:                v.config.Name,
+		UniqueId:            id + "_distance",
+		ObjectId:            id + "_distance",
+		StateTopic:          v.topic,
+		ValueTemplate:       "{{ value_json.range_mm if value_json.valid else None }}",
+		UnitOfMeasurement:   "mm",
+		DeviceClass:         "distance",
+		StateClass:          "measurement",
+		AvailabilityTopic:   v.statusTopic,
+		PayloadAvailable:    StatusOnline,
+		PayloadNotAvailable: StatusOffline,
+		Device: discoveryDevice{Identifiers: []string{id},
+			Name: device.Name, Manufacturer: device.Manufacturer,
+			Model: device.Model, SwVersion: vl53l0x.DriverVersion},
+	}
+	payload, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	topic := prefix + "/sensor/" + id + "/distance/config"
+	return wait(v.client.Publish(topic, v.config.QoS, true, payload))
+}
+
+// Replace characters not allowed in discovery topic and identifiers.
+func sanitizeId(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z',
+			r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, id)
+}
@@ -0,0 +1,183 @@
+// Package mqtt publishes VL53L0X measurements to MQTT broker as JSON,
+// with sensor health reported via retained status topic and last will.
+package mqtt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+	"text/template"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Sensor health states published to status topic.
+const (
+	StatusOnline  = "online"
+	StatusOffline = "offline"
+	StatusError   = "error"
+)
+
+// Default topic templates.
+const (
+	DefaultTopic       = "vl53l0x/{{.Name}}/measurement"
+	DefaultStatusTopic = "vl53l0x/{{.Name}}/status"
+)
+
+// Time to wait for broker acknowledge.
+const publishTimeout = time.Second * 5
+
+// Config describes sensor and topics to publish to. Topic templates are
+// text/template strings with access to Name, Bus and Address fields.
+type Config struct {
+	// sensor identification
+	Name    string
+	Bus     int
+	Address byte
+	// measurement and health status topics;
+	// defaults are DefaultTopic and DefaultStatusTopic
+	Topic       string
+	StatusTopic string
+	QoS         byte
+	// retain measurement messages
+	Retain bool
+}
+
+// Publisher sends measurements to MQTT broker as JSON payload. Sensor
+// health is published as retained message to status topic: "online" on
+// connect, "error" on measurement errors and "offline" on close; "offline"
+// is also registered as last will, so subscribers learn about publisher
+// disconnection.
+type Publisher struct {
+	sync.Mutex
+	client      paho.Client
+	config      Config
+	topic       string
+	statusTopic string
+	status      string
+}
+
+// NewPublisher connects to broker with client options given,
+// registering last will, and publishes "online" status.
+func NewPublisher(opts *paho.ClientOptions, config Config) (*Publisher, error) {
+	if config.Topic == "" {
+		config.Topic = DefaultTopic
+	}
+	if config.StatusTopic == "" {
+		config.StatusTopic = DefaultStatusTopic
+	}
+	topic, err := ExpandTopic(config.Topic, config)
+	if err != nil {
+		return nil, err
+	}
+	statusTopic, err := ExpandTopic(config.StatusTopic, config)
+	if err != nil {
+		return nil, err
+	}
+	opts.SetWill(statusTopic, StatusOffline, config.QoS, true)
+	v := &Publisher{client: paho.NewClient(opts), config: config,
+		topic: topic, statusTopic: statusTopic}
+	err = wait(v.client.Connect())
+	if err != nil {
+		return nil, err
+	}
+	err = v.setStatus(StatusOnline)
+	if err != nil {
+		v.client.Disconnect(250)
+		return nil, err
+	}
+	return v, nil
+}
+
+// ExpandTopic builds topic from template and sensor identification.
+func ExpandTopic(tmpl string, config Config) (string, error) {
+	t, err := template.New("topic").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, config)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Client returns underlying MQTT client, for instance to publish
+// additional messages over the same connection.
+func (v *Publisher) Client() paho.Client {
+	return v.client
+}
+
+// Topic returns measurement topic.
+func (v *Publisher) Topic() string {
+	return v.topic
+}
+
+// StatusTopic returns health status topic.
+func (v *Publisher) StatusTopic() string {
+	return v.statusTopic
+}
+
+// Publish sends measurement as JSON. Measurements with error
+// switch health status to "error", successful ones back to "online".
+func (v *Publisher) Publish(m vl53l0x.Measurement) error {
+	if m.Err != nil {
+		return v.setStatus(StatusError)
+	}
+	err := v.setStatus(StatusOnline)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return wait(v.client.Publish(v.topic, v.config.QoS, v.config.Retain, payload))
+}
+
+// Run publish measurements from stream, for instance one returned by
+// Vl53l0x.Stream, until it's closed. Publishing errors are returned
+// immediately.
+func (v *Publisher) Run(in <-chan vl53l0x.Measurement) error {
+	for m := range in {
+		err := v.Publish(m)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close publishes "offline" status and disconnect from broker.
+func (v *Publisher) Close() error {
+	err := v.setStatus(StatusOffline)
+	v.client.Disconnect(250)
+	return err
+}
+
+// Publish health status, if changed.
+func (v *Publisher) setStatus(status string) error {
+	v.Lock()
+	defer v.Unlock()
+	if v.status == status {
+		return nil
+	}
+	err := wait(v.client.Publish(v.statusTopic, v.config.QoS, true, status))
+	if err != nil {
+		return err
+	}
+	v.status = status
+	return nil
+}
+
+// Wait for token completion with timeout.
+func wait(token paho.Token) error {
+	if !token.WaitTimeout(publishTimeout) {
+		return errors.New("timeout waiting for MQTT broker")
+	}
+	return token.Error()
+}
@@ -0,0 +1,160 @@
+// Package nats publishes VL53L0X measurements and health events
+// to NATS subjects as JSON messages.
+package nats
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	"github.com/d2r2/go-vl53l0x/event"
+	natsgo "github.com/nats-io/nats.go"
+)
+
+// Default subjects; "%s" is replaced with sensor name.
+const (
+	DefaultSubject       = "vl53l0x.%s.measurement"
+	DefaultHealthSubject = "vl53l0x.%s.health"
+)
+
+// Health states reported by HealthEvent.
+const (
+	HealthOk        = "ok"
+	HealthError     = "error"
+	HealthRecovered = "recovered"
+	HealthDegraded  = "degraded"
+	HealthFailed    = "failed"
+)
+
+// HealthEvent is published to health subject, when sensor health changes:
+// measurement errors, successful measurement after errors, recovery
+// performed by watchdog, and health changes of event.HealthMonitor.
+type HealthEvent struct {
+	Sensor string    `json:"sensor"`
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
+	Cause  string    `json:"cause,omitempty"`
+	Err    string    `json:"error,omitempty"`
+}
+
+// Publisher sends measurements and health events to NATS subjects.
+type Publisher struct {
+	sync.Mutex
+	conn          *natsgo.Conn
+	name          string
+	subject       string
+	healthSubject string
+	failing       bool
+}
+
+// NewPublisher creates publisher for sensor name over connection given.
+// Empty subjects stand for defaults, where sensor name is substituted.
+func NewPublisher(conn *natsgo.Conn, name, subject, healthSubject string) *Publisher {
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	if healthSubject == "" {
+		healthSubject = DefaultHealthSubject
+	}
+	v := &Publisher{conn: conn, name: name,
+		subject:       expandSubject(subject, name),
+		healthSubject: expandSubject(healthSubject, name)}
+	return v
+}
+
+// Publish sends measurement as JSON. Measurement errors are reported
+// to health subject instead; the first successful measurement after
+// errors is reported as "ok" health event.
+func (v *Publisher) Publish(m vl53l0x.Measurement) error {
+	v.Lock()
+	failing := v.failing
+	v.failing = m.Err != nil
+	v.Unlock()
+	if m.Err != nil {
+		return v.PublishHealth(HealthEvent{Status: HealthError,
+			Time: time.Now(), Err: m.Err.Error()})
+	}
+	if failing {
+		err := v.PublishHealth(HealthEvent{Status: HealthOk, Time: m.Timestamp})
+		if err != nil {
+			return err
+		}
+	}
+	return v.publishJSON(v.subject, m)
+}
+
+// PublishHealth sends health event.
+func (v *Publisher) PublishHealth(e HealthEvent) error {
+	e.Sensor = v.name
+	return v.publishJSON(v.healthSubject, e)
+}
+
+// OnRecovery returns handler to be registered with Watchdog.OnRecovery,
+// reporting recoveries as health events.
+func (v *Publisher) OnRecovery() func(vl53l0x.RecoveryEvent) {
+	return func(e vl53l0x.RecoveryEvent) {
+		he := HealthEvent{Status: HealthRecovered, Time: e.Time}
+		if e.Cause != nil {
+			he.Cause = e.Cause.Error()
+		}
+		if e.Err != nil {
+			he.Status = HealthError
+			he.Err = e.Err.Error()
+		}
+		err := v.PublishHealth(he)
+		if err != nil {
+			lg.Warnf("Error publishing health event: %s", err)
+		}
+	}
+}
+
+// PublishHealthChange sends health change reported by event.HealthMonitor
+// as health event with status "ok", "degraded" or "failed" and reason
+// of change as cause:
+//
+//	for e := range health.Run(stream, trips) {
+//		err := publisher.PublishHealthChange(e)
+//		...
+//	}
+func (v *Publisher) PublishHealthChange(e event.HealthEvent) error {
+	he := HealthEvent{Status: HealthOk, Time: e.Time, Cause: e.Reason}
+	switch e.To {
+	case event.Degraded:
+		he.Status = HealthDegraded
+	case event.Failed:
+		he.Status = HealthFailed
+	}
+	if e.Err != nil && e.To != event.Healthy {
+		he.Err = e.Err.Error()
+	}
+	return v.PublishHealth(he)
+}
+
+// Run publish measurements from stream, for instance one returned by
+// Vl53l0x.Stream, until it's closed. Publishing errors are returned
+// immediately.
+func (v *Publisher) Run(in <-chan vl53l0x.Measurement) error {
+	for m := range in {
+		err := v.Publish(m)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Marshal value to JSON and publish to subject.
+func (v *Publisher) publishJSON(subject string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return v.conn.Publish(subject, data)
+}
+
+// Substitute sensor name to subject.
+func expandSubject(subject, name string) string {
+	return strings.ReplaceAll(subject, "%s", name)
+}
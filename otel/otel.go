@@ -0,0 +1,279 @@
+// Package otel provides optional OpenTelemetry instrumentation: spans around
+// sensor initialization, configuration and measurements, and metrics for
+// distance, range status distribution and I2C-bus latency. Telemetry is
+// exported through tracer and meter providers supplied by the caller.
+package otel
+
+import (
+	"context"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Instrumentation scope name.
+const scopeName = "github.com/d2r2/go-vl53l0x"
+
+// Instrumentation wraps sensor operations with spans and records metrics.
+type Instrumentation struct {
+	tracer   trace.Tracer
+	distance metric.Int64Histogram
+	status   metric.Int64Counter
+	latency  metric.Float64Histogram
+	ioErrors metric.Int64Counter
+	attrs    []attribute.KeyValue
+}
+
+// New creates instrumentation with providers given. Attributes (for
+// instance sensor name, bus and address) are attached to all spans
+// and measurements.
+func New(tp trace.TracerProvider, mp metric.MeterProvider,
+	attrs ...attribute.KeyValue) (*Instrumentation, error) {
+
+	meter := mp.Meter(scopeName)
+	v := &Instrumentation{tracer: tp.Tracer(scopeName), attrs: attrs}
+	var err error
+	v.distance, err = meter.Int64Histogram("vl53l0x.distance",
+		metric.WithUnit("mm"),
+		metric.WithDescription("Measured distance of valid measurements."))
+	if err != nil {
+		return nil, err
+	}
+	v.status, err = meter.Int64Counter("vl53l0x.measurements",
+		metric.WithDescription("Measurements by range status."))
+	if err != nil {
+		return nil, err
+	}
+	v.latency, err = meter.Float64Histogram("vl53l0x.i2c.duration",
+		metric.WithUnit("ms"),
+		metric.WithDescription("Duration of I2C-bus transactions."))
+	if err != nil {
+		return nil, err
+	}
+	v.ioErrors, err = meter.Int64Counter("vl53l0x.i2c.errors",
+		metric.WithDescription("Failed I2C-bus transactions."))
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Init initialize sensor within span.
+func (v *Instrumentation) Init(ctx context.Context, sensor *vl53l0x.Vl53l0x,
+	bus vl53l0x.Bus) error {
+
+	_, span := v.tracer.Start(ctx, "vl53l0x.Init", trace.WithAttributes(v.attrs...))
+	defer span.End()
+	return v.spanError(span, sensor.Init(bus))
+}
+
+// Config configure sensor within span.
+func (v *Instrumentation) Config(ctx context.Context, sensor *vl53l0x.Vl53l0x,
+	bus vl53l0x.Bus, rng vl53l0x.RangeSpec, speed vl53l0x.SpeedAccuracySpec) error {
+
+	_, span := v.tracer.Start(ctx, "vl53l0x.Config", trace.WithAttributes(v.attrs...))
+	defer span.End()
+	span.SetAttributes(attribute.String("vl53l0x.range", rng.String()),
+		attribute.String("vl53l0x.speed", speed.String()))
+	return v.spanError(span, sensor.Config(bus, rng, speed))
+}
+
+// ReadMeasurementSingle performs single-shot measurement within span
+// and records metrics.
+func (v *Instrumentation) ReadMeasurementSingle(ctx context.Context,
+	sensor *vl53l0x.Vl53l0x, bus vl53l0x.Bus) (vl53l0x.Measurement, error) {
+
+	return v.measure(ctx, "vl53l0x.ReadMeasurementSingle", func() (vl53l0x.Measurement, error) {
+		return sensor.ReadMeasurementSingle(bus)
+	})
+}
+
+// ReadMeasurementContinuous reads measurement in continuous mode
+// within span and records metrics.
+func (v *Instrumentation) ReadMeasurementContinuous(ctx context.Context,
+	sensor *vl53l0x.Vl53l0x, bus vl53l0x.Bus) (vl53l0x.Measurement, error) {
+
+	return v.measure(ctx, "vl53l0x.ReadMeasurementContinuous", func() (vl53l0x.Measurement, error) {
+		return sensor.ReadMeasurementContinuous(bus)
+	})
+}
+
+// Observe records metrics of measurement obtained elsewhere,
+// for instance from Vl53l0x.Stream.
+func (v *Instrumentation) Observe(ctx context.Context, m vl53l0x.Measurement) {
+	if m.Err != nil {
+		return
+	}
+	status := append([]attribute.KeyValue{
+		attribute.String("vl53l0x.status", m.Status.String())}, v.attrs...)
+	v.status.Add(ctx, 1, metric.WithAttributes(status...))
+	if m.Valid() {
+		v.distance.Record(ctx, int64(m.RangeMillimeters), metric.WithAttributes(v.attrs...))
+	}
+}
+
+// Bus returns I2C-bus connection, recording latency and errors
+// of each transaction. Pass it to sensor methods instead of original one.
+// Connection implements vl53l0x.RegisterReader and vl53l0x.BatchBus,
+// if original one does, so combined reads and batched writes are kept.
+func (v *Instrumentation) Bus(bus vl53l0x.Bus) vl53l0x.Bus {
+	b := &instrumentedBus{bus: bus, inst: v}
+	_, regReader := bus.(vl53l0x.RegisterReader)
+	_, batch := bus.(vl53l0x.BatchBus)
+	switch {
+	case regReader && batch:
+		return &instrumentedRegBatchBus{b}
+	case regReader:
+		return &instrumentedRegBus{b}
+	case batch:
+		return &instrumentedBatchBus{b}
+	default:
+		return b
+	}
+}
+
+// Run measurement function within span and record metrics.
+func (v *Instrumentation) measure(ctx context.Context, name string,
+	read func() (vl53l0x.Measurement, error)) (vl53l0x.Measurement, error) {
+
+	ctx, span := v.tracer.Start(ctx, name, trace.WithAttributes(v.attrs...))
+	defer span.End()
+	m, err := read()
+	if err != nil {
+		return m, v.spanError(span, err)
+	}
+	span.SetAttributes(attribute.Int("vl53l0x.range_mm", int(m.RangeMillimeters)),
+		attribute.String("vl53l0x.status", m.Status.String()))
+	v.Observe(ctx, m)
+	return m, nil
+}
+
+// Record error to span, passing it through.
+func (v *Instrumentation) spanError(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// Record transaction latency and error.
+func (v *Instrumentation) transaction(op string, start time.Time, err error) {
+	ctx := context.Background()
+	attrs := append([]attribute.KeyValue{attribute.String("vl53l0x.i2c.op", op)}, v.attrs...)
+	v.latency.Record(ctx, float64(time.Since(start))/float64(time.Millisecond),
+		metric.WithAttributes(attrs...))
+	if err != nil {
+		v.ioErrors.Add(ctx, 1, metric.WithAttributes(attrs...))
+	}
+}
+
+// Bus wrapper recording transactions.
+type instrumentedBus struct {
+	bus  vl53l0x.Bus
+	inst *Instrumentation
+}
+
+// ReadRegU8 implement vl53l0x.Bus interface.
+func (v *instrumentedBus) ReadRegU8(reg byte) (byte, error) {
+	start := time.Now()
+	u8, err := v.bus.ReadRegU8(reg)
+	v.inst.transaction("read", start, err)
+	return u8, err
+}
+
+// WriteRegU8 implement vl53l0x.Bus interface.
+func (v *instrumentedBus) WriteRegU8(reg byte, value byte) error {
+	start := time.Now()
+	err := v.bus.WriteRegU8(reg, value)
+	v.inst.transaction("write", start, err)
+	return err
+}
+
+// ReadBytes implement vl53l0x.Bus interface.
+func (v *instrumentedBus) ReadBytes(buf []byte) (int, error) {
+	start := time.Now()
+	n, err := v.bus.ReadBytes(buf)
+	v.inst.transaction("read", start, err)
+	return n, err
+}
+
+// WriteBytes implement vl53l0x.Bus interface.
+func (v *instrumentedBus) WriteBytes(buf []byte) (int, error) {
+	start := time.Now()
+	n, err := v.bus.WriteBytes(buf)
+	v.inst.transaction("write", start, err)
+	return n, err
+}
+
+// Read register in combined transaction, bus implements RegisterReader.
+func (v *instrumentedBus) readReg(reg byte, buf []byte) error {
+	start := time.Now()
+	err := v.bus.(vl53l0x.RegisterReader).ReadReg(reg, buf)
+	v.inst.transaction("read", start, err)
+	return err
+}
+
+// Write messages in single call, bus implements BatchBus.
+func (v *instrumentedBus) writeBatch(msgs [][]byte) error {
+	start := time.Now()
+	err := v.bus.(vl53l0x.BatchBus).WriteBatch(msgs)
+	v.inst.transaction("batch", start, err)
+	return err
+}
+
+// OpenAddress implement vl53l0x.AddressableBus interface.
+func (v *instrumentedBus) OpenAddress(addr byte) (vl53l0x.Bus, error) {
+	bus, err := vl53l0x.OpenAddress(v.bus, addr)
+	if err != nil {
+		return nil, err
+	}
+	return v.inst.Bus(bus), nil
+}
+
+// Close underlying connection, if it supports closing.
+func (v *instrumentedBus) Close() error {
+	if c, ok := v.bus.(interface{ Close() error }); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Bus wrapper of RegisterReader.
+type instrumentedRegBus struct {
+	*instrumentedBus
+}
+
+// ReadReg implement vl53l0x.RegisterReader interface.
+func (v *instrumentedRegBus) ReadReg(reg byte, buf []byte) error {
+	return v.readReg(reg, buf)
+}
+
+// Bus wrapper of BatchBus.
+type instrumentedBatchBus struct {
+	*instrumentedBus
+}
+
+// WriteBatch implement vl53l0x.BatchBus interface.
+func (v *instrumentedBatchBus) WriteBatch(msgs [][]byte) error {
+	return v.writeBatch(msgs)
+}
+
+// Bus wrapper of bus implementing both RegisterReader and BatchBus.
+type instrumentedRegBatchBus struct {
+	*instrumentedBus
+}
+
+// ReadReg implement vl53l0x.RegisterReader interface.
+func (v *instrumentedRegBatchBus) ReadReg(reg byte, buf []byte) error {
+	return v.readReg(reg, buf)
+}
+
+// WriteBatch implement vl53l0x.BatchBus interface.
+func (v *instrumentedRegBatchBus) WriteBatch(msgs [][]byte) error {
+	return v.writeBatch(msgs)
+}
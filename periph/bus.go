@@ -0,0 +1,67 @@
+// Package periph integrates the driver with periph.io: Bus adapts
+// periph.io I2C-bus to vl53l0x.Bus, and Dev follows periph.io device
+// conventions (String, Halt, Sense, SenseContinuous), so the sensor
+// slots into periph-based HALs.
+package periph
+
+import (
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	"periph.io/x/conn/v3/i2c"
+)
+
+// Bus adapts device on periph.io I2C-bus to vl53l0x.Bus interface.
+type Bus struct {
+	dev *i2c.Dev
+}
+
+// NewBus creates connection to device with address given on periph.io I2C-bus.
+func NewBus(bus i2c.Bus, addr uint16) *Bus {
+	v := &Bus{dev: &i2c.Dev{Bus: bus, Addr: addr}}
+	return v
+}
+
+// ReadRegU8 implement vl53l0x.Bus interface.
+func (v *Bus) ReadRegU8(reg byte) (byte, error) {
+	var buf [1]byte
+	err := v.dev.Tx([]byte{reg}, buf[:])
+	return buf[0], err
+}
+
+// WriteRegU8 implement vl53l0x.Bus interface.
+func (v *Bus) WriteRegU8(reg byte, value byte) error {
+	return v.dev.Tx([]byte{reg, value}, nil)
+}
+
+// ReadReg implement vl53l0x.RegisterReader interface:
+// register index is written and data read in single transaction.
+func (v *Bus) ReadReg(reg byte, buf []byte) error {
+	return v.dev.Tx([]byte{reg}, buf)
+}
+
+// ReadBytes implement vl53l0x.Bus interface.
+func (v *Bus) ReadBytes(buf []byte) (int, error) {
+	err := v.dev.Tx(nil, buf)
+	if err != nil {
+		return 0, err
+	}
+	return len(buf), nil
+}
+
+// WriteBytes implement vl53l0x.Bus interface.
+func (v *Bus) WriteBytes(buf []byte) (int, error) {
+	err := v.dev.Tx(buf, nil)
+	if err != nil {
+		return 0, err
+	}
+	return len(buf), nil
+}
+
+// OpenAddress implement vl53l0x.AddressableBus interface.
+func (v *Bus) OpenAddress(addr byte) (vl53l0x.Bus, error) {
+	return NewBus(v.dev.Bus, uint16(addr)), nil
+}
+
+// String implement Stringer interface.
+func (v *Bus) String() string {
+	return v.dev.String()
+}
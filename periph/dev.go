@@ -0,0 +1,134 @@
+package periph
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	"periph.io/x/conn/v3/i2c"
+)
+
+// Opts is optional configuration of Dev.
+type Opts struct {
+	// I2C address, 0x29 by default
+	Addr uint16
+	// profile applied after initialization, ProfileDefault if nil
+	Profile *vl53l0x.Profile
+}
+
+// DefaultOpts is the recommended default options.
+var DefaultOpts = Opts{Addr: 0x29}
+
+// Dev is a VL53L0X sensor on periph.io I2C-bus, following periph.io
+// device conventions.
+type Dev struct {
+	sync.Mutex
+	sensor *vl53l0x.Vl53l0x
+	bus    *Bus
+	// continuous sensing state
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New initialize sensor on periph.io I2C-bus.
+func New(bus i2c.Bus, opts *Opts) (*Dev, error) {
+	if opts == nil {
+		opts = &DefaultOpts
+	}
+	addr := opts.Addr
+	if addr == 0 {
+		addr = DefaultOpts.Addr
+	}
+	v := &Dev{sensor: vl53l0x.NewVl53l0x(), bus: NewBus(bus, addr)}
+	err := v.sensor.Init(v.bus)
+	if err != nil {
+		return nil, err
+	}
+	profile := vl53l0x.ProfileDefault
+	if opts.Profile != nil {
+		profile = *opts.Profile
+	}
+	err = v.sensor.ApplyProfile(v.bus, profile)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Sensor returns underlying driver instance together with bus,
+// to access functionality not covered by periph.io conventions.
+func (v *Dev) Sensor() (*vl53l0x.Vl53l0x, vl53l0x.Bus) {
+	return v.sensor, v.bus
+}
+
+// String implement conn.Resource interface.
+func (v *Dev) String() string {
+	return "VL53L0X{" + v.bus.String() + "}"
+}
+
+// Sense performs single-shot measurement. It fails, if continuous
+// sensing is running.
+func (v *Dev) Sense(m *vl53l0x.Measurement) error {
+	v.Lock()
+	defer v.Unlock()
+	if v.cancel != nil {
+		return errors.New("continuous sensing is running, call Halt first")
+	}
+	var err error
+	*m, err = v.sensor.ReadMeasurementSingle(v.bus)
+	return err
+}
+
+// SenseContinuous starts continuous mode with interval given (0 stands for
+// back-to-back mode) and returns channel of measurements. Running continuous
+// sensing is stopped first. Channel is closed by Halt or on acquisition
+// error, which is delivered as last measurement with Err field set.
+func (v *Dev) SenseContinuous(interval time.Duration) (<-chan vl53l0x.Measurement, error) {
+	v.Lock()
+	defer v.Unlock()
+	v.halt()
+	ctx, cancel := context.WithCancel(context.Background())
+	in, err := v.sensor.Stream(ctx, v.bus, interval)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	out := make(chan vl53l0x.Measurement, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(out)
+		for m := range in {
+			select {
+			case out <- m:
+			case <-ctx.Done():
+				// keep draining until stream stopped
+			}
+		}
+	}()
+	v.cancel = cancel
+	v.done = done
+	return out, nil
+}
+
+// Halt implement conn.Resource interface: it stops continuous
+// sensing, if running, and waits until ranging is stopped.
+func (v *Dev) Halt() error {
+	v.Lock()
+	defer v.Unlock()
+	v.halt()
+	return nil
+}
+
+// Stop continuous sensing. Must be called under lock.
+func (v *Dev) halt() {
+	if v.cancel == nil {
+		return
+	}
+	v.cancel()
+	<-v.done
+	v.cancel = nil
+	v.done = nil
+}
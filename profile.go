@@ -0,0 +1,155 @@
+package vl53l0x
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// Profile is a named set of ranging settings, applied at once by ApplyProfile.
+// Zero values of optional fields stand for "keep default".
+type Profile struct {
+	Name        string
+	Description string
+	// distance range and accuracy, as used by Config
+	Range RangeSpec
+	Speed SpeedAccuracySpec
+	// return signal rate limit in MCPS, overrides value implied by Range
+	SignalRateLimit float32
+	// range ignore threshold in MCPS: measurements with return signal
+	// rate below this value are reported with SignalFail status
+	RangeIgnoreThreshold float32
+	// crosstalk compensation rate in MCPS to start with, until real
+	// crosstalk calibration is performed
+	CrosstalkHint float32
+}
+
+// Predefined profiles, including presets for common cover-glass setups.
+// Cover-glass presets only give a sane starting point: real crosstalk
+// depends on glass material, thickness, air gap and coating, so perform
+// crosstalk calibration for each design and store it with ExportCalibration.
+var (
+	// ProfileDefault corresponds to settings applied by Init.
+	ProfileDefault = Profile{
+		Name:        "default",
+		Description: "No cover glass, settings as initialized by ST API",
+		Range:       RegularRange,
+		Speed:       RegularAccuracy,
+	}
+	// ProfileLongRange extends range up to 2 m in dark conditions.
+	ProfileLongRange = Profile{
+		Name:        "long-range",
+		Description: "No cover glass, extended range",
+		Range:       LongRange,
+		Speed:       GoodAccuracy,
+	}
+	// ProfileCoverGlassThin for thin (< 0.5 mm) IR-transparent window
+	// mounted directly on the sensor, without air gap.
+	ProfileCoverGlassThin = Profile{
+		Name:                 "cover-glass-thin",
+		Description:          "Thin IR-transparent window, no air gap",
+		Range:                RegularRange,
+		Speed:                RegularAccuracy,
+		SignalRateLimit:      0.25,
+		RangeIgnoreThreshold: 0.5,
+		CrosstalkHint:        0.1,
+	}
+	// ProfileCoverGlassThick for thick (0.5 - 1 mm) IR-transparent window
+	// mounted directly on the sensor, without air gap.
+	ProfileCoverGlassThick = Profile{
+		Name:                 "cover-glass-thick",
+		Description:          "Thick IR-transparent window, no air gap",
+		Range:                RegularRange,
+		Speed:                GoodAccuracy,
+		SignalRateLimit:      0.25,
+		RangeIgnoreThreshold: 1.0,
+		CrosstalkHint:        0.25,
+	}
+	// ProfileCoverGlassAirGap for window mounted with air gap up to 1 mm,
+	// which causes noticeably higher crosstalk.
+	ProfileCoverGlassAirGap = Profile{
+		Name:                 "cover-glass-air-gap",
+		Description:          "IR-transparent window with air gap",
+		Range:                RegularRange,
+		Speed:                GoodAccuracy,
+		SignalRateLimit:      0.3,
+		RangeIgnoreThreshold: 1.5,
+		CrosstalkHint:        0.5,
+	}
+	// ProfileCoverGlassTinted for dark (IR-pass, visible-blocking) window,
+	// which attenuates return signal: signal limit is lowered
+	// and timing budget increased to compensate.
+	ProfileCoverGlassTinted = Profile{
+		Name:                 "cover-glass-tinted",
+		Description:          "Dark IR-pass window",
+		Range:                LongRange,
+		Speed:                HighAccuracy,
+		SignalRateLimit:      0.15,
+		RangeIgnoreThreshold: 0.6,
+		CrosstalkHint:        0.2,
+	}
+)
+
+// Profiles returns all predefined profiles.
+func Profiles() []Profile {
+	return []Profile{ProfileDefault, ProfileLongRange,
+		ProfileCoverGlassThin, ProfileCoverGlassThick,
+		ProfileCoverGlassAirGap, ProfileCoverGlassTinted}
+}
+
+// ProfileByName returns predefined profile by name (case insensitive).
+func ProfileByName(name string) (Profile, error) {
+	for _, p := range Profiles() {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+	return Profile{}, errors.New(spew.Sprintf("unknown profile %q", name))
+}
+
+// String implement Stringer interface.
+func (v Profile) String() string {
+	return v.Name
+}
+
+// ApplyProfile configure sensor initialized by Init with profile settings.
+// Profile is restored automatically on re-initialization.
+func (v *Vl53l0x) ApplyProfile(i2c Bus, p Profile) error {
+
+	debugf("Apply profile %q", p.Name)
+
+	err := v.Config(i2c, p.Range, p.Speed)
+	if err != nil {
+		return err
+	}
+	if p.SignalRateLimit != 0 {
+		err = v.SetSignalRateLimit(i2c, p.SignalRateLimit)
+		if err != nil {
+			return err
+		}
+	}
+	v.SetRangeIgnoreThreshold(p.RangeIgnoreThreshold)
+	if p.CrosstalkHint != 0 {
+		err = v.SetCrosstalkCompensation(i2c, p.CrosstalkHint)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetRangeIgnoreThreshold set range ignore threshold in MCPS: measurements
+// with return signal rate below this value are reported with SignalFail
+// status. It helps to suppress false readings caused by cover glass
+// reflections. Zero value disables the check, which is default.
+// Similar to VL53L0X_CHECKENABLE_RANGE_IGNORE_THRESHOLD limit check,
+// which is performed by software.
+func (v *Vl53l0x) SetRangeIgnoreThreshold(thresholdMcps float32) {
+	v.rangeIgnoreThreshold = thresholdMcps
+}
+
+// GetRangeIgnoreThreshold returns range ignore threshold in MCPS.
+func (v *Vl53l0x) GetRangeIgnoreThreshold() float32 {
+	return v.rangeIgnoreThreshold
+}
@@ -0,0 +1,145 @@
+// Package prometheus exposes VL53L0X measurements and driver health
+// as Prometheus metrics. Exporter implements prometheus.Collector,
+// so it could be registered in any existing registry:
+//
+//	exp := prometheus.NewExporter(sensor, prometheus.Labels{Bus: 1, Address: 0x29, Name: "door"})
+//	registry.MustRegister(exp)
+//	for m, err := range sensor.Measurements(ctx, i2c, 0) {
+//		exp.Observe(m)
+//		...
+//	}
+//
+// TextfileWriter writes the same metrics for node_exporter
+// textfile collector instead of serving them over HTTP.
+package prometheus
+
+import (
+	"strconv"
+	"sync"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Namespace of exported metrics.
+const namespace = "vl53l0x"
+
+// Labels identify sensor in exported metrics.
+type Labels struct {
+	Bus     int
+	Address byte
+	Name    string
+}
+
+// Convert labels to constant labels of metric descriptors.
+func (v Labels) promLabels() prom.Labels {
+	return prom.Labels{
+		"bus":     strconv.Itoa(v.Bus),
+		"address": "0x" + strconv.FormatUint(uint64(v.Address), 16),
+		"name":    v.Name,
+	}
+}
+
+// Exporter collects metrics of single sensor: last measured distance,
+// signal and ambient rates, range status counts, timing budget, I2C-bus
+// transaction counters and measurements dropped by stream. Feed it with
+// measurements by Observe.
+type Exporter struct {
+	sync.Mutex
+	sensor *vl53l0x.Vl53l0x
+	// metric descriptors
+	distance     *prom.Desc
+	signalRate   *prom.Desc
+	ambientRate  *prom.Desc
+	confidence   *prom.Desc
+	statusCount  *prom.Desc
+	timingBudget *prom.Desc
+	ioReads      *prom.Desc
+	ioWrites     *prom.Desc
+	ioErrors     *prom.Desc
+	dropped      *prom.Desc
+	// last observed values
+	last         vl53l0x.Measurement
+	observed     bool
+	statusCounts map[vl53l0x.RangeStatus]uint64
+	budget       float64
+}
+
+// NewExporter creates metrics exporter of sensor with labels given.
+func NewExporter(sensor *vl53l0x.Vl53l0x, labels Labels) *Exporter {
+	constLabels := labels.promLabels()
+	desc := func(name, help string, variableLabels ...string) *prom.Desc {
+		return prom.NewDesc(prom.BuildFQName(namespace, "", name), help,
+			variableLabels, constLabels)
+	}
+	v := &Exporter{sensor: sensor,
+		distance:     desc("distance_millimeters", "Last valid measured distance."),
+		signalRate:   desc("signal_rate_mcps", "Return signal rate of last measurement."),
+		ambientRate:  desc("ambient_rate_mcps", "Ambient rate of last measurement."),
+		confidence:   desc("confidence", "Confidence of last measurement, 0 to 1."),
+		statusCount:  desc("range_status_total", "Measurements by range status.", "status"),
+		timingBudget: desc("timing_budget_seconds", "Measurement timing budget."),
+		ioReads:      desc("i2c_reads_total", "I2C-bus read transactions."),
+		ioWrites:     desc("i2c_writes_total", "I2C-bus write transactions."),
+		ioErrors:     desc("i2c_errors_total", "Failed I2C-bus transactions."),
+		dropped:      desc("stream_dropped_total", "Measurements dropped by stream on slow consumer."),
+		statusCounts: make(map[vl53l0x.RangeStatus]uint64),
+	}
+	return v
+}
+
+// Observe account measurement. Call it from acquisition loop.
+func (v *Exporter) Observe(m vl53l0x.Measurement) {
+	budget := v.sensor.MeasurementTimingBudget().Seconds()
+	v.Lock()
+	defer v.Unlock()
+	v.budget = budget
+	if m.Err != nil {
+		return
+	}
+	v.statusCounts[m.Status]++
+	if m.Valid() {
+		v.last = m
+		v.observed = true
+	} else {
+		v.last.SignalRateMcps = m.SignalRateMcps
+		v.last.AmbientRateMcps = m.AmbientRateMcps
+		v.last.Confidence = 0
+	}
+}
+
+// Describe implement prometheus.Collector interface.
+func (v *Exporter) Describe(ch chan<- *prom.Desc) {
+	for _, d := range []*prom.Desc{v.distance, v.signalRate, v.ambientRate,
+		v.confidence, v.statusCount, v.timingBudget,
+		v.ioReads, v.ioWrites, v.ioErrors, v.dropped} {
+		ch <- d
+	}
+}
+
+// Collect implement prometheus.Collector interface.
+func (v *Exporter) Collect(ch chan<- prom.Metric) {
+	io := v.sensor.GetIoStats()
+	v.Lock()
+	defer v.Unlock()
+	if v.observed {
+		ch <- prom.MustNewConstMetric(v.distance, prom.GaugeValue,
+			float64(v.last.RangeMillimeters))
+	}
+	ch <- prom.MustNewConstMetric(v.signalRate, prom.GaugeValue,
+		float64(v.last.SignalRateMcps))
+	ch <- prom.MustNewConstMetric(v.ambientRate, prom.GaugeValue,
+		float64(v.last.AmbientRateMcps))
+	ch <- prom.MustNewConstMetric(v.confidence, prom.GaugeValue,
+		float64(v.last.Confidence))
+	for status, count := range v.statusCounts {
+		ch <- prom.MustNewConstMetric(v.statusCount, prom.CounterValue,
+			float64(count), status.String())
+	}
+	ch <- prom.MustNewConstMetric(v.timingBudget, prom.GaugeValue, v.budget)
+	ch <- prom.MustNewConstMetric(v.ioReads, prom.CounterValue, float64(io.Reads))
+	ch <- prom.MustNewConstMetric(v.ioWrites, prom.CounterValue, float64(io.Writes))
+	ch <- prom.MustNewConstMetric(v.ioErrors, prom.CounterValue, float64(io.Errors))
+	ch <- prom.MustNewConstMetric(v.dropped, prom.CounterValue,
+		float64(v.sensor.StreamDropped()))
+}
@@ -0,0 +1,64 @@
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Default interval of textfile updates.
+const DefaultTextfileInterval = time.Second * 15
+
+// TextfileWriter periodically writes metrics gathered from registry
+// to file in node_exporter textfile collector format, for devices already
+// running node_exporter, where no extra listening port is wanted:
+//
+//	registry := prom.NewRegistry()
+//	registry.MustRegister(prometheus.NewExporter(sensor, labels))
+//	w := prometheus.NewTextfileWriter("/var/lib/node_exporter/vl53l0x.prom", registry, 0)
+//	go w.Run(ctx)
+//
+// File is replaced atomically, so collector never reads partial output.
+// Name must have ".prom" extension to be picked up by node_exporter.
+type TextfileWriter struct {
+	path     string
+	gatherer prom.Gatherer
+	interval time.Duration
+}
+
+// NewTextfileWriter creates writer of metrics gathered from gatherer to path
+// given. Zero interval stands for DefaultTextfileInterval.
+func NewTextfileWriter(path string, gatherer prom.Gatherer,
+	interval time.Duration) *TextfileWriter {
+
+	if interval <= 0 {
+		interval = DefaultTextfileInterval
+	}
+	v := &TextfileWriter{path: path, gatherer: gatherer, interval: interval}
+	return v
+}
+
+// Write gather metrics and replace file once.
+func (v *TextfileWriter) Write() error {
+	return prom.WriteToTextfile(v.path, v.gatherer)
+}
+
+// Run write metrics immediately and then every interval, until context
+// is done; final state is written on exit. Write errors are returned
+// immediately.
+func (v *TextfileWriter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+	for {
+		err := v.Write()
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return v.Write()
+		case <-ticker.C:
+		}
+	}
+}
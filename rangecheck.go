@@ -0,0 +1,49 @@
+package vl53l0x
+
+// RangeCheck is set of range validity checks performed by the sensor itself,
+// which reports measurements failing them with SignalFail status. Some
+// tunings from ST application notes require them enabled, while Init
+// disables them, as ST API and Pololu library do.
+//
+// Note, that SYSTEM_RANGE_CONFIG register doesn't control validity checks,
+// ST API writes to it fractional ranging enable only, see
+// VL53L0X_SetRangeFractionEnable(). Checks are controlled by disable bits
+// of MSRC_CONFIG_CONTROL register instead, see VL53L0X_SetLimitCheckEnable().
+type RangeCheck byte
+
+const (
+	// RangeCheckMsrcSignalRate checks return signal rate
+	// of MSRC (Minimum Signal Rate Check) sequence step.
+	RangeCheckMsrcSignalRate RangeCheck = 0x02
+	// RangeCheckPreRangeSignalRate checks return signal rate
+	// of pre-range sequence step.
+	RangeCheckPreRangeSignalRate RangeCheck = 0x10
+	// RangeCheckAll contains all range checks.
+	RangeCheckAll = RangeCheckMsrcSignalRate | RangeCheckPreRangeSignalRate
+)
+
+// SetRangeChecks enable range validity checks given and disable all others.
+// Final range signal rate check is controlled by SetSignalRateLimit instead.
+func (v *Vl53l0x) SetRangeChecks(i2c Bus, checks RangeCheck) error {
+	u8, err := v.readRegU8(i2c, MSRC_CONFIG_CONTROL)
+	if err != nil {
+		return err
+	}
+	// register keeps disable bits
+	u8 = u8&^byte(RangeCheckAll) | byte(RangeCheckAll&^checks)
+	err = v.writeRegU8(i2c, MSRC_CONFIG_CONTROL, u8)
+	if err != nil {
+		return err
+	}
+	v.settings.rangeChecks = checks & RangeCheckAll
+	return nil
+}
+
+// GetRangeChecks gets range validity checks enabled.
+func (v *Vl53l0x) GetRangeChecks(i2c Bus) (RangeCheck, error) {
+	u8, err := v.readRegU8(i2c, MSRC_CONFIG_CONTROL)
+	if err != nil {
+		return 0, err
+	}
+	return RangeCheckAll &^ RangeCheck(u8), nil
+}
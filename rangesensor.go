@@ -0,0 +1,113 @@
+package vl53l0x
+
+import (
+	"context"
+	"time"
+)
+
+// RangeSensor is common interface of ST time-of-flight sensor drivers
+// bound to their connection, implemented by Device for VL53L0X and
+// intended for VL53L1X and VL6180X drivers, so application code, filters
+// and sinks could work with any model:
+//
+//	var s vl53l0x.RangeSensor = vl53l0x.NewDevice(conn)
+//	err := s.Init()
+//	...
+//	err = s.Configure(vl53l0x.RegularRange, vl53l0x.GoodAccuracy)
+//	...
+//	ch, err := s.Stream(ctx, 0)
+type RangeSensor interface {
+	// Model returns sensor model served by driver.
+	Model() Model
+	// Init initialize sensor after power-on or reset.
+	Init() error
+	// Configure set expected distance range and speed/accuracy trade-off.
+	Configure(rng RangeSpec, speed SpeedAccuracySpec) error
+	// ReadRange take single-shot measurement.
+	ReadRange() (Measurement, error)
+	// Stream deliver continuous mode measurements over channel,
+	// until context is done; see Vl53l0x.Stream.
+	Stream(ctx context.Context, period time.Duration) (<-chan Measurement, error)
+	// Close release connection.
+	Close() error
+}
+
+// TriggeredSensor is implemented by RangeSensor, which could start
+// single-shot ranging and collect its result separately, so several
+// sensors of Array are ranging simultaneously.
+type TriggeredSensor interface {
+	// StartRange trigger single-shot ranging without waiting for result.
+	StartRange() error
+	// FinishRange wait for ranging started by StartRange and read result.
+	FinishRange() (Measurement, error)
+}
+
+// Device is VL53L0X driver bound to connection, implementing RangeSensor.
+// If connection implements InterruptWaiter (as SimulatedSensor does),
+// it's used to wait for data ready.
+type Device struct {
+	sensor *Vl53l0x
+	bus    Bus
+}
+
+// Static check, that Device implements RangeSensor and TriggeredSensor.
+var (
+	_ RangeSensor     = (*Device)(nil)
+	_ TriggeredSensor = (*Device)(nil)
+)
+
+// NewDevice creates driver instance bound to connection given.
+func NewDevice(i2c Bus) *Device {
+	v := &Device{sensor: NewVl53l0x(), bus: i2c}
+	if waiter, ok := i2c.(InterruptWaiter); ok {
+		v.sensor.SetInterruptWaiter(waiter)
+	}
+	return v
+}
+
+// Sensor returns underlying driver instance together with connection,
+// to access VL53L0X specific functionality.
+func (v *Device) Sensor() (*Vl53l0x, Bus) {
+	return v.sensor, v.bus
+}
+
+// Model implement RangeSensor interface.
+func (v *Device) Model() Model {
+	return ModelVl53l0x
+}
+
+// Init implement RangeSensor interface.
+func (v *Device) Init() error {
+	return v.sensor.Init(v.bus)
+}
+
+// Configure implement RangeSensor interface.
+func (v *Device) Configure(rng RangeSpec, speed SpeedAccuracySpec) error {
+	return v.sensor.Config(v.bus, rng, speed)
+}
+
+// ReadRange implement RangeSensor interface.
+func (v *Device) ReadRange() (Measurement, error) {
+	return v.sensor.ReadMeasurementSingle(v.bus)
+}
+
+// StartRange implement TriggeredSensor interface.
+func (v *Device) StartRange() error {
+	return v.sensor.startSingle(v.bus)
+}
+
+// FinishRange implement TriggeredSensor interface.
+func (v *Device) FinishRange() (Measurement, error) {
+	return v.sensor.finishSingle(v.bus)
+}
+
+// Stream implement RangeSensor interface.
+func (v *Device) Stream(ctx context.Context, period time.Duration) (<-chan Measurement, error) {
+	return v.sensor.Stream(ctx, v.bus, period)
+}
+
+// Close implement RangeSensor interface. Connection is closed,
+// if it supports closing.
+func (v *Device) Close() error {
+	return closeBus(v.bus)
+}
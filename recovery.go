@@ -0,0 +1,246 @@
+package vl53l0x
+
+import (
+	"errors"
+
+	i2c "github.com/d2r2/go-i2c"
+)
+
+// Default sensor address.
+const defaultAddress = 0x29
+
+// Opens I2C-connection; referenced, where function parameters
+// named i2c shadow the package.
+var newI2C = i2c.NewI2C
+
+// Keeps settings applied by user after Init, so they could be
+// restored on re-initialization. Zero values stand for "not set".
+type appliedSettings struct {
+	signalRateLimit       float32
+	preRangeVcselPeriod   uint8
+	finalRangeVcselPeriod uint8
+	// range checks enabled by SetRangeChecks
+	rangeChecks RangeCheck
+	// calibration applied by SetCalibration
+	calibration *Calibration
+	// range offset and crosstalk compensation applied last, either
+	// directly, by calibration procedures or with calibration data
+	offsetUm      *int32
+	crosstalkRate *float32
+	// address assigned by SetAddress
+	address byte
+	// continuous mode state
+	continuous bool
+	periodMs   uint32
+}
+
+// SetAutoRecovery enable transparent recovery from measurement failures
+// (I2C-bus errors, sensor stall, power loss). When measurement fails,
+// sensor is reset, initialized again, previously applied settings (signal
+// rate limit, range checks, VCSEL periods, timing budget, calibration,
+// address, continuous mode) restored and measurement repeated, up to
+// attempts times. Zero value disables recovery, which is default.
+func (v *Vl53l0x) SetAutoRecovery(attempts int) {
+	v.recoveryAttempts = attempts
+}
+
+// Run measurement, recovering sensor on failure, if enabled.
+func (v *Vl53l0x) withRecovery(i2c Bus,
+	read func(i2c Bus) (Measurement, error)) (Measurement, error) {
+
+	m, err := read(i2c)
+	for i := 0; err != nil && i < v.recoveryAttempts; i++ {
+		warnf("Measurement failed, recovery attempt %d of %d: %s",
+			i+1, v.recoveryAttempts, err)
+		err2 := v.recover(i2c)
+		if err2 != nil {
+			warnf("Recovery attempt %d failed: %s", i+1, err2)
+			continue
+		}
+		m, err = read(i2c)
+	}
+	return m, err
+}
+
+// Full recovery cycle: reset, initialize, restore settings
+// and continuous mode.
+func (v *Vl53l0x) recover(i2c Bus) error {
+	settings := v.settings
+	// Sensor could lose power, so it's waiting on default address now.
+	err := v.restoreAddress(i2c)
+	if err != nil {
+		return err
+	}
+	err = v.Reset(i2c)
+	if err != nil {
+		// Soft reset could drop address as well.
+		err2 := v.restoreAddress(i2c)
+		if err2 != nil {
+			return err
+		}
+	}
+	err = v.reinit(i2c)
+	if err != nil {
+		return err
+	}
+	if settings.continuous {
+		return v.restartContinuous(i2c, settings.periodMs)
+	}
+	return nil
+}
+
+// Re-initialize sensor and restore settings applied by user.
+func (v *Vl53l0x) reinit(i2c Bus) error {
+	settings := v.settings
+	budgetUsec := v.measurementTimingBudgetUsec
+	err := v.Init(i2c)
+	if err != nil {
+		return err
+	}
+	return v.restoreSettings(i2c, settings, budgetUsec)
+}
+
+// ReInit re-initialize sensor, which was initialized by Init before and
+// hasn't been power-cycled since, for instance after stall or mode switch,
+// and restore settings applied by user. It reuses stop variable, SPAD info
+// and timing budget known from previous initialization, instead of reading
+// them from the sensor again, which makes it several times faster than Init.
+// Falls back to full initialization, if Init wasn't called before.
+func (v *Vl53l0x) ReInit(i2c Bus) error {
+	if v.refSpadInfo.Count == 0 {
+		return v.reinit(i2c)
+	}
+	debug("Fast re-initialization")
+	settings := v.settings
+	budgetUsec := v.measurementTimingBudgetUsec
+	err := v.writeRegU8(i2c, 0x88, 0x00)
+	if err != nil {
+		return err
+	}
+	u8, err := v.readRegU8(i2c, MSRC_CONFIG_CONTROL)
+	if err != nil {
+		return err
+	}
+	err = v.writeRegU8(i2c, MSRC_CONFIG_CONTROL, u8|0x12)
+	if err != nil {
+		return err
+	}
+	err = v.SetSignalRateLimit(i2c, 0.25)
+	if err != nil {
+		return err
+	}
+	err = v.writeRegU8(i2c, SYSTEM_SEQUENCE_CONFIG, 0xFF)
+	if err != nil {
+		return err
+	}
+	err = v.setReferenceSpads(i2c, v.refSpadInfo)
+	if err != nil {
+		return err
+	}
+	err = v.writeRegValues(i2c, defaultTuningSettings...)
+	if err != nil {
+		return err
+	}
+	err = v.setGpioConfig(i2c)
+	if err != nil {
+		return err
+	}
+	err = v.writeRegU8(i2c, SYSTEM_SEQUENCE_CONFIG, 0xE8)
+	if err != nil {
+		return err
+	}
+	err = v.performRefCalibration(i2c)
+	if err != nil {
+		return err
+	}
+	v.restartWarmUp()
+	err = v.restoreSettings(i2c, settings, budgetUsec)
+	if err != nil {
+		return err
+	}
+	return v.verifyConfigIfEnabled(i2c)
+}
+
+// Restore settings applied by user after (re-)initialization.
+func (v *Vl53l0x) restoreSettings(i2c Bus, settings appliedSettings,
+	budgetUsec uint32) error {
+
+	var err error
+	if settings.calibration != nil {
+		err = v.SetCalibration(i2c, settings.calibration)
+		if err != nil {
+			return err
+		}
+	}
+	// applied after calibration data, since they could be changed since
+	if settings.offsetUm != nil {
+		err = v.SetOffsetCalibration(i2c, *settings.offsetUm)
+		if err != nil {
+			return err
+		}
+	}
+	if settings.crosstalkRate != nil {
+		err = v.SetCrosstalkCompensation(i2c, *settings.crosstalkRate)
+		if err != nil {
+			return err
+		}
+	}
+	if settings.signalRateLimit != 0 {
+		err = v.SetSignalRateLimit(i2c, settings.signalRateLimit)
+		if err != nil {
+			return err
+		}
+	}
+	if settings.rangeChecks != 0 {
+		err = v.SetRangeChecks(i2c, settings.rangeChecks)
+		if err != nil {
+			return err
+		}
+	}
+	if settings.preRangeVcselPeriod != 0 {
+		err = v.SetVcselPulsePeriod(i2c, VcselPeriodPreRange, settings.preRangeVcselPeriod)
+		if err != nil {
+			return err
+		}
+	}
+	if settings.finalRangeVcselPeriod != 0 {
+		err = v.SetVcselPulsePeriod(i2c, VcselPeriodFinalRange, settings.finalRangeVcselPeriod)
+		if err != nil {
+			return err
+		}
+	}
+	if budgetUsec != 0 {
+		err = v.SetMeasurementTimingBudget(i2c, budgetUsec)
+		if err != nil {
+			return err
+		}
+	}
+	v.settings.address = settings.address
+	return nil
+}
+
+// Assign address given by SetAddress again, if sensor
+// doesn't answer on it, but answers on default one.
+func (v *Vl53l0x) restoreAddress(i2c Bus) error {
+	if v.settings.address == 0 || v.settings.address == defaultAddress {
+		return nil
+	}
+	u8, err := i2c.ReadRegU8(IDENTIFICATION_MODEL_ID)
+	if err == nil && u8 == modelIdVl53l0x {
+		return nil
+	}
+	debugf("Sensor doesn't answer on address 0x%x, try default one", v.settings.address)
+	conn, err := OpenAddress(i2c, defaultAddress)
+	if err != nil {
+		return err
+	}
+	defer closeBus(conn)
+	u8, err = conn.ReadRegU8(IDENTIFICATION_MODEL_ID)
+	if err != nil {
+		return err
+	}
+	if u8 != modelIdVl53l0x {
+		return errors.New("unexpected device found on default address")
+	}
+	return conn.WriteRegU8(I2C_SLAVE_DEVICE_ADDRESS, v.settings.address)
+}
@@ -0,0 +1,136 @@
+package vl53l0x
+
+import (
+	"encoding/hex"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Names of page 0 registers used in transaction trace. Registers sharing
+// address are named after the one, which driver accesses.
+var registerNames = map[byte]string{
+	SYSRANGE_START:                              "SYSRANGE_START",
+	SYSTEM_SEQUENCE_CONFIG:                      "SYSTEM_SEQUENCE_CONFIG",
+	SYSTEM_INTERMEASUREMENT_PERIOD:              "SYSTEM_INTERMEASUREMENT_PERIOD",
+	SYSTEM_RANGE_CONFIG:                         "SYSTEM_RANGE_CONFIG",
+	SYSTEM_INTERRUPT_CONFIG_GPIO:                "SYSTEM_INTERRUPT_CONFIG_GPIO",
+	SYSTEM_INTERRUPT_CLEAR:                      "SYSTEM_INTERRUPT_CLEAR",
+	SYSTEM_THRESH_HIGH:                          "SYSTEM_THRESH_HIGH",
+	SYSTEM_THRESH_LOW:                           "SYSTEM_THRESH_LOW",
+	RESULT_INTERRUPT_STATUS:                     "RESULT_INTERRUPT_STATUS",
+	RESULT_RANGE_STATUS:                         "RESULT_RANGE_STATUS",
+	CROSSTALK_COMPENSATION_PEAK_RATE_MCPS:       "CROSSTALK_COMPENSATION_PEAK_RATE_MCPS",
+	PRE_RANGE_CONFIG_MIN_SNR:                    "PRE_RANGE_CONFIG_MIN_SNR",
+	ALGO_PART_TO_PART_RANGE_OFFSET_MM:           "ALGO_PART_TO_PART_RANGE_OFFSET_MM",
+	ALGO_PHASECAL_LIM:                           "ALGO_PHASECAL_LIM",
+	GLOBAL_CONFIG_VCSEL_WIDTH:                   "GLOBAL_CONFIG_VCSEL_WIDTH",
+	HISTOGRAM_CONFIG_INITIAL_PHASE_SELECT:       "HISTOGRAM_CONFIG_INITIAL_PHASE_SELECT",
+	FINAL_RANGE_CONFIG_MIN_COUNT_RATE_RTN_LIMIT: "FINAL_RANGE_CONFIG_MIN_COUNT_RATE_RTN_LIMIT",
+	MSRC_CONFIG_TIMEOUT_MACROP:                  "MSRC_CONFIG_TIMEOUT_MACROP",
+	FINAL_RANGE_CONFIG_VALID_PHASE_LOW:          "FINAL_RANGE_CONFIG_VALID_PHASE_LOW",
+	FINAL_RANGE_CONFIG_VALID_PHASE_HIGH:         "FINAL_RANGE_CONFIG_VALID_PHASE_HIGH",
+	DYNAMIC_SPAD_NUM_REQUESTED_REF_SPAD:         "DYNAMIC_SPAD_NUM_REQUESTED_REF_SPAD",
+	DYNAMIC_SPAD_REF_EN_START_OFFSET:            "DYNAMIC_SPAD_REF_EN_START_OFFSET",
+	PRE_RANGE_CONFIG_VCSEL_PERIOD:               "PRE_RANGE_CONFIG_VCSEL_PERIOD",
+	PRE_RANGE_CONFIG_TIMEOUT_MACROP_HI:          "PRE_RANGE_CONFIG_TIMEOUT_MACROP_HI",
+	PRE_RANGE_CONFIG_TIMEOUT_MACROP_LO:          "PRE_RANGE_CONFIG_TIMEOUT_MACROP_LO",
+	HISTOGRAM_CONFIG_READOUT_CTRL:               "HISTOGRAM_CONFIG_READOUT_CTRL",
+	PRE_RANGE_CONFIG_VALID_PHASE_LOW:            "PRE_RANGE_CONFIG_VALID_PHASE_LOW",
+	PRE_RANGE_CONFIG_VALID_PHASE_HIGH:           "PRE_RANGE_CONFIG_VALID_PHASE_HIGH",
+	MSRC_CONFIG_CONTROL:                         "MSRC_CONFIG_CONTROL",
+	PRE_RANGE_CONFIG_SIGMA_THRESH_HI:            "PRE_RANGE_CONFIG_SIGMA_THRESH_HI",
+	PRE_RANGE_CONFIG_SIGMA_THRESH_LO:            "PRE_RANGE_CONFIG_SIGMA_THRESH_LO",
+	PRE_RANGE_MIN_COUNT_RATE_RTN_LIMIT:          "PRE_RANGE_MIN_COUNT_RATE_RTN_LIMIT",
+	FINAL_RANGE_CONFIG_MIN_SNR:                  "FINAL_RANGE_CONFIG_MIN_SNR",
+	FINAL_RANGE_CONFIG_VCSEL_PERIOD:             "FINAL_RANGE_CONFIG_VCSEL_PERIOD",
+	FINAL_RANGE_CONFIG_TIMEOUT_MACROP_HI:        "FINAL_RANGE_CONFIG_TIMEOUT_MACROP_HI",
+	FINAL_RANGE_CONFIG_TIMEOUT_MACROP_LO:        "FINAL_RANGE_CONFIG_TIMEOUT_MACROP_LO",
+	POWER_MANAGEMENT_GO1_POWER_FORCE:            "POWER_MANAGEMENT_GO1_POWER_FORCE",
+	SYSTEM_HISTOGRAM_BIN:                        "SYSTEM_HISTOGRAM_BIN",
+	GPIO_HV_MUX_ACTIVE_HIGH:                     "GPIO_HV_MUX_ACTIVE_HIGH",
+	VHV_CONFIG_PAD_SCL_SDA__EXTSUP_HV:           "VHV_CONFIG_PAD_SCL_SDA__EXTSUP_HV",
+	I2C_SLAVE_DEVICE_ADDRESS:                    "I2C_SLAVE_DEVICE_ADDRESS",
+	GLOBAL_CONFIG_SPAD_ENABLES_REF_0:            "GLOBAL_CONFIG_SPAD_ENABLES_REF_0",
+	GLOBAL_CONFIG_SPAD_ENABLES_REF_1:            "GLOBAL_CONFIG_SPAD_ENABLES_REF_1",
+	GLOBAL_CONFIG_SPAD_ENABLES_REF_2:            "GLOBAL_CONFIG_SPAD_ENABLES_REF_2",
+	GLOBAL_CONFIG_SPAD_ENABLES_REF_3:            "GLOBAL_CONFIG_SPAD_ENABLES_REF_3",
+	GLOBAL_CONFIG_SPAD_ENABLES_REF_4:            "GLOBAL_CONFIG_SPAD_ENABLES_REF_4",
+	GLOBAL_CONFIG_SPAD_ENABLES_REF_5:            "GLOBAL_CONFIG_SPAD_ENABLES_REF_5",
+	GLOBAL_CONFIG_REF_EN_START_SELECT:           "GLOBAL_CONFIG_REF_EN_START_SELECT",
+	RESULT_CORE_AMBIENT_WINDOW_EVENTS_RTN:       "RESULT_CORE_AMBIENT_WINDOW_EVENTS_RTN",
+	RESULT_CORE_AMBIENT_WINDOW_EVENTS_REF:       "RESULT_CORE_AMBIENT_WINDOW_EVENTS_REF",
+	RESULT_CORE_RANGING_TOTAL_EVENTS_REF:        "RESULT_CORE_RANGING_TOTAL_EVENTS_REF",
+	SOFT_RESET_GO2_SOFT_RESET_N:                 "SOFT_RESET_GO2_SOFT_RESET_N",
+	IDENTIFICATION_MODEL_ID:                     "IDENTIFICATION_MODEL_ID",
+	IDENTIFICATION_REVISION_ID:                  "IDENTIFICATION_REVISION_ID",
+	OSC_CALIBRATE_VAL:                           "OSC_CALIBRATE_VAL",
+	0xFF:                                        "PAGE_SELECT",
+}
+
+// Register transaction tracer state.
+type registerTrace struct {
+	w io.Writer
+	// register page selected by the last write to 0xFF;
+	// registers of other pages than 0 are not named
+	page byte
+	// line buffer reused between transactions
+	buf []byte
+}
+
+// SetTrace enable tracing of every register transaction to writer given,
+// separately from debug logger, to capture exact sequences for bug reports.
+// Each transaction is written as line of key=value pairs:
+//
+//	time=2024-05-01T10:00:00.000123Z op=write reg=0x00 name=SYSRANGE_START value=01 latency=85µs
+//
+// Multi-byte values are hex bytes starting from register given; failed
+// transactions have error key instead of value. Batched writes are traced
+// per message with latency of the whole batch. Nil writer disables tracing,
+// which is default. Write errors of writer are ignored.
+func (v *Vl53l0x) SetTrace(w io.Writer) {
+	v.regTrace = nil
+	if w != nil {
+		v.regTrace = &registerTrace{w: w}
+	}
+}
+
+// Trace register transaction started at the moment given, if enabled.
+func (v *Vl53l0x) traceTx(write bool, reg byte, data []byte,
+	start time.Time, err error) {
+
+	t := v.regTrace
+	if t == nil {
+		return
+	}
+	now := time.Now()
+	b := append(t.buf[:0], "time="...)
+	b = now.UTC().AppendFormat(b, "2006-01-02T15:04:05.000000Z07:00")
+	if write {
+		b = append(b, " op=write"...)
+	} else {
+		b = append(b, " op=read"...)
+	}
+	b = append(b, " reg=0x"...)
+	b = hex.AppendEncode(b, []byte{reg})
+	if name, ok := registerNames[reg]; ok && (t.page == 0 || reg == 0xFF) {
+		b = append(b, " name="...)
+		b = append(b, name...)
+	}
+	if err == nil {
+		b = append(b, " value="...)
+		b = hex.AppendEncode(b, data)
+	}
+	b = append(b, " latency="...)
+	b = append(b, now.Sub(start).String()...)
+	if err != nil {
+		b = append(b, " error="...)
+		b = strconv.AppendQuote(b, err.Error())
+	}
+	b = append(b, '\n')
+	t.buf = b
+	t.w.Write(b)
+	if write && err == nil && reg == 0xFF && len(data) > 0 {
+		t.page = data[0]
+	}
+}
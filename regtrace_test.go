@@ -0,0 +1,35 @@
+package vl53l0x
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRegisterTrace(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	var trace bytes.Buffer
+	v.SetTrace(&trace)
+	_, err := v.ReadMeasurementSingle(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.SetTrace(nil)
+	lines := strings.Split(strings.TrimSuffix(trace.String(), "\n"), "\n")
+	for _, want := range []string{
+		" op=write reg=0x00 name=SYSRANGE_START value=01 latency=",
+		" op=write reg=0x91 value=3c latency=",
+		" op=write reg=0x0b name=SYSTEM_INTERRUPT_CLEAR value=01 latency=",
+	} {
+		found := false
+		for _, line := range lines {
+			if !strings.HasPrefix(line, "time=") {
+				t.Fatalf("malformed trace line %q", line)
+			}
+			found = found || strings.Contains(line, want)
+		}
+		if !found {
+			t.Errorf("no %q in trace:\n%s", want, trace.String())
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package vl53l0x
+
+import (
+	"slices"
+)
+
+// Remediation is machine-readable suggestion on how to fix
+// measurement failure, reported by range status.
+type Remediation int
+
+const (
+	// IncreaseTimingBudget suggests longer timing budget, which collects
+	// more return signal and lowers sigma.
+	IncreaseTimingBudget Remediation = iota + 1
+	// LowerSignalRateLimit suggests lower return signal rate limit,
+	// accepting weaker signal at the cost of accuracy.
+	LowerSignalRateLimit
+	// CheckCoverGlass suggests to inspect cover glass for dirt, smudges and
+	// air gap, producing crosstalk, and to calibrate crosstalk compensation.
+	CheckCoverGlass
+	// SwitchToLongRange suggests LongRange configuration, which raises
+	// VCSEL pulse periods and lowers signal rate limit.
+	SwitchToLongRange
+	// MoveTargetFarther suggests target is too close to the sensor.
+	MoveTargetFarther
+	// ReduceAmbientLight suggests shielding sensor from sunlight
+	// or other infrared sources.
+	ReduceAmbientLight
+	// ResetSensor suggests hardware reset and reinitialization of the sensor,
+	// and check of power supply if failure persists.
+	ResetSensor
+)
+
+// String implement Stringer interface.
+func (v Remediation) String() string {
+	switch v {
+	case IncreaseTimingBudget:
+		return "IncreaseTimingBudget"
+	case LowerSignalRateLimit:
+		return "LowerSignalRateLimit"
+	case CheckCoverGlass:
+		return "CheckCoverGlass"
+	case SwitchToLongRange:
+		return "SwitchToLongRange"
+	case MoveTargetFarther:
+		return "MoveTargetFarther"
+	case ReduceAmbientLight:
+		return "ReduceAmbientLight"
+	case ResetSensor:
+		return "ResetSensor"
+	default:
+		return "<unknown>"
+	}
+}
+
+// Description returns human-readable remediation text for end users.
+func (v Remediation) Description() string {
+	switch v {
+	case IncreaseTimingBudget:
+		return "increase measurement timing budget"
+	case LowerSignalRateLimit:
+		return "lower return signal rate limit"
+	case CheckCoverGlass:
+		return "clean cover glass and check crosstalk calibration"
+	case SwitchToLongRange:
+		return "switch to long range mode"
+	case MoveTargetFarther:
+		return "move target farther from the sensor"
+	case ReduceAmbientLight:
+		return "shield sensor from ambient light"
+	case ResetSensor:
+		return "reset sensor and check power supply"
+	default:
+		return "<unknown>"
+	}
+}
+
+// Remediations of range status failures, most effective first.
+var rangeStatusRemediations = map[RangeStatus][]Remediation{
+	SigmaFail:    {IncreaseTimingBudget, ReduceAmbientLight, CheckCoverGlass},
+	SignalFail:   {SwitchToLongRange, LowerSignalRateLimit, IncreaseTimingBudget, CheckCoverGlass},
+	MinRangeFail: {MoveTargetFarther, CheckCoverGlass},
+	PhaseFail:    {ReduceAmbientLight, IncreaseTimingBudget, CheckCoverGlass},
+	HardwareFail: {ResetSensor},
+	OutOfRange:   {SwitchToLongRange, LowerSignalRateLimit},
+}
+
+// Remediations returns suggestions to fix failure reported by range status,
+// most effective first. Returns nil for valid measurements and statuses
+// with no update.
+func (v RangeStatus) Remediations() []Remediation {
+	return slices.Clone(rangeStatusRemediations[v])
+}
@@ -0,0 +1,80 @@
+package vl53l0x
+
+import (
+	"sync/atomic"
+)
+
+// Single-producer/single-consumer lock-free ring of measurements,
+// decoupling acquisition loop from consumer without mutexes in hot path.
+// Producer either drops new measurement, when ring is full, or waits
+// for free slot; drops are accounted by caller. Size must be power of 2.
+type measurementRing struct {
+	buf  []Measurement
+	mask uint64
+	// index of next slot to read, owned by consumer
+	head atomic.Uint64
+	// index of next slot to write, owned by producer
+	tail atomic.Uint64
+	// signals consumer, that new measurement is available
+	ready chan struct{}
+	// signals producer, that slot became free
+	space chan struct{}
+}
+
+// Creates ring of size given, which must be power of 2.
+func newMeasurementRing(size int) *measurementRing {
+	v := &measurementRing{buf: make([]Measurement, size),
+		mask: uint64(size - 1), ready: make(chan struct{}, 1),
+		space: make(chan struct{}, 1)}
+	return v
+}
+
+// Non-blocking notification over channel of capacity 1.
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// Put measurement to the ring, if there is free slot; call from producer only.
+// Returns false, if ring is full and measurement isn't put.
+func (v *measurementRing) put(m Measurement) bool {
+	tail := v.tail.Load()
+	if tail-v.head.Load() > v.mask {
+		return false
+	}
+	v.buf[tail&v.mask] = m
+	v.tail.Store(tail + 1)
+	notify(v.ready)
+	return true
+}
+
+// Put measurement to the ring, waiting for free slot, if ring is full;
+// call from producer only. Returns false, if done is closed before.
+func (v *measurementRing) pushWait(m Measurement, done <-chan struct{}) bool {
+	for !v.put(m) {
+		select {
+		case <-v.space:
+		case <-done:
+			return false
+		}
+	}
+	return true
+}
+
+// Take the oldest measurement from the ring; call from consumer only.
+// Returns false, if ring is empty.
+func (v *measurementRing) pop() (Measurement, bool) {
+	head := v.head.Load()
+	if head == v.tail.Load() {
+		return Measurement{}, false
+	}
+	slot := &v.buf[head&v.mask]
+	m := *slot
+	// release reference to error
+	*slot = Measurement{}
+	v.head.Store(head + 1)
+	notify(v.space)
+	return m, true
+}
@@ -0,0 +1,122 @@
+package vl53l0x
+
+// Range of 7-bit addresses probed by Scan by default;
+// addresses outside are reserved by I2C specification.
+const (
+	scanFirstAddress = 0x08
+	scanLastAddress  = 0x77
+)
+
+// ScanOptions configure Scan.
+type ScanOptions struct {
+	// candidate addresses to probe; all non-reserved addresses
+	// from 0x08 to 0x77 are probed, if empty
+	Addresses []byte
+}
+
+// Address ranges of PCF8574 and PCF8574A GPIO expanders, which have no
+// registers: any single byte written sets their outputs, so the register
+// index write of identification would toggle lines, for instance XSHUT
+// ones, driven by expander. Default scan doesn't identify devices there.
+var scanUnsafeRanges = [][2]byte{{0x20, 0x27}, {0x38, 0x3F}}
+
+// Found describes sensor discovered by Scan.
+type Found struct {
+	// I2C-bus number and sensor address
+	Bus     int
+	Address byte
+	// IDENTIFICATION_REVISION_ID register
+	Revision byte
+	// product minor revision, as returned by GetProductMinorRevision
+	MinorRevision byte
+}
+
+// Scan probes candidate addresses on I2C-bus given and returns VL53L0X
+// sensors found, verified by model ID and module type, for instance to be
+// used by provisioning code. Nil options probe all non-reserved addresses.
+//
+// Each address is probed for presence by read-only transaction first.
+// Device answering is identified by reading 8-bit indexed identification
+// registers, which requires register index write. Such a write sets outputs
+// of PCF8574 GPIO expanders, so by default addresses 0x20..0x27 and
+// 0x38..0x3F are skipped; list them in options explicitly to find sensor
+// assigned to one of them.
+// Addresses, which can't be opened (for instance claimed by kernel driver)
+// or don't respond, are skipped; error is returned, if none of addresses
+// could be opened.
+func Scan(bus int, options *ScanOptions) ([]Found, error) {
+	var addrs []byte
+	if options != nil {
+		addrs = options.Addresses
+	}
+	explicit := len(addrs) > 0
+	if !explicit {
+		for addr := scanFirstAddress; addr <= scanLastAddress; addr++ {
+			addrs = append(addrs, byte(addr))
+		}
+	}
+	var found []Found
+	var openErr error
+	opened := false
+	for _, addr := range addrs {
+		if !explicit && scanUnsafe(addr) {
+			debugf("Skip address 0x%x reserved by GPIO expanders", addr)
+			continue
+		}
+		conn, err := newI2C(addr, bus)
+		if err != nil {
+			debugf("Skip address 0x%x: %s", addr, err)
+			openErr = err
+			continue
+		}
+		opened = true
+		f, ok := probeVl53l0x(conn)
+		conn.Close()
+		if ok {
+			f.Bus, f.Address = bus, addr
+			debugf("Found %s at address 0x%x on bus %d", ModelVl53l0x, addr, bus)
+			found = append(found, f)
+		}
+	}
+	if !opened && openErr != nil {
+		return nil, openErr
+	}
+	return found, nil
+}
+
+// Report whether address belongs to GPIO expander ranges,
+// where identification isn't safe.
+func scanUnsafe(addr byte) bool {
+	for _, r := range scanUnsafeRanges {
+		if addr >= r[0] && addr <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Check presence of device by read-only transaction, then read its
+// identification registers and returns revision info, if it's VL53L0X.
+// Device not responding is not VL53L0X.
+func probeVl53l0x(i2c Bus) (Found, bool) {
+	var f Found
+	var buf [1]byte
+	_, err := i2c.ReadBytes(buf[:])
+	if err != nil {
+		return f, false
+	}
+	u8, err := i2c.ReadRegU8(IDENTIFICATION_MODEL_ID)
+	if err != nil || u8 != modelIdVl53l0x {
+		return f, false
+	}
+	u8, err = i2c.ReadRegU8(regModuleTypeL0x)
+	if err != nil || u8 != moduleTypeVl53l0x {
+		return f, false
+	}
+	f.Revision, err = i2c.ReadRegU8(IDENTIFICATION_REVISION_ID)
+	if err != nil {
+		return f, false
+	}
+	f.MinorRevision = (f.Revision & 0xF0) >> 4
+	return f, true
+}
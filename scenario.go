@@ -0,0 +1,94 @@
+package vl53l0x
+
+import (
+	"sort"
+	"time"
+)
+
+// Scenario produces synthetic target distance in millimeters at time
+// elapsed since simulation start, see SimulatedSensor. Returns false,
+// when no target is present in sensor field of view.
+type Scenario interface {
+	Distance(elapsed time.Duration) (float64, bool)
+}
+
+// ScenarioFunc is an adapter to use ordinary function as Scenario.
+type ScenarioFunc func(elapsed time.Duration) (float64, bool)
+
+// Distance implement Scenario interface.
+func (f ScenarioFunc) Distance(elapsed time.Duration) (float64, bool) {
+	return f(elapsed)
+}
+
+// ConstantScenario places target at fixed distance.
+func ConstantScenario(mm float64) Scenario {
+	return ScenarioFunc(func(time.Duration) (float64, bool) {
+		return mm, true
+	})
+}
+
+// NoTargetScenario keeps field of view empty.
+func NoTargetScenario() Scenario {
+	return ScenarioFunc(func(time.Duration) (float64, bool) {
+		return 0, false
+	})
+}
+
+// RampScenario moves target linearly from one distance to another
+// during duration given, then keeps it at final distance.
+func RampScenario(from, to float64, duration time.Duration) Scenario {
+	return ScenarioFunc(func(elapsed time.Duration) (float64, bool) {
+		if elapsed >= duration || duration <= 0 {
+			return to, true
+		}
+		return from + (to-from)*float64(elapsed)/float64(duration), true
+	})
+}
+
+// TraceScenario replays recorded measurements, for instance collected by
+// Capture, with their original timing relative to the first one. Invalid
+// measurements are replayed as absence of target. Once trace is over,
+// it's started again if loop is set, otherwise the last state is kept.
+func TraceScenario(trace []Measurement, loop bool) Scenario {
+	offsets := make([]time.Duration, len(trace))
+	for i, m := range trace {
+		offsets[i] = m.Timestamp.Sub(trace[0].Timestamp)
+	}
+	var period time.Duration
+	if len(trace) > 1 {
+		// keep average interval between the last and the first sample
+		period = offsets[len(offsets)-1] +
+			offsets[len(offsets)-1]/time.Duration(len(offsets)-1)
+	}
+	return ScenarioFunc(func(elapsed time.Duration) (float64, bool) {
+		if len(trace) == 0 {
+			return 0, false
+		}
+		if loop && period > 0 {
+			elapsed %= period
+		}
+		i := sort.Search(len(offsets), func(i int) bool {
+			return offsets[i] > elapsed
+		}) - 1
+		if i < 0 {
+			i = 0
+		}
+		m := trace[i]
+		return float64(m.RangeMillimeters), m.Valid()
+	})
+}
+
+// NoiseModel describes imperfections SimulatedSensor adds to distances
+// produced by scenario.
+type NoiseModel struct {
+	// standard deviation of gaussian noise in millimeters
+	StdDev float64
+	// probability of dropout, reported as out-of-range measurement
+	DropoutRate float64
+	// probability of spike and its magnitude in millimeters;
+	// spikes are added or subtracted with equal probability
+	SpikeRate        float64
+	SpikeMillimeters float64
+	// seed of pseudo-random generator, so CI runs are reproducible
+	Seed uint64
+}
@@ -0,0 +1,128 @@
+package vl53l0x
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Sample is a measurement delivered by SampleAtRate on the tick of schedule.
+type Sample struct {
+	Measurement
+	// tick time the sample delivered on
+	Tick time.Time
+	// true if no new measurement taken since previous tick,
+	// so the sensor can't keep up with requested rate
+	Stale bool
+}
+
+// SampleAtRate deliver samples at exact rate (one per interval) regardless
+// of timing budget jitter. Sensor runs in continuous back-to-back mode, while
+// the latest measurement is delivered on each tick of the ticker. If no new
+// measurement taken since previous tick, previous one is repeated with Stale
+// flag set. Once context is done, continuous mode is stopped and channel
+// closed. Acquisition error delivered as last sample with Err field set.
+// Once ranging limit set by SetRangingLimit is reached, the last measurement
+// is delivered and channel closed without error sample.
+func (v *Vl53l0x) SampleAtRate(ctx context.Context, i2c Bus,
+	interval time.Duration) (<-chan Sample, error) {
+
+	debugf("Start sampling each %v", interval)
+
+	if interval <= 0 {
+		return nil, errors.New("sampling interval should be positive")
+	}
+	budget := time.Duration(v.measurementTimingBudgetUsec) * time.Microsecond
+	if budget > interval {
+		warnf("Timing budget %v exceeds sampling interval %v, sensor can't keep up",
+			budget, interval)
+	}
+	err := v.StartContinuousDuration(i2c, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var latest struct {
+		sync.Mutex
+		m   Measurement
+		seq uint64
+		// ranging limit reached, no new measurements follow
+		limited bool
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer v.stopContinuousQuietly(i2c)
+		for {
+			m, err := v.ReadMeasurementContinuous(i2c)
+			if errors.Is(err, ErrLimitReached) {
+				latest.Lock()
+				latest.limited = true
+				latest.Unlock()
+				return
+			}
+			if err != nil {
+				m.Err = err
+			}
+			if v.warmingUp(m) {
+				continue
+			}
+			latest.Lock()
+			latest.m = m
+			latest.seq++
+			latest.Unlock()
+			if err != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+		}
+	}()
+
+	ch := make(chan Sample, streamBufferSize)
+	go func() {
+		defer close(ch)
+		defer wg.Wait()
+		defer cancel()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var delivered uint64
+		for {
+			select {
+			case <-ctx.Done():
+				debug("Stop sampling")
+				return
+			case tick := <-ticker.C:
+				latest.Lock()
+				s := Sample{Measurement: latest.m, Tick: tick,
+					Stale: latest.seq == delivered}
+				limited := latest.limited
+				delivered = latest.seq
+				latest.Unlock()
+				if s.Stale && limited {
+					debug("Stop sampling on ranging limit")
+					return
+				}
+				if delivered == 0 {
+					// nothing measured yet
+					continue
+				}
+				select {
+				case ch <- s:
+				case <-ctx.Done():
+					return
+				}
+				if s.Err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
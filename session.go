@@ -0,0 +1,289 @@
+package vl53l0x
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// Version of session format produced by SessionWriter.
+const sessionVersion = 1
+
+// Session is recorded in JSON Lines format: record per line, each having
+// "type" field. Time offsets are in microseconds since session start.
+//
+//	{"type":"session","version":1,"driver":"...","start":"2024-05-01T10:00:00Z"}
+//	{"type":"config","t":0,"snapshot":{...}}
+//	{"type":"result","t":33012,"raw":"5800040000000a0c000d0096"}
+//	{"type":"error","t":66100,"error":"timeout occurs at WaitDataReady after 1s; ..."}
+//
+// Raw result block is the content of 12 registers starting from
+// RESULT_RANGE_STATUS, so measurements are decoded again on replay
+// with current software settings (correction table, mounting angle,
+// range ignore threshold) and thresholds could be tuned offline.
+type sessionRecord struct {
+	Type     string     `json:"type"`
+	Version  int        `json:"version,omitempty"`
+	Driver   string     `json:"driver,omitempty"`
+	Start    *time.Time `json:"start,omitempty"`
+	Offset   int64      `json:"t"`
+	Snapshot *Snapshot  `json:"snapshot,omitempty"`
+	Raw      string     `json:"raw,omitempty"`
+	Err      string     `json:"error,omitempty"`
+}
+
+// Session record types.
+const (
+	recordSession = "session"
+	recordConfig  = "config"
+	recordResult  = "result"
+	recordError   = "error"
+)
+
+// SessionWriter writes measurement session: configuration snapshots,
+// raw result blocks and acquisition errors. Attach it to the sensor with
+// SetRecorder, or use Record helper. Output is buffered; call Flush
+// once recording is over.
+type SessionWriter struct {
+	sync.Mutex
+	w     *bufio.Writer
+	enc   *json.Encoder
+	start time.Time
+	// the first write error, after which recording stops
+	err error
+}
+
+// NewSessionWriter creates session writer to w.
+func NewSessionWriter(w io.Writer) *SessionWriter {
+	bw := bufio.NewWriter(w)
+	v := &SessionWriter{w: bw, enc: json.NewEncoder(bw)}
+	return v
+}
+
+// WriteConfig write configuration snapshot, taken by TakeSnapshot.
+// Timing budget of snapshot is used on replay for sigma estimation.
+func (v *SessionWriter) WriteConfig(s *Snapshot) error {
+	return v.write(time.Now(), sessionRecord{Type: recordConfig, Snapshot: s})
+}
+
+// WriteResult write raw result block read at moment ts.
+func (v *SessionWriter) WriteResult(ts time.Time, raw []byte) error {
+	return v.write(ts, sessionRecord{Type: recordResult, Raw: hex.EncodeToString(raw)})
+}
+
+// WriteError write acquisition error.
+func (v *SessionWriter) WriteError(ts time.Time, err error) error {
+	return v.write(ts, sessionRecord{Type: recordError, Err: err.Error()})
+}
+
+// Flush write buffered records.
+func (v *SessionWriter) Flush() error {
+	v.Lock()
+	defer v.Unlock()
+	if v.err != nil {
+		return v.err
+	}
+	return v.w.Flush()
+}
+
+// Write record, starting session with header, if required.
+func (v *SessionWriter) write(ts time.Time, r sessionRecord) error {
+	v.Lock()
+	defer v.Unlock()
+	if v.err != nil {
+		return v.err
+	}
+	if v.start.IsZero() {
+		v.start = ts
+		v.err = v.enc.Encode(sessionRecord{Type: recordSession,
+			Version: sessionVersion, Driver: Version().String(), Start: &ts})
+		if v.err != nil {
+			return v.err
+		}
+	}
+	r.Offset = ts.Sub(v.start).Microseconds()
+	v.err = v.enc.Encode(r)
+	return v.err
+}
+
+// SetRecorder attach session writer, so every measurement taken by
+// the driver is recorded as raw result block; pass nil to detach.
+// Don't call it while ranging.
+func (v *Vl53l0x) SetRecorder(w *SessionWriter) {
+	v.recorder = w
+}
+
+// Record raw result block, if recorder attached.
+func (v *Vl53l0x) recordResult(ts time.Time, raw []byte) {
+	if v.recorder == nil {
+		return
+	}
+	err := v.recorder.WriteResult(ts, raw)
+	if err != nil {
+		warnf("Session recording failed: %s", err)
+		v.recorder = nil
+	}
+}
+
+// Record acquisition error, if recorder attached.
+func (v *Vl53l0x) recordError(err error) {
+	if v.recorder == nil {
+		return
+	}
+	err = v.recorder.WriteError(time.Now(), err)
+	if err != nil {
+		warnf("Session recording failed: %s", err)
+		v.recorder = nil
+	}
+}
+
+// Record writes configuration snapshot and then measurements taken
+// in continuous mode with inter-measurement period given (0 stands for
+// back-to-back mode) to w, until context is done or acquisition error
+// occurs. Measurements are passed to handler, if not nil, so session
+// could be recorded along with regular processing.
+func (v *Vl53l0x) Record(ctx context.Context, i2c Bus, period time.Duration,
+	w io.Writer, handler func(Measurement)) error {
+
+	debug("Start session recording")
+
+	sw := NewSessionWriter(w)
+	snapshot, err := v.TakeSnapshot(i2c)
+	if err != nil {
+		return err
+	}
+	err = sw.WriteConfig(snapshot)
+	if err != nil {
+		return err
+	}
+	v.SetRecorder(sw)
+	defer v.SetRecorder(nil)
+	ch, err := v.Stream(ctx, i2c, period)
+	if err != nil {
+		return err
+	}
+	var streamErr error
+	for m := range ch {
+		if m.Err != nil {
+			streamErr = m.Err
+		}
+		if handler != nil {
+			handler(m)
+		}
+	}
+	err = sw.Flush()
+	if streamErr != nil {
+		return streamErr
+	}
+	return err
+}
+
+// Replay decodes recorded session and delivers measurements over
+// channel, like Stream does, so they could be passed through filters and
+// event detectors. Measurements keep original timestamps; with speed
+// of 1 they are delivered with original timing, 2 - twice faster and so on;
+// zero speed means as fast as consumer reads. Software settings of the
+// sensor (correction table, mounting angle, range ignore threshold) are
+// applied; sensor doesn't need to be connected. Recorded acquisition
+// errors are delivered as measurements with Err field set; format error
+// is delivered as last measurement with Err field set, after that channel
+// is closed. Channel is closed as well, once session is over or context
+// is done.
+func (v *Vl53l0x) Replay(ctx context.Context, r io.Reader,
+	speed float64) <-chan Measurement {
+
+	debug("Start session replay")
+
+	ch := make(chan Measurement, streamBufferSize)
+	go func() {
+		defer close(ch)
+		err := v.replay(ctx, r, speed, ch)
+		if err != nil && ctx.Err() == nil {
+			select {
+			case ch <- Measurement{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return ch
+}
+
+// Decode session records and send measurements to channel.
+func (v *Vl53l0x) replay(ctx context.Context, r io.Reader, speed float64,
+	ch chan<- Measurement) error {
+
+	dec := json.NewDecoder(r)
+	var start time.Time
+	// replay clock: the first record is delivered immediately
+	var first int64 = -1
+	began := time.Now()
+	budgetUsec := v.measurementTimingBudgetUsec
+	for {
+		var rec sessionRecord
+		err := dec.Decode(&rec)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if start.IsZero() {
+			if rec.Type != recordSession {
+				return errors.New("session header not found")
+			}
+			if rec.Version != sessionVersion || rec.Start == nil {
+				return errors.New(spew.Sprintf("unsupported session version %d", rec.Version))
+			}
+			start = *rec.Start
+			continue
+		}
+		var m Measurement
+		ts := start.Add(time.Duration(rec.Offset) * time.Microsecond)
+		switch rec.Type {
+		case recordConfig:
+			if rec.Snapshot != nil {
+				budgetUsec = rec.Snapshot.TimingBudgetUsec
+			}
+			continue
+		case recordResult:
+			raw, err := hex.DecodeString(rec.Raw)
+			if err != nil {
+				return err
+			}
+			if len(raw) != resultBlockSize {
+				return errors.New(spew.Sprintf("result block size %d, expected %d",
+					len(raw), resultBlockSize))
+			}
+			v.parseResult(&m, (*[resultBlockSize]byte)(raw), ts, budgetUsec)
+		case recordError:
+			m = Measurement{Timestamp: ts, Err: errors.New(rec.Err)}
+		default:
+			// skip records of future format extensions
+			continue
+		}
+		if speed > 0 {
+			if first < 0 {
+				first = rec.Offset
+			}
+			due := began.Add(time.Duration(float64(rec.Offset-first)/speed) * time.Microsecond)
+			timer := time.NewTimer(time.Until(due))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			}
+		}
+		select {
+		case ch <- m:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package vl53l0x
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Defaults of simulated sensor.
+const (
+	defaultSimMeasurementTime = time.Microsecond * 33000
+	defaultSimMaxRange        = 2000
+	defaultSimAmbientRate     = 0.1
+	// effective SPAD count reported, as typical for uncovered sensor
+	simEffectiveSpadCount = 4
+)
+
+// SimulatedSensor emulates VL53L0X register map, generating synthetic
+// distances from Scenario with optional NoiseModel. It implements Bus,
+// BatchBus and InterruptWaiter, so the regular driver, filters and event detectors
+// run on top of it unchanged, allowing application development and CI
+// testing without hardware:
+//
+//	sim := vl53l0x.NewSimulatedSensor(vl53l0x.RampScenario(100, 1000, 10*time.Second))
+//	sensor := vl53l0x.NewVl53l0x()
+//	sensor.SetInterruptWaiter(sim)
+//	err := sensor.Init(sim)
+//	...
+//	ch, err := sensor.Stream(ctx, sim, 0)
+//
+// Measurements complete in real time according to measurement time (33 ms
+// by default) or inter-measurement period in timed continuous mode.
+// Scenario time starts with the first ranging after creation.
+// Signal rate is derived from distance, so confidence and sigma
+// estimation behave plausibly.
+type SimulatedSensor struct {
+	sync.Mutex
+	scenario        Scenario
+	noise           NoiseModel
+	rnd             *rand.Rand
+	measurementTime time.Duration
+	maxRange        float64
+	ambientRate     float32
+	// register map of page 0 and all other pages
+	regs  [256]byte
+	paged [256]byte
+	page  byte
+	// register pointer of multi-byte transactions
+	pointer byte
+	// ranging state
+	start     time.Time
+	mode      byte
+	readyAt   time.Time
+	generated bool
+}
+
+// NewSimulatedSensor creates simulated sensor driven by scenario given.
+func NewSimulatedSensor(scenario Scenario) *SimulatedSensor {
+	v := &SimulatedSensor{scenario: scenario,
+		rnd:             rand.New(rand.NewPCG(0, 0)),
+		measurementTime: defaultSimMeasurementTime,
+		maxRange:        defaultSimMaxRange,
+		ambientRate:     defaultSimAmbientRate}
+	v.regs[IDENTIFICATION_MODEL_ID] = modelIdVl53l0x
+	v.regs[IDENTIFICATION_REVISION_ID] = 0x10
+	for i := 0; i < 6; i++ {
+		v.regs[GLOBAL_CONFIG_SPAD_ENABLES_REF_0+i] = 0xFF
+	}
+	// stop variable and reference SPAD info: 5 aperture SPADs
+	v.paged[0x91] = 0x3C
+	v.paged[0x92] = 0x85
+	return v
+}
+
+// SetNoise define imperfections added to scenario distances.
+func (v *SimulatedSensor) SetNoise(noise NoiseModel) {
+	v.Lock()
+	defer v.Unlock()
+	v.noise = noise
+	v.rnd = rand.New(rand.NewPCG(noise.Seed, noise.Seed))
+}
+
+// SetMeasurementTime define duration of single ranging; 33 ms by default.
+func (v *SimulatedSensor) SetMeasurementTime(d time.Duration) {
+	v.Lock()
+	defer v.Unlock()
+	v.measurementTime = d
+}
+
+// SetMaxRange define distance in millimeters, beyond which target
+// is not detected; 2000 mm by default.
+func (v *SimulatedSensor) SetMaxRange(mm float64) {
+	v.Lock()
+	defer v.Unlock()
+	v.maxRange = mm
+}
+
+// SetAmbientRate define reported ambient rate in MCPS.
+func (v *SimulatedSensor) SetAmbientRate(mcps float32) {
+	v.Lock()
+	defer v.Unlock()
+	v.ambientRate = mcps
+}
+
+// ReadRegU8 implement Bus interface.
+func (v *SimulatedSensor) ReadRegU8(reg byte) (byte, error) {
+	v.Lock()
+	defer v.Unlock()
+	v.pointer = reg
+	return v.readReg(reg, time.Now()), nil
+}
+
+// WriteRegU8 implement Bus interface.
+func (v *SimulatedSensor) WriteRegU8(reg byte, value byte) error {
+	v.Lock()
+	defer v.Unlock()
+	v.writeReg(reg, value, time.Now())
+	return nil
+}
+
+// ReadBytes implement Bus interface. Reads start from register
+// addressed by the last write.
+func (v *SimulatedSensor) ReadBytes(buf []byte) (int, error) {
+	v.Lock()
+	defer v.Unlock()
+	now := time.Now()
+	for i := range buf {
+		buf[i] = v.readReg(v.pointer+byte(i), now)
+	}
+	return len(buf), nil
+}
+
+// WriteBytes implement Bus interface. The first byte addresses
+// register, the rest are written to consecutive registers.
+func (v *SimulatedSensor) WriteBytes(buf []byte) (int, error) {
+	v.Lock()
+	defer v.Unlock()
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	v.pointer = buf[0]
+	now := time.Now()
+	for i, b := range buf[1:] {
+		v.writeReg(buf[0]+byte(i), b, now)
+	}
+	return len(buf), nil
+}
+
+// WriteBatch implement BatchBus interface.
+func (v *SimulatedSensor) WriteBatch(msgs [][]byte) error {
+	for _, msg := range msgs {
+		_, err := v.WriteBytes(msg)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitInterrupt implement InterruptWaiter interface: it sleeps
+// until pending measurement is complete, which saves CPU otherwise
+// spent on polling of status register.
+func (v *SimulatedSensor) WaitInterrupt(timeout time.Duration) (bool, error) {
+	v.Lock()
+	readyAt := v.readyAt
+	v.Unlock()
+	if readyAt.IsZero() {
+		return false, nil
+	}
+	wait := time.Until(readyAt)
+	if wait > timeout && timeout > 0 {
+		time.Sleep(timeout)
+		return false, nil
+	}
+	time.Sleep(wait)
+	return true, nil
+}
+
+// Read register, emulating status and result registers.
+// Call with sensor locked.
+func (v *SimulatedSensor) readReg(reg byte, now time.Time) byte {
+	if reg == 0xFF {
+		return v.page
+	}
+	if v.page != 0 {
+		if reg == 0x83 && v.paged[reg] == 0 {
+			// NVM strobe is complete immediately
+			return 0x01
+		}
+		return v.paged[reg]
+	}
+	switch reg {
+	case SYSRANGE_START:
+		// single-shot start bit is cleared, once ranging started
+		return v.regs[reg] &^ 0x01
+	case RESULT_INTERRUPT_STATUS:
+		if !v.readyAt.IsZero() && !now.Before(v.readyAt) {
+			return 0x07
+		}
+		return 0
+	case RESULT_RANGE_STATUS:
+		if !v.generated && !v.readyAt.IsZero() {
+			v.generate()
+		}
+	}
+	return v.regs[reg]
+}
+
+// Write register, emulating ranging commands. Call with sensor locked.
+func (v *SimulatedSensor) writeReg(reg byte, value byte, now time.Time) {
+	if reg == 0xFF {
+		v.page = value
+		return
+	}
+	if v.page != 0 {
+		v.paged[reg] = value
+		return
+	}
+	v.regs[reg] = value
+	switch reg {
+	case SYSRANGE_START:
+		if value&0x07 == 0 {
+			return
+		}
+		if v.start.IsZero() && !v.refCalibration() {
+			v.start = now
+		}
+		v.mode = value & 0x07
+		v.readyAt = now.Add(v.interval())
+		v.generated = false
+	case SYSTEM_INTERRUPT_CLEAR:
+		if value&0x01 == 0 || v.readyAt.IsZero() || now.Before(v.readyAt) {
+			return
+		}
+		v.generated = false
+		if v.mode&0x06 == 0 {
+			// single-shot ranging is over
+			v.readyAt = time.Time{}
+			return
+		}
+		// next continuous ranging either in progress or already complete
+		v.readyAt = v.readyAt.Add(v.interval())
+		if v.readyAt.Before(now) {
+			v.readyAt = now
+		}
+	}
+}
+
+// Duration of current ranging cycle. Call with sensor locked.
+func (v *SimulatedSensor) interval() time.Duration {
+	if v.refCalibration() {
+		return time.Millisecond
+	}
+	d := v.measurementTime
+	if v.mode == 0x04 {
+		// timed mode: inter-measurement period in milliseconds,
+		// multiplied by oscillator calibration value
+		period := uint32(v.regs[SYSTEM_INTERMEASUREMENT_PERIOD])<<24 |
+			uint32(v.regs[SYSTEM_INTERMEASUREMENT_PERIOD+1])<<16 |
+			uint32(v.regs[SYSTEM_INTERMEASUREMENT_PERIOD+2])<<8 |
+			uint32(v.regs[SYSTEM_INTERMEASUREMENT_PERIOD+3])
+		osc := uint32(v.regs[OSC_CALIBRATE_VAL])<<8 | uint32(v.regs[OSC_CALIBRATE_VAL+1])
+		if osc != 0 {
+			period /= osc
+		}
+		d = max(d, time.Duration(period)*time.Millisecond)
+	}
+	return d
+}
+
+// Check, that only reference calibration steps are enabled
+// in sequence. Call with sensor locked.
+func (v *SimulatedSensor) refCalibration() bool {
+	return v.regs[SYSTEM_SEQUENCE_CONFIG]&0xE0 == 0
+}
+
+// Generate result block of pending ranging. Call with sensor locked.
+func (v *SimulatedSensor) generate() {
+	elapsed := v.readyAt.Sub(v.start)
+	mm, ok := v.scenario.Distance(elapsed)
+	if ok {
+		mm += v.rnd.NormFloat64() * v.noise.StdDev
+		if v.noise.SpikeRate > 0 && v.rnd.Float64() < v.noise.SpikeRate {
+			if v.rnd.IntN(2) == 0 {
+				mm += v.noise.SpikeMillimeters
+			} else {
+				mm -= v.noise.SpikeMillimeters
+			}
+		}
+		if v.noise.DropoutRate > 0 && v.rnd.Float64() < v.noise.DropoutRate {
+			ok = false
+		}
+	}
+	// device range status 11 stands for valid ranging,
+	// 4 for signal fail, which comes with 8190 mm when no target
+	status := byte(11)
+	rng := uint16(math.Round(math.Max(0, mm)))
+	var signal float64
+	if !ok || mm > v.maxRange {
+		status, rng = 4, outOfRangeMillimeters
+	} else {
+		// return signal decays with squared distance: 20 MCPS at 100 mm
+		d := math.Max(mm, 30)
+		signal = math.Min(2e5/(d*d), 511)
+	}
+	r := v.regs[RESULT_RANGE_STATUS : RESULT_RANGE_STATUS+resultBlockSize]
+	clear(r)
+	r[0] = status << 3
+	r[2], r[3] = simEffectiveSpadCount, 0
+	putU16 := func(b []byte, u uint16) {
+		b[0], b[1] = byte(u>>8), byte(u)
+	}
+	// rates in Q9.7 fixed point format
+	putU16(r[6:], uint16(signal*(1<<7)))
+	putU16(r[8:], uint16(math.Min(float64(v.ambientRate), 511)*(1<<7)))
+	putU16(r[10:], rng)
+	v.generated = true
+}
@@ -0,0 +1,227 @@
+package vl53l0x
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// Version of register snapshot format produced by TakeSnapshot.
+const snapshotVersion = 1
+
+// Configuration registers captured by register snapshot.
+// Multi-byte registers are listed byte by byte.
+var snapshotRegs = []byte{
+	SYSTEM_SEQUENCE_CONFIG,
+	SYSTEM_INTERMEASUREMENT_PERIOD, SYSTEM_INTERMEASUREMENT_PERIOD + 1,
+	SYSTEM_INTERMEASUREMENT_PERIOD + 2, SYSTEM_INTERMEASUREMENT_PERIOD + 3,
+	SYSTEM_RANGE_CONFIG,
+	SYSTEM_INTERRUPT_CONFIG_GPIO,
+	SYSTEM_THRESH_HIGH, SYSTEM_THRESH_HIGH + 1,
+	SYSTEM_THRESH_LOW, SYSTEM_THRESH_LOW + 1,
+	CROSSTALK_COMPENSATION_PEAK_RATE_MCPS, CROSSTALK_COMPENSATION_PEAK_RATE_MCPS + 1,
+	PRE_RANGE_CONFIG_MIN_SNR,
+	ALGO_PART_TO_PART_RANGE_OFFSET_MM, ALGO_PART_TO_PART_RANGE_OFFSET_MM + 1,
+	ALGO_PHASECAL_LIM,
+	GLOBAL_CONFIG_VCSEL_WIDTH,
+	HISTOGRAM_CONFIG_INITIAL_PHASE_SELECT,
+	FINAL_RANGE_CONFIG_MIN_COUNT_RATE_RTN_LIMIT, FINAL_RANGE_CONFIG_MIN_COUNT_RATE_RTN_LIMIT + 1,
+	MSRC_CONFIG_TIMEOUT_MACROP,
+	FINAL_RANGE_CONFIG_VALID_PHASE_LOW,
+	FINAL_RANGE_CONFIG_VALID_PHASE_HIGH,
+	DYNAMIC_SPAD_NUM_REQUESTED_REF_SPAD,
+	DYNAMIC_SPAD_REF_EN_START_OFFSET,
+	PRE_RANGE_CONFIG_VCSEL_PERIOD,
+	PRE_RANGE_CONFIG_TIMEOUT_MACROP_HI,
+	PRE_RANGE_CONFIG_TIMEOUT_MACROP_LO,
+	HISTOGRAM_CONFIG_READOUT_CTRL,
+	PRE_RANGE_CONFIG_VALID_PHASE_LOW,
+	PRE_RANGE_CONFIG_VALID_PHASE_HIGH,
+	MSRC_CONFIG_CONTROL,
+	PRE_RANGE_CONFIG_SIGMA_THRESH_HI,
+	PRE_RANGE_CONFIG_SIGMA_THRESH_LO,
+	PRE_RANGE_MIN_COUNT_RATE_RTN_LIMIT, PRE_RANGE_MIN_COUNT_RATE_RTN_LIMIT + 1,
+	FINAL_RANGE_CONFIG_MIN_SNR,
+	FINAL_RANGE_CONFIG_VCSEL_PERIOD,
+	FINAL_RANGE_CONFIG_TIMEOUT_MACROP_HI,
+	FINAL_RANGE_CONFIG_TIMEOUT_MACROP_LO,
+	GPIO_HV_MUX_ACTIVE_HIGH,
+	VHV_CONFIG_PAD_SCL_SDA__EXTSUP_HV,
+	GLOBAL_CONFIG_SPAD_ENABLES_REF_0, GLOBAL_CONFIG_SPAD_ENABLES_REF_1,
+	GLOBAL_CONFIG_SPAD_ENABLES_REF_2, GLOBAL_CONFIG_SPAD_ENABLES_REF_3,
+	GLOBAL_CONFIG_SPAD_ENABLES_REF_4, GLOBAL_CONFIG_SPAD_ENABLES_REF_5,
+	GLOBAL_CONFIG_REF_EN_START_SELECT,
+}
+
+// RegisterValue is a single register captured by snapshot.
+type RegisterValue struct {
+	Reg   byte `json:"reg"`
+	Value byte `json:"value"`
+}
+
+// Snapshot keeps device configuration state: configuration registers,
+// reference calibration values and driver internal state, required to
+// reproduce exact device state on another unit. It's serialized to JSON
+// by Marshal, so users could send it to support for troubleshooting.
+type Snapshot struct {
+	// format version
+	Version int `json:"version"`
+	// driver and tuning versions produced snapshot
+	Driver string    `json:"driver"`
+	Time   time.Time `json:"time"`
+	// configuration registers
+	Registers []RegisterValue `json:"registers"`
+	// reference calibration values (page 1 registers)
+	VhvSettings uint8 `json:"vhv_settings"`
+	PhaseCal    uint8 `json:"phase_cal"`
+	// driver internal state
+	StopVariable     uint8  `json:"stop_variable"`
+	TimingBudgetUsec uint32 `json:"timing_budget_us"`
+}
+
+// TakeSnapshot reads configuration registers and driver state.
+// Don't call it while ranging.
+func (v *Vl53l0x) TakeSnapshot(i2c Bus) (*Snapshot, error) {
+
+	debug("Take register snapshot")
+
+	s := &Snapshot{Version: snapshotVersion, Driver: Version().String(),
+		Time: time.Now(), StopVariable: v.stopVariable,
+		TimingBudgetUsec: v.measurementTimingBudgetUsec}
+	for _, reg := range snapshotRegs {
+		u8, err := v.readRegU8(i2c, reg)
+		if err != nil {
+			return nil, err
+		}
+		s.Registers = append(s.Registers, RegisterValue{Reg: reg, Value: u8})
+	}
+	var err error
+	s.VhvSettings, s.PhaseCal, err = v.refCalibrationIo(i2c, true, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// RestoreSnapshot writes registers and driver state from snapshot
+// to the sensor initialized by Init. Don't call it while ranging.
+func (v *Vl53l0x) RestoreSnapshot(i2c Bus, s *Snapshot) error {
+
+	debug("Restore register snapshot")
+
+	if s.Version != snapshotVersion {
+		return errors.New(spew.Sprintf("unsupported snapshot version %d", s.Version))
+	}
+	for _, r := range s.Registers {
+		if volatileRegs[r.Reg] {
+			return errors.New(spew.Sprintf("snapshot contains volatile register 0x%X", r.Reg))
+		}
+		err := v.writeRegU8(i2c, r.Reg, r.Value)
+		if err != nil {
+			return err
+		}
+	}
+	_, _, err := v.refCalibrationIo(i2c, false, s.VhvSettings, s.PhaseCal)
+	if err != nil {
+		return err
+	}
+	v.stopVariable = s.StopVariable
+	v.measurementTimingBudgetUsec = s.TimingBudgetUsec
+	return nil
+}
+
+// Marshal returns snapshot as indented JSON.
+func (s *Snapshot) Marshal() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// UnmarshalSnapshot parse snapshot from JSON produced by Snapshot.Marshal.
+func UnmarshalSnapshot(data []byte) (*Snapshot, error) {
+	s := &Snapshot{}
+	err := json.Unmarshal(data, s)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// RegisterChange is a register, which value differs between two snapshots.
+type RegisterChange struct {
+	// register page, 1 for reference calibration values
+	Page byte
+	Reg  byte
+	// symbolic name, empty if register isn't known
+	Name     string
+	Old, New byte
+}
+
+// String implement Stringer interface.
+func (v RegisterChange) String() string {
+	name := v.Name
+	if name == "" {
+		name = "<unknown>"
+	}
+	if v.Page != 0 {
+		return spew.Sprintf("%s (page %d, 0x%02X): 0x%02X -> 0x%02X",
+			name, v.Page, v.Reg, v.Old, v.New)
+	}
+	return spew.Sprintf("%s (0x%02X): 0x%02X -> 0x%02X", name, v.Reg, v.Old, v.New)
+}
+
+// DiffSnapshots compare configuration registers and reference calibration
+// values of snapshots a and b, for instance customer's configuration against
+// known-good baseline, returning changed registers in address order.
+func DiffSnapshots(a, b *Snapshot) ([]RegisterChange, error) {
+	if a.Version != b.Version {
+		return nil, errors.New(spew.Sprintf("snapshot versions %d and %d differ",
+			a.Version, b.Version))
+	}
+	if len(a.Registers) != len(b.Registers) {
+		return nil, errors.New("snapshots contain different registers")
+	}
+	old := make(map[byte]byte, len(a.Registers))
+	for _, r := range a.Registers {
+		old[r.Reg] = r.Value
+	}
+	var changes []RegisterChange
+	for _, r := range b.Registers {
+		u8, ok := old[r.Reg]
+		if !ok {
+			return nil, errors.New(spew.Sprintf("register 0x%02X is missing in snapshot", r.Reg))
+		}
+		if u8 != r.Value {
+			changes = append(changes, RegisterChange{Reg: r.Reg,
+				Name: snapshotRegName(r.Reg), Old: u8, New: r.Value})
+		}
+	}
+	slices.SortFunc(changes, func(x, y RegisterChange) int {
+		return int(x.Reg) - int(y.Reg)
+	})
+	for _, r := range []RegisterChange{
+		{Page: 1, Reg: 0x91, Name: "STOP_VARIABLE", Old: a.StopVariable, New: b.StopVariable},
+		{Page: 1, Reg: 0xCB, Name: "VHV_SETTINGS", Old: a.VhvSettings, New: b.VhvSettings},
+		{Page: 1, Reg: 0xEE, Name: "PHASE_CAL", Old: a.PhaseCal, New: b.PhaseCal},
+	} {
+		if r.Old != r.New {
+			changes = append(changes, r)
+		}
+	}
+	return changes, nil
+}
+
+// Name register captured by snapshot. Bytes of multi-byte
+// registers are named after the first one, like "SYSTEM_THRESH_HIGH+1".
+func snapshotRegName(reg byte) string {
+	if name, ok := registerNames[reg]; ok {
+		return name
+	}
+	for k := byte(1); k <= 3 && k <= reg; k++ {
+		if name, ok := registerNames[reg-k]; ok {
+			return spew.Sprintf("%s+%d", name, k)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package vl53l0x
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	baseline, err := v.TakeSnapshot(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = v.SetSignalRateLimit(bus, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := v.TakeSnapshot(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := DiffSnapshots(baseline, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range changes {
+		names = append(names, c.Name)
+	}
+	want := []string{"FINAL_RANGE_CONFIG_MIN_COUNT_RATE_RTN_LIMIT+1"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("changed registers %v, want %v", names, want)
+	}
+	changes, err = DiffSnapshots(s, s)
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("snapshot differs from itself: %v, %v", changes, err)
+	}
+}
@@ -0,0 +1,170 @@
+package vl53l0x
+
+import (
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// StatsSnapshot keeps statistics collected by Stats at some moment.
+// Min, Max, Mean and StdDev calculated over valid measurements only.
+type StatsSnapshot struct {
+	Count   int
+	Valid   int
+	Invalid int
+	// range in millimeters
+	Min    uint16
+	Max    uint16
+	Mean   float64
+	StdDev float64
+	// share of valid measurements, from 0 to 1
+	ValidRatio float64
+	// range percentiles in millimeters over sliding window of the last
+	// valid measurements; zero, unless window is set by SetPercentileWindow
+	P50 uint16
+	P90 uint16
+	P99 uint16
+}
+
+// String implement Stringer interface.
+func (v StatsSnapshot) String() string {
+	s := spew.Sprintf("count=%d, valid=%.1f%%, min=%d mm, max=%d mm, mean=%.1f mm, stddev=%.1f mm",
+		v.Count, v.ValidRatio*100, v.Min, v.Max, v.Mean, v.StdDev)
+	if v.P99 != 0 {
+		s += spew.Sprintf(", p50=%d mm, p90=%d mm, p99=%d mm", v.P50, v.P90, v.P99)
+	}
+	return s
+}
+
+// Stats is a streaming statistics accumulator, which could be fed
+// by measurements directly, or attached to the stream. Used for quick
+// characterization of sensor installation. Safe for concurrent use.
+type Stats struct {
+	sync.Mutex
+	count int
+	valid int
+	min   uint16
+	max   uint16
+	// running mean and sum of squared deviations (Welford's algorithm)
+	mean float64
+	m2   float64
+	// sliding window of the last valid ranges for percentiles
+	window []uint16
+	next   int
+	filled int
+	// scratch buffer of percentiles calculation
+	sorted []uint16
+}
+
+// NewStats creates empty statistics accumulator.
+func NewStats() *Stats {
+	v := &Stats{}
+	return v
+}
+
+// SetPercentileWindow enable estimation of range percentiles (P50, P90, P99)
+// over sliding window of n last valid measurements, so dashboards could show
+// robust summary, rather than noise-dominated mean. Percentiles are
+// calculated exactly on snapshot, at the cost of sorting the window.
+// Zero value disables percentiles, which is default. Resets the window.
+func (v *Stats) SetPercentileWindow(n int) {
+	v.Lock()
+	defer v.Unlock()
+	v.window = nil
+	v.sorted = nil
+	if n > 0 {
+		v.window = make([]uint16, n)
+		v.sorted = make([]uint16, 0, n)
+	}
+	v.next = 0
+	v.filled = 0
+}
+
+// Add measurement to statistics.
+func (v *Stats) Add(m Measurement) {
+	v.Lock()
+	defer v.Unlock()
+	v.count++
+	if !m.Valid() {
+		return
+	}
+	rng := m.RangeMillimeters
+	v.valid++
+	if v.valid == 1 || rng < v.min {
+		v.min = rng
+	}
+	if v.valid == 1 || rng > v.max {
+		v.max = rng
+	}
+	if len(v.window) > 0 {
+		v.window[v.next] = rng
+		v.next = (v.next + 1) % len(v.window)
+		if v.filled < len(v.window) {
+			v.filled++
+		}
+	}
+	delta := float64(rng) - v.mean
+	v.mean += delta / float64(v.valid)
+	v.m2 += delta * (float64(rng) - v.mean)
+}
+
+// Attach returns channel repeating measurements from stream given,
+// adding each of them to statistics on the way.
+func (v *Stats) Attach(in <-chan Measurement) <-chan Measurement {
+	out := make(chan Measurement, cap(in))
+	go func() {
+		defer close(out)
+		for m := range in {
+			v.Add(m)
+			out <- m
+		}
+	}()
+	return out
+}
+
+// Snapshot returns statistics collected so far.
+func (v *Stats) Snapshot() StatsSnapshot {
+	v.Lock()
+	defer v.Unlock()
+	s := StatsSnapshot{Count: v.count, Valid: v.valid,
+		Invalid: v.count - v.valid, Min: v.min, Max: v.max, Mean: v.mean}
+	if v.count > 0 {
+		s.ValidRatio = float64(v.valid) / float64(v.count)
+	}
+	if v.valid > 1 {
+		s.StdDev = math.Sqrt(v.m2 / float64(v.valid-1))
+	}
+	if v.filled > 0 {
+		v.sorted = append(v.sorted[:0], v.window[:v.filled]...)
+		slices.Sort(v.sorted)
+		s.P50 = percentile(v.sorted, 50)
+		s.P90 = percentile(v.sorted, 90)
+		s.P99 = percentile(v.sorted, 99)
+	}
+	return s
+}
+
+// Returns percentile p of sorted values by nearest-rank method.
+func percentile(sorted []uint16, p int) uint16 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Reset clears statistics.
+func (v *Stats) Reset() {
+	v.Lock()
+	defer v.Unlock()
+	v.count = 0
+	v.valid = 0
+	v.min = 0
+	v.max = 0
+	v.mean = 0
+	v.m2 = 0
+	v.next = 0
+	v.filled = 0
+}
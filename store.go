@@ -0,0 +1,56 @@
+package vl53l0x
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ErrCalibrationNotFound returned by CalibrationStore,
+// when no calibration data stored for the key.
+var ErrCalibrationNotFound = errors.New("calibration not found")
+
+// CalibrationStore is a pluggable key-value storage for calibration blobs
+// produced by ExportCalibration. Module unique identifier (ModuleInfo.Uid)
+// is used as a key, so calibration follows physical module regardless of
+// its position on the bus.
+type CalibrationStore interface {
+	// LoadCalibration returns ErrCalibrationNotFound, if no data stored for the key.
+	LoadCalibration(key string) ([]byte, error)
+	SaveCalibration(key string, data []byte) error
+}
+
+// DirCalibrationStore keeps calibration blobs as files in directory,
+// one file per module named "<key>.json".
+type DirCalibrationStore struct {
+	dir string
+}
+
+// NewDirCalibrationStore creates calibration store in directory given.
+func NewDirCalibrationStore(dir string) *DirCalibrationStore {
+	v := &DirCalibrationStore{dir: dir}
+	return v
+}
+
+// LoadCalibration implement CalibrationStore interface.
+func (v *DirCalibrationStore) LoadCalibration(key string) ([]byte, error) {
+	data, err := os.ReadFile(v.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrCalibrationNotFound
+	}
+	return data, err
+}
+
+// SaveCalibration implement CalibrationStore interface.
+func (v *DirCalibrationStore) SaveCalibration(key string, data []byte) error {
+	err := os.MkdirAll(v.dir, 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(v.path(key), data, 0644)
+}
+
+// Build file path for the key.
+func (v *DirCalibrationStore) path(key string) string {
+	return filepath.Join(v.dir, filepath.Base(key)+".json")
+}
@@ -0,0 +1,218 @@
+package vl53l0x
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Size of ring buffer used to deliver measurements by Stream, power of 2.
+const streamBufferSize = 16
+
+// BackpressurePolicy specify what Stream does, when consumer doesn't keep up
+// with acquisition and buffer of 16 measurements is full.
+type BackpressurePolicy int
+
+const (
+	// DropNewest drops new measurements, until consumer frees buffer.
+	DropNewest BackpressurePolicy = iota + 1
+	// DropOldest drops the oldest buffered measurement to make room for
+	// the new one, so consumer always gets the latest measurements.
+	DropOldest
+	// BlockAcquisition suspends acquisition loop, until consumer frees buffer.
+	// Sensor keeps ranging meanwhile, so results are lost on sensor side
+	// instead, and measurements delivered are no longer evenly spaced.
+	BlockAcquisition
+)
+
+// String implement Stringer interface.
+func (v BackpressurePolicy) String() string {
+	switch v {
+	case DropNewest:
+		return "DropNewest"
+	case DropOldest:
+		return "DropOldest"
+	case BlockAcquisition:
+		return "BlockAcquisition"
+	default:
+		return "<unknown>"
+	}
+}
+
+// SetStreamBackpressure define what Stream does, when consumer is slow.
+// Takes effect on the next Stream call. Default is DropNewest.
+func (v *Vl53l0x) SetStreamBackpressure(policy BackpressurePolicy) {
+	v.streamPolicy = policy
+}
+
+// Stream start continuous mode with inter-measurement period given (0 stands
+// for back-to-back mode) and deliver measurements over channel, reading them
+// in background goroutine. Once context is done, continuous mode is stopped,
+// pending interrupt cleared and channel closed. Acquisition error delivered as
+// last measurement with Err field set, after that channel is closed too.
+// Once ranging limit set by SetRangingLimit is reached, channel is closed
+// without error measurement.
+//
+// Measurements are passed through lock-free ring buffer of 16 entries.
+// When it's full, measurements are dropped or acquisition loop waits
+// for consumer, according to policy set by SetStreamBackpressure; dropped
+// measurements are accounted by StreamDropped. Acquisition error is never
+// dropped.
+func (v *Vl53l0x) Stream(ctx context.Context, i2c Bus,
+	period time.Duration) (<-chan Measurement, error) {
+
+	debug("Start stream")
+
+	err := v.StartContinuousDuration(i2c, period)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := v.streamPolicy
+	ring := newMeasurementRing(streamBufferSize)
+	// closed, when acquisition is stopped and continuous mode left
+	acquired := make(chan struct{})
+	// acquisition error, set before acquired is closed
+	var failure *Measurement
+
+	go func() {
+		defer close(acquired)
+		defer v.stopContinuousQuietly(i2c)
+
+		for {
+			m, err := v.ReadMeasurementContinuous(i2c)
+			if errors.Is(err, ErrLimitReached) {
+				debug("Stop stream on ranging limit")
+				return
+			}
+			if err != nil {
+				m.Err = err
+				debugf("Stop stream on error: %s", err)
+				failure = &m
+				return
+			}
+			if v.warmingUp(m) {
+				continue
+			}
+			if policy == BlockAcquisition {
+				if !ring.pushWait(m, ctx.Done()) {
+					debug("Stop stream")
+					return
+				}
+			} else if !ring.put(m) {
+				v.streamDropped.Add(1)
+				debug("Stream consumer doesn't keep up, measurement dropped")
+			}
+			select {
+			case <-ctx.Done():
+				debug("Stop stream")
+				return
+			default:
+			}
+		}
+	}()
+
+	ch := make(chan Measurement)
+	go func() {
+		defer close(ch)
+		// channel is closed only after continuous mode left
+		defer func() { <-acquired }()
+
+		send := func(m Measurement) bool {
+			select {
+			case ch <- m:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		if policy == DropOldest {
+			v.forwardDroppingOldest(ctx, ring, ch, acquired)
+			// forwarding could stop on context done, while acquisition
+			// is still running and may set failure yet
+			<-acquired
+		} else {
+		forward:
+			for {
+				m, ok := ring.pop()
+				if ok {
+					if !send(m) {
+						return
+					}
+					continue
+				}
+				select {
+				case <-ring.ready:
+				case <-acquired:
+					break forward
+				}
+			}
+		}
+		for m, ok := ring.pop(); ok; m, ok = ring.pop() {
+			if !send(m) {
+				return
+			}
+		}
+		if failure != nil {
+			send(*failure)
+		}
+	}()
+	return ch, nil
+}
+
+// Forward measurements from ring to channel, until acquisition is over,
+// moving them to pending queue as soon as they arrive, so the oldest pending
+// one could be dropped, when queue is full. Pending measurements are
+// delivered, before the function returns.
+func (v *Vl53l0x) forwardDroppingOldest(ctx context.Context,
+	ring *measurementRing, ch chan<- Measurement, acquired <-chan struct{}) {
+
+	pending := newMeasurementRing(streamBufferSize)
+	drain := func() {
+		for m, ok := ring.pop(); ok; m, ok = ring.pop() {
+			if !pending.put(m) {
+				pending.pop()
+				pending.put(m)
+				v.streamDropped.Add(1)
+				debug("Stream consumer doesn't keep up, oldest measurement dropped")
+			}
+		}
+	}
+	var next Measurement
+	var out chan<- Measurement
+	for {
+		if out == nil {
+			if m, ok := pending.pop(); ok {
+				next, out = m, ch
+			}
+		}
+		select {
+		case out <- next:
+			out = nil
+		case <-ring.ready:
+			drain()
+		case <-acquired:
+			drain()
+			for out != nil {
+				select {
+				case out <- next:
+				case <-ctx.Done():
+					return
+				}
+				out = nil
+				if m, ok := pending.pop(); ok {
+					next, out = m, ch
+				}
+			}
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// StreamDropped returns number of measurements dropped by Stream,
+// because consumer didn't keep up with acquisition.
+func (v *Vl53l0x) StreamDropped() uint64 {
+	return v.streamDropped.Load()
+}
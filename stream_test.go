@@ -0,0 +1,68 @@
+package vl53l0x
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// Bus, which fails all transactions once fail is set.
+type failingBus struct {
+	*traceBus
+	fail atomic.Bool
+}
+
+var errBusFailed = errors.New("bus failed")
+
+func (v *failingBus) ReadRegU8(reg byte) (byte, error) {
+	if v.fail.Load() {
+		return 0, errBusFailed
+	}
+	return v.traceBus.ReadRegU8(reg)
+}
+
+func (v *failingBus) WriteRegU8(reg byte, value byte) error {
+	if v.fail.Load() {
+		return errBusFailed
+	}
+	return v.traceBus.WriteRegU8(reg, value)
+}
+
+func (v *failingBus) ReadBytes(buf []byte) (int, error) {
+	if v.fail.Load() {
+		return 0, errBusFailed
+	}
+	return v.traceBus.ReadBytes(buf)
+}
+
+func (v *failingBus) WriteBytes(buf []byte) (int, error) {
+	if v.fail.Load() {
+		return 0, errBusFailed
+	}
+	return v.traceBus.WriteBytes(buf)
+}
+
+// Acquisition error occurring along with context cancellation shouldn't
+// race with forwarding of DropOldest policy; run with -race.
+func TestStreamDropOldestCancelError(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		v, trace := initTraceSensor(t)
+		bus := &failingBus{traceBus: trace}
+		v.SetStreamBackpressure(DropOldest)
+		ctx, cancel := context.WithCancel(context.Background())
+		stop := context.AfterFunc(ctx, func() { bus.fail.Store(true) })
+		ch, err := v.Stream(ctx, bus, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-ch
+		cancel()
+		for m := range ch {
+			if m.Err != nil && !errors.Is(m.Err, errBusFailed) {
+				t.Fatalf("unexpected error: %v", m.Err)
+			}
+		}
+		stop()
+	}
+}
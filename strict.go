@@ -0,0 +1,78 @@
+package vl53l0x
+
+import (
+	"github.com/davecgh/go-spew/spew"
+)
+
+// WriteMismatchError returned in strict mode, when value read back
+// from the register differs from the value just written there.
+// Usually it points to marginal bus wiring (weak pull-ups, long wires)
+// or to another device answering on the same I2C address.
+type WriteMismatchError struct {
+	// first register of the write transaction
+	Reg byte
+	// bytes written, starting from Reg
+	Written []byte
+	// bytes read back, starting from Reg
+	ReadBack []byte
+}
+
+// Error implement error interface.
+func (e *WriteMismatchError) Error() string {
+	return spew.Sprintf("register 0x%x verification failed: written [% x], read back [% x]",
+		e.Reg, e.Written, e.ReadBack)
+}
+
+// Registers which can't be verified by reading back, since they are
+// either self-clearing command registers, page selectors, or change
+// device state in a way that makes read-back value meaningless.
+// Same registers are never merged into multi-byte writes.
+var volatileRegs = map[byte]bool{
+	SYSRANGE_START:                   true,
+	SYSTEM_INTERRUPT_CLEAR:           true,
+	I2C_SLAVE_DEVICE_ADDRESS:         true,
+	SOFT_RESET_GO2_SOFT_RESET_N:      true,
+	POWER_MANAGEMENT_GO1_POWER_FORCE: true,
+	0x81:                             true,
+	0x83:                             true,
+	0x94:                             true,
+	0xFF:                             true,
+}
+
+// SetStrictMode enable or disable strict mode. In strict mode every register
+// write is read back and compared with the value written; a difference
+// returned as *WriteMismatchError. Strict mode doubles bus traffic,
+// so it's intended mostly for bring-up and diagnostics.
+func (v *Vl53l0x) SetStrictMode(strict bool) {
+	v.strict = strict
+}
+
+// GetStrictMode returns true if strict mode is active.
+func (v *Vl53l0x) GetStrictMode() bool {
+	return v.strict
+}
+
+// Read back register values just written and compare them
+// with buf, if strict mode is active.
+func (v *Vl53l0x) verifyWrite(i2c Bus, reg byte, buf []byte) error {
+	if !v.strict {
+		return nil
+	}
+	readBack := make([]byte, len(buf))
+	err := v.readRegBytes(i2c, reg, readBack)
+	if err != nil {
+		return err
+	}
+	for i := range buf {
+		if volatileRegs[reg+byte(i)] {
+			continue
+		}
+		if buf[i] != readBack[i] {
+			debugf("Register 0x%x verification failed", reg+byte(i))
+			// buf could be scratch buffer of the driver
+			return &WriteMismatchError{Reg: reg,
+				Written: append([]byte(nil), buf...), ReadBack: readBack}
+		}
+	}
+	return nil
+}
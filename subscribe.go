@@ -0,0 +1,107 @@
+package vl53l0x
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Keeps callbacks registered to receive measurements and errors
+// from acquisition loop run by Listen.
+type subscription struct {
+	sync.Mutex
+	measurement []func(Measurement)
+	err         []func(error)
+}
+
+// OnMeasurement register handler to be called for each measurement acquired
+// by Listen. Handlers are called synchronously from acquisition loop,
+// so they should return quickly.
+func (v *Vl53l0x) OnMeasurement(handler func(Measurement)) {
+	v.handlers.Lock()
+	defer v.handlers.Unlock()
+	v.handlers.measurement = append(v.handlers.measurement, handler)
+}
+
+// OnError register handler to be called for each acquisition error
+// occurred in the loop run by Listen.
+func (v *Vl53l0x) OnError(handler func(error)) {
+	v.handlers.Lock()
+	defer v.handlers.Unlock()
+	v.handlers.err = append(v.handlers.err, handler)
+}
+
+// Listen run acquisition loop in continuous mode with inter-measurement period
+// given (0 stands for back-to-back mode) dispatching measurements to handlers
+// registered by OnMeasurement, and errors to handlers registered by OnError.
+// Acquisition continues after errors, so it's up to error handler to decide
+// when it's time to give up by cancelling context. Listen blocks until context
+// is done or ranging limit is reached, then stops continuous mode and returns
+// nil. Error is returned only if continuous mode can't be started.
+func (v *Vl53l0x) Listen(ctx context.Context, i2c Bus, period time.Duration) error {
+
+	debug("Start listening")
+
+	err := v.StartContinuousDuration(i2c, period)
+	if err != nil {
+		return err
+	}
+	defer v.stopContinuousQuietly(i2c)
+
+	// pause before next attempt after error, to avoid busy loop
+	// when bus is broken and each operation fails instantly
+	pause := period
+	if pause == 0 {
+		pause = time.Duration(v.measurementTimingBudgetUsec) * time.Microsecond
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			debug("Stop listening")
+			return nil
+		default:
+		}
+		m, err := v.ReadMeasurementContinuous(i2c)
+		if errors.Is(err, ErrLimitReached) {
+			debug("Stop listening on ranging limit")
+			return nil
+		}
+		if err != nil {
+			v.dispatchError(err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(pause):
+			}
+			continue
+		}
+		if v.warmingUp(m) {
+			continue
+		}
+		v.dispatchMeasurement(m)
+	}
+}
+
+// Call measurement handlers.
+func (v *Vl53l0x) dispatchMeasurement(m Measurement) {
+	v.handlers.Lock()
+	handlers := v.handlers.measurement
+	v.handlers.Unlock()
+	for _, handler := range handlers {
+		handler(m)
+	}
+}
+
+// Call error handlers.
+func (v *Vl53l0x) dispatchError(err error) {
+	v.handlers.Lock()
+	handlers := v.handlers.err
+	v.handlers.Unlock()
+	if len(handlers) == 0 {
+		warnf("Acquisition error: %s", err)
+	}
+	for _, handler := range handlers {
+		handler(err)
+	}
+}
@@ -0,0 +1,79 @@
+package vl53l0x
+
+import (
+	"errors"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// WaitStage identifies the wait for sensor state, which timed out.
+type WaitStage int
+
+const (
+	// WaitResetEnter is a wait for sensor to enter soft reset.
+	WaitResetEnter WaitStage = iota + 1
+	// WaitResetExit is a wait for sensor to boot after soft reset.
+	WaitResetExit
+	// WaitStartBit is a wait for single-shot start bit to clear,
+	// i.e. for ranging to start.
+	WaitStartBit
+	// WaitDataReady is a wait for measurement completion.
+	WaitDataReady
+	// WaitRefCalibration is a wait for VHV or phase calibration completion.
+	WaitRefCalibration
+	// WaitSpadInfo is a wait for SPAD info read from NVM.
+	WaitSpadInfo
+	// WaitModuleInfo is a wait for module info read from NVM.
+	WaitModuleInfo
+)
+
+// String implement Stringer interface.
+func (v WaitStage) String() string {
+	switch v {
+	case WaitResetEnter:
+		return "WaitResetEnter"
+	case WaitResetExit:
+		return "WaitResetExit"
+	case WaitStartBit:
+		return "WaitStartBit"
+	case WaitDataReady:
+		return "WaitDataReady"
+	case WaitRefCalibration:
+		return "WaitRefCalibration"
+	case WaitSpadInfo:
+		return "WaitSpadInfo"
+	case WaitModuleInfo:
+		return "WaitModuleInfo"
+	default:
+		return "<unknown>"
+	}
+}
+
+// ErrTimeout is matched by errors.Is for *TimeoutError.
+var ErrTimeout = errors.New("timeout occurs")
+
+// TimeoutError returned, when sensor doesn't reach expected state within
+// timeout set by SetTimeout. Stage allows stage-specific recovery:
+// for instance, missed WaitStartBit or WaitDataReady could be solved
+// by triggering measurement again, while stalled WaitResetExit
+// requires power cycle.
+type TimeoutError struct {
+	Stage WaitStage
+	// polled register and its last read value
+	Reg   byte
+	Value byte
+	// time spent waiting
+	Elapsed time.Duration
+}
+
+// Error implement error interface.
+func (e *TimeoutError) Error() string {
+	return spew.Sprintf("%s at %s after %v; last read register 0x%x equal to 0x%x",
+		ErrTimeout, e.Stage, e.Elapsed, e.Reg, e.Value)
+}
+
+// Unwrap returns ErrTimeout.
+func (e *TimeoutError) Unwrap() error {
+	return ErrTimeout
+}
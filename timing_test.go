@@ -0,0 +1,80 @@
+package vl53l0x
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+)
+
+// Read back timing budget exceeds requested one by difference of start
+// overheads of VL53L0X_get_measurement_timing_budget_micro_seconds() and
+// VL53L0X_set_measurement_timing_budget_micro_seconds().
+const budgetReadBackOverhead = 1910 - 1320
+
+// Property: for any valid VCSEL periods, sequence steps and timing budget,
+// budget read back from registers differs from requested one only by
+// overhead difference and quantization of final range timeout: it's truncated
+// by timeout encoding (8 significant bits, so below 1/128 of the timeout)
+// and rounded to the nearest macro period (below 1 MCLK each way).
+func TestTimingBudgetReadBack(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	sequenceConfigs := []byte{
+		defaultSequenceConfig,
+		0xE8 | 0x10, // with TCC
+		0xC0 | 0x04, // MSRC instead of DSS
+		0xC0 | 0x14, // MSRC and TCC
+		0x80,        // final range only
+	}
+	checked := 0
+	for pre := uint8(12); pre <= 18; pre += 2 {
+		for final := uint8(8); final <= 14; final += 2 {
+			bus := newTraceBus()
+			v := NewVl53l0x()
+			err := v.Init(bus)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = v.SetVcselPulsePeriod(bus, VcselPeriodPreRange, pre)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = v.SetVcselPulsePeriod(bus, VcselPeriodFinalRange, final)
+			if err != nil {
+				t.Fatal(err)
+			}
+			macroPeriodUsec := float64(v.calcMacroPeriod(uint16(final))) / 1000
+			for _, seq := range sequenceConfigs {
+				err = v.writeRegU8(bus, SYSTEM_SEQUENCE_CONFIG, seq)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i := 0; i < 20; i++ {
+					budget := 20000 + rnd.Uint32N(3000000)
+					err = v.SetMeasurementTimingBudget(bus, budget)
+					var rangeErr *TimeoutRangeError
+					if errors.As(err, &rangeErr) {
+						// too long for long VCSEL periods
+						continue
+					}
+					if err != nil {
+						t.Fatal(err)
+					}
+					got, err := v.GetMeasurementTimingBudget(bus)
+					if err != nil {
+						t.Fatal(err)
+					}
+					diff := float64(got) - float64(budget) - budgetReadBackOverhead
+					// final range timeout is below the whole budget
+					if diff > macroPeriodUsec || diff < -(float64(budget)/128+macroPeriodUsec) {
+						t.Errorf("periods %d/%d, sequence 0x%x: budget %d us read back as %d us",
+							pre, final, seq, budget, got)
+					}
+					checked++
+				}
+			}
+		}
+	}
+	if checked < 500 {
+		t.Errorf("only %d configurations checked", checked)
+	}
+}
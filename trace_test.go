@@ -0,0 +1,291 @@
+package vl53l0x
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Golden register traces in testdata are register write sequences of the
+// same operations in VL53L0X.cpp of Pololu vl53l0x-arduino library, which
+// driver is ported from, written down along with reference source. Writes
+// are "<register> <value>" lines in hex, "#" starts comment. Intentional
+// differences are documented inline: line marked "-" is written by
+// reference only, line marked "+" by driver only. Update traces by hand
+// after intended changes, marking and explaining each new difference.
+
+// Bus over simulated sensor, recording register writes. It implements
+// plain Bus only, so multi-byte writes are expanded by auto-increment
+// into single register writes, to be comparable with the reference
+// regardless of transaction merging.
+type traceBus struct {
+	sim   *SimulatedSensor
+	trace bytes.Buffer
+}
+
+func newTraceBus() *traceBus {
+	v := &traceBus{sim: NewSimulatedSensor(ConstantScenario(500))}
+	return v
+}
+
+func (v *traceBus) ReadRegU8(reg byte) (byte, error) {
+	return v.sim.ReadRegU8(reg)
+}
+
+func (v *traceBus) WriteRegU8(reg byte, value byte) error {
+	fmt.Fprintf(&v.trace, "%02x %02x\n", reg, value)
+	return v.sim.WriteRegU8(reg, value)
+}
+
+func (v *traceBus) ReadBytes(buf []byte) (int, error) {
+	return v.sim.ReadBytes(buf)
+}
+
+func (v *traceBus) WriteBytes(buf []byte) (int, error) {
+	// single byte is register index of following read
+	for i, b := range buf[1:] {
+		fmt.Fprintf(&v.trace, "%02x %02x\n", buf[0]+byte(i), b)
+	}
+	return v.sim.WriteBytes(buf)
+}
+
+// Create initialized sensor on trace bus with empty trace.
+func initTraceSensor(t *testing.T) (*Vl53l0x, *traceBus) {
+	t.Helper()
+	bus := newTraceBus()
+	v := NewVl53l0x()
+	err := v.Init(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus.trace.Reset()
+	return v, bus
+}
+
+// Read golden file testdata/<name>.trace and return writes
+// expected from driver along with their line numbers.
+func readGoldenTrace(t *testing.T, name string) ([]string, []int) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name+".trace"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var writes []string
+	var lines []int
+	for i, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "+"))
+		writes = append(writes, line)
+		lines = append(lines, i+1)
+	}
+	return writes, lines
+}
+
+// Compare trace with golden file testdata/<name>.trace.
+func checkTrace(t *testing.T, name string, bus *traceBus) {
+	t.Helper()
+	want, lines := readGoldenTrace(t, name)
+	got := strings.Split(strings.TrimSuffix(bus.trace.String(), "\n"), "\n")
+	for i := range min(len(got), len(want)) {
+		if got[i] != want[i] {
+			t.Fatalf("write %d: got %q, want %q (%s.trace:%d)",
+				i+1, got[i], want[i], name, lines[i])
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d writes, want %d", len(got), len(want))
+	}
+}
+
+func TestInitTrace(t *testing.T) {
+	bus := newTraceBus()
+	v := NewVl53l0x()
+	err := v.Init(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTrace(t, "init", bus)
+	if v.stopVariable != 0x3C {
+		t.Errorf("stop variable 0x%x, want 0x3c", v.stopVariable)
+	}
+	if v.refSpadInfo != (SpadInfo{Count: 5, TypeIsAperture: true}) {
+		t.Errorf("SPAD info %+v, want 5 aperture SPADs", v.refSpadInfo)
+	}
+}
+
+func TestConfigTrace(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	err := v.Config(bus, LongRange, HighAccuracy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTrace(t, "config_long_range_high_accuracy", bus)
+	if v.measurementTimingBudgetUsec != 100000 {
+		t.Errorf("timing budget %d us, want 100000", v.measurementTimingBudgetUsec)
+	}
+}
+
+func TestSetVcselPulsePeriodTrace(t *testing.T) {
+	tests := []struct {
+		name   string
+		tpe    VcselPeriodType
+		period uint8
+	}{
+		{"vcsel_pre_range_18", VcselPeriodPreRange, 18},
+		{"vcsel_final_range_14", VcselPeriodFinalRange, 14},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, bus := initTraceSensor(t)
+			err := v.SetVcselPulsePeriod(bus, test.tpe, test.period)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTrace(t, test.name, bus)
+		})
+	}
+}
+
+func TestSingleMeasurementTrace(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	m, err := v.ReadMeasurementSingle(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTrace(t, "single_measurement", bus)
+	if !m.Valid() || m.RangeMillimeters != 500 {
+		t.Errorf("got %d mm, status %v, want valid 500 mm", m.RangeMillimeters, m.Status)
+	}
+}
+
+func TestContinuousTrace(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	err := v.StartContinuous(bus, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err = v.ReadMeasurementContinuous(bus)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = v.StopContinuous(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTrace(t, "continuous", bus)
+}
+
+func TestTimeoutEncoding(t *testing.T) {
+	v := NewVl53l0x()
+	tests := []struct {
+		mclks   uint16
+		encoded uint16
+	}{
+		{1, 0x0000},
+		{2, 0x0001},
+		{256, 0x00FF},
+		{257, 0x0180},
+		{1000, 0x02F9},
+	}
+	for _, test := range tests {
+		encoded := v.encodeTimeout(test.mclks)
+		if encoded != test.encoded {
+			t.Errorf("encodeTimeout(%d) = 0x%04x, want 0x%04x", test.mclks, encoded, test.encoded)
+		}
+		decoded := v.decodeTimeout(encoded)
+		if decoded > test.mclks || test.mclks-decoded > test.mclks/128 {
+			t.Errorf("decodeTimeout(0x%04x) = %d, want about %d", encoded, decoded, test.mclks)
+		}
+	}
+}
+
+func TestDecodeRangeStatus(t *testing.T) {
+	tests := []struct {
+		device byte
+		rng    uint16
+		want   RangeStatus
+	}{
+		{11, 500, RangeValid},
+		{11, 8190, OutOfRange},
+		{4, 8190, OutOfRange},
+		{4, 1200, SignalFail},
+		{6, 100, PhaseFail},
+		{9, 100, PhaseFail},
+		{8, 20, MinRangeFail},
+		{10, 20, MinRangeFail},
+		{1, 8190, HardwareFail},
+		{3, 100, HardwareFail},
+		{0, 100, RangeStatusNone},
+	}
+	for _, test := range tests {
+		got := decodeRangeStatus(test.device, test.rng)
+		if got != test.want {
+			t.Errorf("decodeRangeStatus(%d, %d) = %v, want %v", test.device, test.rng, got, test.want)
+		}
+	}
+}
+
+func TestParseResult(t *testing.T) {
+	v := NewVl53l0x()
+	buf := &[resultBlockSize]byte{0x58, 0x00, 0x04, 0x00, 0x00, 0x00, 0x0a, 0x0c, 0x00, 0x0d, 0x00, 0x96}
+	var m Measurement
+	v.parseResult(&m, buf, Measurement{}.Timestamp, 33000)
+	if m.Status != RangeValid || m.RangeMillimeters != 150 {
+		t.Errorf("got %d mm, status %v, want valid 150 mm", m.RangeMillimeters, m.Status)
+	}
+	if m.SignalRateMcps != float32(0x0a0c)/128 || m.AmbientRateMcps != float32(0x0d)/128 {
+		t.Errorf("got rates %v/%v MCPS", m.SignalRateMcps, m.AmbientRateMcps)
+	}
+	if m.EffectiveSpadCount != 4 {
+		t.Errorf("got %v effective SPADs, want 4", m.EffectiveSpadCount)
+	}
+}
+
+func TestTimingBudgetOutOfRange(t *testing.T) {
+	v, bus := initTraceSensor(t)
+	err := v.SetMeasurementTimingBudget(bus, 5000000)
+	var rangeErr *TimeoutRangeError
+	if !errors.As(err, &rangeErr) {
+		t.Fatalf("got error %v, want *TimeoutRangeError", err)
+	}
+	if bus.trace.Len() != 0 {
+		t.Errorf("timeout registers written:\n%s", bus.trace.String())
+	}
+	if v.measurementTimingBudgetUsec == 5000000 {
+		t.Error("rejected timing budget is stored")
+	}
+}
+
+func TestSetVcselPulsePeriodInvalid(t *testing.T) {
+	tests := []struct {
+		tpe    VcselPeriodType
+		period uint8
+	}{
+		{VcselPeriodPreRange, 10},
+		{VcselPeriodPreRange, 15},
+		{VcselPeriodPreRange, 20},
+		{VcselPeriodFinalRange, 6},
+		{VcselPeriodFinalRange, 16},
+		{VcselPeriodType(0), 14},
+	}
+	for _, test := range tests {
+		v, bus := initTraceSensor(t)
+		err := v.SetVcselPulsePeriod(bus, test.tpe, test.period)
+		if !errors.Is(err, ErrInvalidVcselPeriod) {
+			t.Errorf("%v %d: got error %v, want ErrInvalidVcselPeriod", test.tpe, test.period, err)
+		}
+		if bus.trace.Len() != 0 {
+			t.Errorf("%v %d: registers written", test.tpe, test.period)
+		}
+	}
+}
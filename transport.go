@@ -0,0 +1,67 @@
+package vl53l0x
+
+import (
+	"errors"
+
+	i2c "github.com/d2r2/go-i2c"
+)
+
+// Bus is a connection to the sensor over I2C-bus. *i2c.I2C from
+// github.com/d2r2/go-i2c implements it; other transports (periph.io,
+// bit-banged buses, test fakes) could be plugged in with adapters.
+type Bus interface {
+	ReadRegU8(reg byte) (byte, error)
+	WriteRegU8(reg byte, value byte) error
+	ReadBytes(buf []byte) (int, error)
+	WriteBytes(buf []byte) (int, error)
+}
+
+// AddressableBus is implemented by Bus, which could open connection
+// to another address on the same physical bus. It's required to restore
+// address assigned by SetAddress, when sensor loses it after power loss.
+type AddressableBus interface {
+	Bus
+	OpenAddress(addr byte) (Bus, error)
+}
+
+// OpenAddress opens connection to another address on the same bus as b,
+// which should be *i2c.I2C or implement AddressableBus. Returned connection
+// should be closed, if it implements io.Closer.
+func OpenAddress(b Bus, addr byte) (Bus, error) {
+	switch conn := b.(type) {
+	case *i2c.I2C:
+		return newI2C(addr, conn.GetBus())
+	case AddressableBus:
+		return conn.OpenAddress(addr)
+	default:
+		return nil, errors.New("bus doesn't support connection to another address")
+	}
+}
+
+// BatchBus is implemented by Bus, which could perform several write
+// transactions in single call, for instance with Linux I2C_RDWR ioctl,
+// saving per-transaction overhead on long register sequences, such as
+// tuning settings written by Init. Each message is register index
+// followed by data, written in order.
+type BatchBus interface {
+	Bus
+	WriteBatch(msgs [][]byte) error
+}
+
+// RegisterReader is implemented by Bus, which could write register index
+// and read data in single combined transaction with repeated start
+// condition, for instance with Linux I2C_RDWR ioctl. Some clock-stretching
+// setups and I2C bridges misbehave, when register index write and data
+// read are separate transactions; combined one is also faster.
+type RegisterReader interface {
+	Bus
+	ReadReg(reg byte, buf []byte) error
+}
+
+// Close connection, if it supports closing.
+func closeBus(b Bus) error {
+	if c, ok := b.(interface{ Close() error }); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package vl53l0x
+
+// DefaultTuningSettings from vl53l0x_tuning.h of ST VL53L0X API, written
+// by Init. Register 0xFF selects register page, so the order of writes
+// is kept; consecutive registers are merged to multi-byte transactions
+// by writeRegValues.
+var defaultTuningSettings = []RegBytePair{
+	{Reg: 0xFF, Value: 0x01},
+	{Reg: 0x00, Value: 0x00},
+
+	{Reg: 0xFF, Value: 0x00},
+	{Reg: 0x09, Value: 0x00},
+	{Reg: 0x10, Value: 0x00},
+	{Reg: 0x11, Value: 0x00},
+	{Reg: 0x24, Value: 0x01},
+	{Reg: 0x25, Value: 0xFF},
+	{Reg: 0x75, Value: 0x00},
+
+	{Reg: 0xFF, Value: 0x01},
+	{Reg: 0x4E, Value: 0x2C},
+	{Reg: 0x48, Value: 0x00},
+	{Reg: 0x30, Value: 0x20},
+
+	{Reg: 0xFF, Value: 0x00},
+	{Reg: 0x30, Value: 0x09},
+	{Reg: 0x54, Value: 0x00},
+	{Reg: 0x31, Value: 0x04},
+	{Reg: 0x32, Value: 0x03},
+	{Reg: 0x40, Value: 0x83},
+	{Reg: 0x46, Value: 0x25},
+	{Reg: 0x60, Value: 0x00},
+	{Reg: 0x27, Value: 0x00},
+	{Reg: 0x50, Value: 0x06},
+	{Reg: 0x51, Value: 0x00},
+	{Reg: 0x52, Value: 0x96},
+	{Reg: 0x56, Value: 0x08},
+	{Reg: 0x57, Value: 0x30},
+	{Reg: 0x61, Value: 0x00},
+	{Reg: 0x62, Value: 0x00},
+	{Reg: 0x64, Value: 0x00},
+	{Reg: 0x65, Value: 0x00},
+	{Reg: 0x66, Value: 0xA0},
+
+	{Reg: 0xFF, Value: 0x01},
+	{Reg: 0x22, Value: 0x32},
+	{Reg: 0x47, Value: 0x14},
+	{Reg: 0x49, Value: 0xFF},
+	{Reg: 0x4A, Value: 0x00},
+
+	{Reg: 0xFF, Value: 0x00},
+	{Reg: 0x7A, Value: 0x0A},
+	{Reg: 0x7B, Value: 0x00},
+	{Reg: 0x78, Value: 0x21},
+
+	{Reg: 0xFF, Value: 0x01},
+	{Reg: 0x23, Value: 0x34},
+	{Reg: 0x42, Value: 0x00},
+	{Reg: 0x44, Value: 0xFF},
+	{Reg: 0x45, Value: 0x26},
+	{Reg: 0x46, Value: 0x05},
+	{Reg: 0x40, Value: 0x40},
+	{Reg: 0x0E, Value: 0x06},
+	{Reg: 0x20, Value: 0x1A},
+	{Reg: 0x43, Value: 0x40},
+
+	{Reg: 0xFF, Value: 0x00},
+	{Reg: 0x34, Value: 0x03},
+	{Reg: 0x35, Value: 0x44},
+
+	{Reg: 0xFF, Value: 0x01},
+	{Reg: 0x31, Value: 0x04},
+	{Reg: 0x4B, Value: 0x09},
+	{Reg: 0x4C, Value: 0x05},
+	{Reg: 0x4D, Value: 0x04},
+
+	{Reg: 0xFF, Value: 0x00},
+	{Reg: 0x44, Value: 0x00},
+	{Reg: 0x45, Value: 0x20},
+	{Reg: 0x47, Value: 0x08},
+	{Reg: 0x48, Value: 0x28},
+	{Reg: 0x67, Value: 0x00},
+	{Reg: 0x70, Value: 0x04},
+	{Reg: 0x71, Value: 0x01},
+	{Reg: 0x72, Value: 0xFE},
+	{Reg: 0x76, Value: 0x00},
+	{Reg: 0x77, Value: 0x00},
+
+	{Reg: 0xFF, Value: 0x01},
+	{Reg: 0x0D, Value: 0x01},
+
+	{Reg: 0xFF, Value: 0x00},
+	{Reg: 0x80, Value: 0x01},
+	{Reg: 0x01, Value: 0xF8},
+
+	{Reg: 0xFF, Value: 0x01},
+	{Reg: 0x8E, Value: 0x01},
+	{Reg: 0x00, Value: 0x01},
+
+	{Reg: 0xFF, Value: 0x00},
+	{Reg: 0x80, Value: 0x00},
+}
@@ -0,0 +1,130 @@
+package vl53l0x
+
+import (
+	"cmp"
+	"strings"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// ConfigMismatch describes single configuration value,
+// which differs from the one written by driver.
+type ConfigMismatch struct {
+	// configuration item, for instance "signal rate limit"
+	Name string
+	// register and its expected and actual values
+	Reg      byte
+	Expected uint16
+	Actual   uint16
+}
+
+// ConfigMismatchError returned by VerifyConfig, when sensor configuration
+// differs from the one written by driver. Usually it means, that sensor
+// silently lost writes due to marginal bus wiring (weak pull-ups, long wires),
+// or was reset by power glitch.
+type ConfigMismatchError struct {
+	Mismatches []ConfigMismatch
+}
+
+// Error implement error interface.
+func (e *ConfigMismatchError) Error() string {
+	var b strings.Builder
+	b.WriteString("configuration verification failed:")
+	for i, m := range e.Mismatches {
+		if i > 0 {
+			b.WriteString(";")
+		}
+		b.WriteString(spew.Sprintf(" %s (register 0x%x) expected 0x%x, actual 0x%x",
+			m.Name, m.Reg, m.Expected, m.Actual))
+	}
+	return b.String()
+}
+
+// Sequence steps enabled by Init: DSS, pre-range and final range.
+const defaultSequenceConfig = 0xE8
+
+// Default VCSEL pulse periods in PCLKs set by Init.
+const (
+	defaultPreRangeVcselPeriod   = 14
+	defaultFinalRangeVcselPeriod = 10
+)
+
+// SetConfigVerification enable or disable verification of sensor
+// configuration by VerifyConfig at the end of Init and Config (and so
+// ApplyProfile). Unlike strict mode, which verifies every write, it reads
+// back only few key registers, so it's cheap enough for production use.
+func (v *Vl53l0x) SetConfigVerification(verify bool) {
+	v.verifyConfig = verify
+}
+
+// VerifyConfig read back key registers (signal rate limit, VCSEL pulse
+// periods, sequence config and interrupt config) and compare them with
+// values written by driver. Returns *ConfigMismatchError listing all
+// differences, if any.
+func (v *Vl53l0x) VerifyConfig(i2c Bus) error {
+	var mismatches []ConfigMismatch
+	check := func(name string, reg byte, expected, actual uint16) {
+		if expected != actual {
+			mismatches = append(mismatches, ConfigMismatch{Name: name, Reg: reg,
+				Expected: expected, Actual: actual})
+		}
+	}
+
+	u16, err := v.readRegU16(i2c, FINAL_RANGE_CONFIG_MIN_COUNT_RATE_RTN_LIMIT)
+	if err != nil {
+		return err
+	}
+	// Q9.7 fixed point format, as written by SetSignalRateLimit
+	check("signal rate limit", FINAL_RANGE_CONFIG_MIN_COUNT_RATE_RTN_LIMIT,
+		uint16(v.settings.signalRateLimit*(1<<7)), u16)
+
+	for _, period := range []struct {
+		name     string
+		reg      byte
+		expected uint8
+	}{
+		{"pre-range VCSEL period", PRE_RANGE_CONFIG_VCSEL_PERIOD,
+			cmp.Or(v.settings.preRangeVcselPeriod, defaultPreRangeVcselPeriod)},
+		{"final range VCSEL period", FINAL_RANGE_CONFIG_VCSEL_PERIOD,
+			cmp.Or(v.settings.finalRangeVcselPeriod, defaultFinalRangeVcselPeriod)},
+	} {
+		u8, err := v.readRegU8(i2c, period.reg)
+		if err != nil {
+			return err
+		}
+		check(period.name, period.reg, uint16(v.encodeVcselPeriod(period.expected)), uint16(u8))
+	}
+
+	u8, err := v.readRegU8(i2c, SYSTEM_SEQUENCE_CONFIG)
+	if err != nil {
+		return err
+	}
+	check("sequence config", SYSTEM_SEQUENCE_CONFIG, defaultSequenceConfig, uint16(u8))
+
+	u8, err = v.readRegU8(i2c, SYSTEM_INTERRUPT_CONFIG_GPIO)
+	if err != nil {
+		return err
+	}
+	check("interrupt config", SYSTEM_INTERRUPT_CONFIG_GPIO, 0x04, uint16(u8))
+
+	u8, err = v.readRegU8(i2c, GPIO_HV_MUX_ACTIVE_HIGH)
+	if err != nil {
+		return err
+	}
+	// only polarity bit is written by driver: active low
+	check("interrupt polarity", GPIO_HV_MUX_ACTIVE_HIGH, 0, uint16(u8&0x10))
+
+	if len(mismatches) > 0 {
+		warnf("Configuration verification failed: %d mismatches", len(mismatches))
+		return &ConfigMismatchError{Mismatches: mismatches}
+	}
+	return nil
+}
+
+// Verify configuration, if enabled by SetConfigVerification.
+func (v *Vl53l0x) verifyConfigIfEnabled(i2c Bus) error {
+	if !v.verifyConfig {
+		return nil
+	}
+	return v.VerifyConfig(i2c)
+}
@@ -0,0 +1,33 @@
+package vl53l0x
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifyConfig(t *testing.T) {
+	bus := newTraceBus()
+	v := NewVl53l0x()
+	v.SetConfigVerification(true)
+	err := v.Init(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = v.Config(bus, LongRange, HighSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// lost write of signal rate limit
+	err = bus.sim.WriteRegU8(FINAL_RANGE_CONFIG_MIN_COUNT_RATE_RTN_LIMIT+1, 0x20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = v.VerifyConfig(bus)
+	var mismatchErr *ConfigMismatchError
+	if !errors.As(err, &mismatchErr) {
+		t.Fatalf("got error %v, want *ConfigMismatchError", err)
+	}
+	if len(mismatchErr.Mismatches) != 1 || mismatchErr.Mismatches[0].Name != "signal rate limit" {
+		t.Errorf("got mismatches %+v, want signal rate limit only", mismatchErr.Mismatches)
+	}
+}
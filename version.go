@@ -0,0 +1,48 @@
+package vl53l0x
+
+import "github.com/davecgh/go-spew/spew"
+
+// Driver version and revision of the tuning table embedded to Init().
+// Tuning table is DefaultTuningSettings from vl53l0x_tuning.h
+// of ST VL53L0X API, so it's versioned with API implementation numbers.
+const (
+	DriverVersion = "1.1.0"
+
+	tuningVerMajor    = 1
+	tuningVerMinor    = 0
+	tuningVerBuild    = 2
+	tuningVerRevision = 4823
+
+	palSpecVerMajor    = 1
+	palSpecVerMinor    = 2
+	palSpecVerBuild    = 7
+	palSpecVerRevision = 1440
+)
+
+// VersionInfo keeps versions of the driver, the ST API the embedded
+// tuning table was taken from (VL53L0X_GetVersion()), and PAL specification
+// the driver follows (VL53L0X_GetPalSpecVersion()).
+type VersionInfo struct {
+	Driver  string
+	Tuning  string
+	PalSpec string
+}
+
+// String implement Stringer interface.
+func (v VersionInfo) String() string {
+	return spew.Sprintf("driver %s, tuning %s, PAL spec %s",
+		v.Driver, v.Tuning, v.PalSpec)
+}
+
+// Version returns driver version with the revision of embedded
+// tuning table, so it's possible to report which driver/tuning
+// combination produced measurement data.
+func Version() VersionInfo {
+	return VersionInfo{
+		Driver: DriverVersion,
+		Tuning: spew.Sprintf("%d.%d.%d.%d", tuningVerMajor, tuningVerMinor,
+			tuningVerBuild, tuningVerRevision),
+		PalSpec: spew.Sprintf("%d.%d.%d.%d", palSpecVerMajor, palSpecVerMinor,
+			palSpecVerBuild, palSpecVerRevision),
+	}
+}
@@ -37,6 +37,8 @@ package vl53l0x
 
 import (
 	"errors"
+	"math"
+	"sync/atomic"
 	"time"
 
 	i2c "github.com/d2r2/go-i2c"
@@ -138,6 +140,68 @@ const (
 	VcselPeriodFinalRange
 )
 
+// String implement Stringer interface.
+func (v VcselPeriodType) String() string {
+	switch v {
+	case VcselPeriodPreRange:
+		return "VcselPeriodPreRange"
+	case VcselPeriodFinalRange:
+		return "VcselPeriodFinalRange"
+	default:
+		return "<unknown>"
+	}
+}
+
+// Returns valid range of VCSEL pulse period in PCLKs;
+// valid values are even numbers only.
+func (v VcselPeriodType) pclksRange() (uint8, uint8, bool) {
+	switch v {
+	case VcselPeriodPreRange:
+		return 12, 18, true
+	case VcselPeriodFinalRange:
+		return 8, 14, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// ErrInvalidVcselPeriod is matched by errors.Is for *VcselPeriodError.
+var ErrInvalidVcselPeriod = errors.New("invalid VCSEL pulse period")
+
+// VcselPeriodError returned by SetVcselPulsePeriod, when requested period
+// isn't valid for period type given, before any register is written.
+// Min and Max are the allowed range of even values; they are zero
+// for unknown period type.
+type VcselPeriodError struct {
+	Type  VcselPeriodType
+	Pclks uint8
+	Min   uint8
+	Max   uint8
+}
+
+// Error implement error interface.
+func (e *VcselPeriodError) Error() string {
+	if e.Min == 0 {
+		return spew.Sprintf("%s: unknown VCSEL period type %d", ErrInvalidVcselPeriod, e.Type)
+	}
+	return spew.Sprintf("%s: %d PCLKs of %s, expected even value from %d to %d",
+		ErrInvalidVcselPeriod, e.Pclks, e.Type, e.Min, e.Max)
+}
+
+// Unwrap returns ErrInvalidVcselPeriod.
+func (e *VcselPeriodError) Unwrap() error {
+	return ErrInvalidVcselPeriod
+}
+
+// Check VCSEL pulse period against valid range of period type.
+func validateVcselPeriod(tpe VcselPeriodType, periodPclks uint8) error {
+	lo, hi, ok := tpe.pclksRange()
+	if !ok || periodPclks < lo || periodPclks > hi || periodPclks%2 != 0 {
+		return &VcselPeriodError{Type: tpe, Pclks: periodPclks, Min: lo, Max: hi}
+	}
+	return nil
+}
+
 // RangeSpec used to configure sensor for expected distance to measure.
 type RangeSpec int
 
@@ -146,7 +210,7 @@ const (
 	RegularRange RangeSpec = iota + 1
 	// Signal rate limit = 0.10 MCPS, laser pulse periods = (18, 14).
 	// Use "long range" mode only when "regular" can't detect distance
-	// (returned distance value is 8190 mm or more). It's ordinary
+	// (measurement status is OutOfRange). It's ordinary
 	// happens, when distance exceed something about a meter.
 	LongRange
 )
@@ -208,18 +272,68 @@ type Vl53l0x struct {
 	measurementTimingBudgetUsec uint32
 	// default timeout value
 	ioTimeout time.Duration
+	// read back and compare every register write
+	strict bool
+	// handlers registered by OnMeasurement and OnError
+	handlers subscription
+	// optional GPIO1 data ready interrupt line
+	interrupt InterruptWaiter
+	// settings applied by user, to be restored on re-initialization
+	settings appliedSettings
+	// number of recovery attempts on measurement failure
+	recoveryAttempts int
+	// warm-up samples discarding policy
+	warmUp warmUp
+	// good SPAD map read by Init and reference SPADs currently enabled
+	goodSpadMap [6]byte
+	refSpadInfo SpadInfo
+	// range ignore threshold in MCPS, checked by software
+	rangeIgnoreThreshold float32
+	// piecewise-linear distance correction
+	correction *CorrectionTable
+	// mounting angle in degrees and its cosine
+	mountingAngle float64
+	mountingCos   float64
+	// I2C-bus transaction counters
+	io ioCounters
+	// optional session recorder
+	recorder *SessionWriter
+	// scratch buffers of I2C-bus transactions, so hot read path doesn't
+	// allocate; driver instance isn't safe for concurrent use anyway
+	regBuf    [1]byte
+	ioBuf     [5]byte
+	resultBuf [resultBlockSize + 1]byte
+	// moment when current measurement started, used to sleep
+	// through timing budget instead of polling for data ready
+	rangingStarted time.Time
+	// measurements dropped by Stream on slow consumer
+	streamDropped atomic.Uint64
+	// what Stream does on slow consumer
+	streamPolicy BackpressurePolicy
+	// optional register transaction trace
+	regTrace *registerTrace
+	// verify configuration after Init and Config
+	verifyConfig bool
+	// unexpected sensor reset detection by canary register
+	resetDetection resetDetection
+	// limit of continuous mode session and its state
+	rangingLimit RangingLimit
+	limitState   rangingLimitState
 }
 
+// Default timeout for operations which could hang, waiting for sensor response.
+const defaultIoTimeout = time.Millisecond * 1000
+
 // NewVl53l0x creates sensor instance.
 func NewVl53l0x() *Vl53l0x {
-	v := &Vl53l0x{}
+	v := &Vl53l0x{ioTimeout: defaultIoTimeout}
 	return v
 }
 
 // Config configure sensor expected distance range and time to make a measurement.
-func (v *Vl53l0x) Config(i2c *i2c.I2C, rng RangeSpec, speed SpeedAccuracySpec) error {
+func (v *Vl53l0x) Config(i2c Bus, rng RangeSpec, speed SpeedAccuracySpec) error {
 
-	lg.Debug("Start config")
+	debug("Start config")
 
 	switch rng {
 	case RegularRange:
@@ -287,22 +401,22 @@ func (v *Vl53l0x) Config(i2c *i2c.I2C, rng RangeSpec, speed SpeedAccuracySpec) e
 		}
 	}
 
-	lg.Debug("End config")
+	debug("End config")
 
-	return nil
+	return v.verifyConfigIfEnabled(i2c)
 }
 
 // Reset soft-reset the sensor.
 // Based on VL53L0X_ResetDevice().
-func (v *Vl53l0x) Reset(i2c *i2c.I2C) error {
+func (v *Vl53l0x) Reset(i2c Bus) error {
 	// Set reset bit
-	lg.Debug("Set reset bit")
+	debug("Set reset bit")
 	err := v.writeRegU8(i2c, SOFT_RESET_GO2_SOFT_RESET_N, 0x00)
 	if err != nil {
 		return err
 	}
 	// Wait for some time
-	err = v.waitUntilOrTimeout(i2c, IDENTIFICATION_MODEL_ID,
+	err = v.waitUntilOrTimeout(i2c, WaitResetEnter, IDENTIFICATION_MODEL_ID,
 		func(checkReg byte, err error) (bool, error) {
 			return checkReg == 0, err
 		})
@@ -310,13 +424,13 @@ func (v *Vl53l0x) Reset(i2c *i2c.I2C) error {
 		return err
 	}
 	// Release reset
-	lg.Debug("Release reset bit")
+	debug("Release reset bit")
 	err = v.writeRegU8(i2c, SOFT_RESET_GO2_SOFT_RESET_N, 0x01)
 	if err != nil {
 		return err
 	}
 	// Wait for some time
-	err = v.waitUntilOrTimeout(i2c, IDENTIFICATION_MODEL_ID,
+	err = v.waitUntilOrTimeout(i2c, WaitResetExit, IDENTIFICATION_MODEL_ID,
 		func(checkReg byte, err error) (bool, error) {
 			// Skip error like "read /dev/i2c-x: no such device or address"
 			// for a while, because sensor in reboot has temporary
@@ -332,7 +446,7 @@ func (v *Vl53l0x) Reset(i2c *i2c.I2C) error {
 
 // GetProductMinorRevision takes revision from sensor hardware.
 // Based on VL53L0X_GetProductRevision.
-func (v *Vl53l0x) GetProductMinorRevision(i2c *i2c.I2C) (byte, error) {
+func (v *Vl53l0x) GetProductMinorRevision(i2c Bus) (byte, error) {
 	u8, err := v.readRegU8(i2c, IDENTIFICATION_REVISION_ID)
 	if err != nil {
 		return 0, err
@@ -346,6 +460,7 @@ func (v *Vl53l0x) SetAddress(i2cRef **i2c.I2C, newAddr byte) error {
 	if err != nil {
 		return err
 	}
+	v.settings.address = newAddr & 0x7F
 	*i2cRef, err = i2c.NewI2C(newAddr, (*i2cRef).GetBus())
 	return err
 }
@@ -356,9 +471,7 @@ func (v *Vl53l0x) SetAddress(i2cRef **i2c.I2C, newAddr byte) error {
 // (VL53L0X_PerformRefSpadManagement()), since the API user manual says that it
 // is performed by ST on the bare modules; it seems like that should work well
 // enough unless a cover glass is added.
-func (v *Vl53l0x) Init(i2c *i2c.I2C) error {
-
-	v.setTimeout(time.Millisecond * 1000)
+func (v *Vl53l0x) Init(i2c Bus) error {
 
 	// VL53L0X_DataInit() begin
 
@@ -430,36 +543,8 @@ func (v *Vl53l0x) Init(i2c *i2c.I2C) error {
 
 	// -- VL53L0X_set_reference_spads() begin (assume NVM values are valid)
 
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x01},
-		{Reg: DYNAMIC_SPAD_REF_EN_START_OFFSET, Value: 0x00},
-		{Reg: DYNAMIC_SPAD_NUM_REQUESTED_REF_SPAD, Value: 0x2C},
-		{Reg: 0xFF, Value: 0x00},
-		{Reg: GLOBAL_CONFIG_REF_EN_START_SELECT, Value: 0xB4},
-	}...)
-	if err != nil {
-		return err
-	}
-
-	var firstSpadToEnable byte
-	if spadInfo.TypeIsAperture {
-		// 12 is the first aperture spad
-		firstSpadToEnable = 12
-	}
-	var spadsEnabled byte
-
-	var i byte
-	for i = 0; i < 48; i++ {
-		if i < firstSpadToEnable || spadsEnabled == spadInfo.Count {
-			// This bit is lower than the first one that should be enabled, or
-			// (reference_spad_count) bits have already been enabled, so zero this bit
-			spadMap[i/8] &= ^(1 << (i % 8))
-		} else if (spadMap[i/8]>>(i%8))&0x1 != 0 {
-			spadsEnabled++
-		}
-	}
-
-	err = v.writeBytes(i2c, GLOBAL_CONFIG_SPAD_ENABLES_REF_0, spadMap)
+	copy(v.goodSpadMap[:], spadMap)
+	err = v.setReferenceSpads(i2c, *spadInfo)
 	if err != nil {
 		return err
 	}
@@ -467,182 +552,63 @@ func (v *Vl53l0x) Init(i2c *i2c.I2C) error {
 	// -- VL53L0X_set_reference_spads() end
 
 	// -- VL53L0X_load_tuning_settings() begin
-	// DefaultTuningSettings from vl53l0x_tuning.h
-
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x01},
-		{Reg: 0x00, Value: 0x00},
-	}...)
-	if err != nil {
-		return err
-	}
-
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x00},
-		{Reg: 0x09, Value: 0x00},
-		{Reg: 0x10, Value: 0x00},
-		{Reg: 0x11, Value: 0x00},
-	}...)
-	if err != nil {
-		return err
-	}
-
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0x24, Value: 0x01},
-		{Reg: 0x25, Value: 0xFF},
-		{Reg: 0x75, Value: 0x00},
-	}...)
-	if err != nil {
-		return err
-	}
-
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x01},
-		{Reg: 0x4E, Value: 0x2C},
-		{Reg: 0x48, Value: 0x00},
-		{Reg: 0x30, Value: 0x20},
-	}...)
-	if err != nil {
-		return err
-	}
 
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x00},
-		{Reg: 0x30, Value: 0x09},
-		{Reg: 0x54, Value: 0x00},
-		{Reg: 0x31, Value: 0x04},
-		{Reg: 0x32, Value: 0x03},
-		{Reg: 0x40, Value: 0x83},
-		{Reg: 0x46, Value: 0x25},
-		{Reg: 0x60, Value: 0x00},
-		{Reg: 0x27, Value: 0x00},
-		{Reg: 0x50, Value: 0x06},
-		{Reg: 0x51, Value: 0x00},
-		{Reg: 0x52, Value: 0x96},
-		{Reg: 0x56, Value: 0x08},
-		{Reg: 0x57, Value: 0x30},
-		{Reg: 0x61, Value: 0x00},
-		{Reg: 0x62, Value: 0x00},
-		{Reg: 0x64, Value: 0x00},
-		{Reg: 0x65, Value: 0x00},
-		{Reg: 0x66, Value: 0xA0},
-	}...)
+	err = v.writeRegValues(i2c, defaultTuningSettings...)
 	if err != nil {
 		return err
 	}
 
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x01},
-		{Reg: 0x22, Value: 0x32},
-		{Reg: 0x47, Value: 0x14},
-		{Reg: 0x49, Value: 0xFF},
-		{Reg: 0x4A, Value: 0x00},
-	}...)
-	if err != nil {
-		return err
-	}
+	// -- VL53L0X_load_tuning_settings() end
 
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x00},
-		{Reg: 0x7A, Value: 0x0A},
-		{Reg: 0x7B, Value: 0x00},
-		{Reg: 0x78, Value: 0x21},
-	}...)
+	err = v.setGpioConfig(i2c)
 	if err != nil {
 		return err
 	}
 
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x01},
-		{Reg: 0x23, Value: 0x34},
-		{Reg: 0x42, Value: 0x00},
-		{Reg: 0x44, Value: 0xFF},
-		{Reg: 0x45, Value: 0x26},
-		{Reg: 0x46, Value: 0x05},
-		{Reg: 0x40, Value: 0x40},
-		{Reg: 0x0E, Value: 0x06},
-		{Reg: 0x20, Value: 0x1A},
-		{Reg: 0x43, Value: 0x40},
-	}...)
+	u32, err := v.getMeasurementTimingBudget(i2c)
 	if err != nil {
 		return err
 	}
+	v.measurementTimingBudgetUsec = u32
 
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x00},
-		{Reg: 0x34, Value: 0x03},
-		{Reg: 0x35, Value: 0x44},
-	}...)
-	if err != nil {
-		return err
-	}
+	// "Disable MSRC and TCC by default"
+	// MSRC = Minimum Signal Rate Check
+	// TCC = Target CentreCheck
+	// -- VL53L0X_SetSequenceStepEnable() begin
 
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x01},
-		{Reg: 0x31, Value: 0x04},
-		{Reg: 0x4B, Value: 0x09},
-		{Reg: 0x4C, Value: 0x05},
-		{Reg: 0x4D, Value: 0x04},
-	}...)
+	err = v.writeRegU8(i2c, SYSTEM_SEQUENCE_CONFIG, 0xE8)
 	if err != nil {
 		return err
 	}
 
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x00},
-		{Reg: 0x44, Value: 0x00},
-		{Reg: 0x45, Value: 0x20},
-		{Reg: 0x47, Value: 0x08},
-		{Reg: 0x48, Value: 0x28},
-		{Reg: 0x67, Value: 0x00},
-		{Reg: 0x70, Value: 0x04},
-		{Reg: 0x71, Value: 0x01},
-		{Reg: 0x72, Value: 0xFE},
-		{Reg: 0x76, Value: 0x00},
-		{Reg: 0x77, Value: 0x00},
-	}...)
-	if err != nil {
-		return err
-	}
+	// -- VL53L0X_SetSequenceStepEnable() end
 
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x01},
-		{Reg: 0x0D, Value: 0x01},
-	}...)
+	// "Recalculate timing budget"
+	err = v.SetMeasurementTimingBudget(i2c, v.measurementTimingBudgetUsec)
 	if err != nil {
 		return err
 	}
 
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x00},
-		{Reg: 0x80, Value: 0x01},
-		{Reg: 0x01, Value: 0xF8},
-	}...)
-	if err != nil {
-		return err
-	}
+	// VL53L0X_StaticInit() end
 
-	err = v.writeRegValues(i2c, []RegBytePair{
-		{Reg: 0xFF, Value: 0x01},
-		{Reg: 0x8E, Value: 0x01},
-		{Reg: 0x00, Value: 0x01},
-		{Reg: 0xFF, Value: 0x00},
-		{Reg: 0x80, Value: 0x00},
-	}...)
+	err = v.performRefCalibration(i2c)
 	if err != nil {
 		return err
 	}
 
-	// -- VL53L0X_load_tuning_settings() end
+	v.restartWarmUp()
 
-	// "Set interrupt config to new sample ready"
-	// -- VL53L0X_SetGpioConfig() begin
+	return v.verifyConfigIfEnabled(i2c)
+}
 
-	err = v.writeRegU8(i2c, SYSTEM_INTERRUPT_CONFIG_GPIO, 0x04)
+// Set interrupt config to new sample ready, GPIO1 active low.
+// Based on VL53L0X_SetGpioConfig().
+func (v *Vl53l0x) setGpioConfig(i2c Bus) error {
+	err := v.writeRegU8(i2c, SYSTEM_INTERRUPT_CONFIG_GPIO, 0x04)
 	if err != nil {
 		return err
 	}
-	u8, err = v.readRegU8(i2c, GPIO_HV_MUX_ACTIVE_HIGH)
+	u8, err := v.readRegU8(i2c, GPIO_HV_MUX_ACTIVE_HIGH)
 	if err != nil {
 		return err
 	}
@@ -653,40 +619,15 @@ func (v *Vl53l0x) Init(i2c *i2c.I2C) error {
 	if err != nil {
 		return err
 	}
+	return nil
+}
 
-	// -- VL53L0X_SetGpioConfig() end
-
-	u32, err := v.getMeasurementTimingBudget(i2c)
-	if err != nil {
-		return err
-	}
-	v.measurementTimingBudgetUsec = u32
-
-	// "Disable MSRC and TCC by default"
-	// MSRC = Minimum Signal Rate Check
-	// TCC = Target CentreCheck
-	// -- VL53L0X_SetSequenceStepEnable() begin
-
-	err = v.writeRegU8(i2c, SYSTEM_SEQUENCE_CONFIG, 0xE8)
-	if err != nil {
-		return err
-	}
-
-	// -- VL53L0X_SetSequenceStepEnable() end
-
-	// "Recalculate timing budget"
-	err = v.SetMeasurementTimingBudget(i2c, v.measurementTimingBudgetUsec)
-	if err != nil {
-		return err
-	}
-
-	// VL53L0X_StaticInit() end
-
-	// VL53L0X_PerformRefCalibration() begin (VL53L0X_perform_ref_calibration())
-
+// Perform VHV and phase calibration.
+// Based on VL53L0X_perform_ref_calibration().
+func (v *Vl53l0x) performRefCalibration(i2c Bus) error {
 	// -- VL53L0X_perform_vhv_calibration() begin
 
-	err = v.writeRegU8(i2c, SYSTEM_SEQUENCE_CONFIG, 0x01)
+	err := v.writeRegU8(i2c, SYSTEM_SEQUENCE_CONFIG, 0x01)
 	if err != nil {
 		return err
 	}
@@ -715,9 +656,6 @@ func (v *Vl53l0x) Init(i2c *i2c.I2C) error {
 	if err != nil {
 		return err
 	}
-
-	// VL53L0X_PerformRefCalibration() end
-
 	return nil
 }
 
@@ -729,18 +667,22 @@ func (v *Vl53l0x) Init(i2c *i2c.I2C) error {
 // seems to increase the likelihood of getting an inaccurate reading because of
 // unwanted reflections from objects other than the intended target.
 // Defaults to 0.25 MCPS as initialized by the ST API and this library.
-func (v *Vl53l0x) SetSignalRateLimit(i2c *i2c.I2C, limitMcps float32) error {
+func (v *Vl53l0x) SetSignalRateLimit(i2c Bus, limitMcps float32) error {
 	if limitMcps < 0 || limitMcps > 511.99 {
 		return errors.New("out of MCPS range")
 	}
 	// Q9.7 fixed point format (9 integer bits, 7 fractional bits)
 	err := v.writeRegU16(i2c, FINAL_RANGE_CONFIG_MIN_COUNT_RATE_RTN_LIMIT,
 		uint16(limitMcps*(1<<7)))
-	return err
+	if err != nil {
+		return err
+	}
+	v.settings.signalRateLimit = limitMcps
+	return nil
 }
 
 // GetSignalRateLimit gets the return signal rate limit check value in MCPS.
-func (v *Vl53l0x) GetSignalRateLimit(i2c *i2c.I2C) (float32, error) {
+func (v *Vl53l0x) GetSignalRateLimit(i2c Bus) (float32, error) {
 	u16, err := v.readRegU16(i2c, FINAL_RANGE_CONFIG_MIN_COUNT_RATE_RTN_LIMIT)
 	if err != nil {
 		return 0, err
@@ -775,9 +717,9 @@ type SequenceStepTimeouts struct {
 
 // Get sequence step enables.
 // Based on VL53L0X_GetSequenceStepEnables().
-func (v *Vl53l0x) getSequenceStepEnables(i2c *i2c.I2C) (*SequenceStepEnables, error) {
+func (v *Vl53l0x) getSequenceStepEnables(i2c Bus) (*SequenceStepEnables, error) {
 
-	lg.Debug("Start getting sequence step enables")
+	debug("Start getting sequence step enables")
 
 	sequenceConfig, err := v.readRegU8(i2c, SYSTEM_SEQUENCE_CONFIG)
 	if err != nil {
@@ -815,16 +757,19 @@ func (v *Vl53l0x) calcMacroPeriod(vcselPeriodPclks uint16) uint32 {
 
 // Convert sequence step timeout from MCLKs to microseconds with given VCSEL period in PCLKs.
 // Based on VL53L0X_calc_timeout_us().
+// Calculated in 64 bits, since long timeouts overflow 32-bit product.
 func (v *Vl53l0x) timeoutMclksToMicroseconds(timeoutPeriodMclks uint16, vcselPeriodPclks uint16) uint32 {
-	macroPeriodNsec := v.calcMacroPeriod(vcselPeriodPclks)
-	return (uint32(timeoutPeriodMclks)*macroPeriodNsec + macroPeriodNsec/2) / 1000
+	macroPeriodNsec := uint64(v.calcMacroPeriod(vcselPeriodPclks))
+	return uint32((uint64(timeoutPeriodMclks)*macroPeriodNsec + macroPeriodNsec/2) / 1000)
 }
 
 // Convert sequence step timeout from microseconds to MCLKs with given VCSEL period in PCLKs.
 // Based on VL53L0X_calc_timeout_mclks().
+// Calculated in 64 bits, since timeouts longer than 4.3 seconds
+// overflow 32-bit nanoseconds.
 func (v *Vl53l0x) timeoutMicrosecondsToMclks(timeoutPeriodUsec uint32, vcselPeriodPclks uint16) uint32 {
-	macroPeriodNsec := v.calcMacroPeriod(vcselPeriodPclks)
-	return (timeoutPeriodUsec*1000 + macroPeriodNsec/2) / macroPeriodNsec
+	macroPeriodNsec := uint64(v.calcMacroPeriod(vcselPeriodPclks))
+	return uint32((uint64(timeoutPeriodUsec)*1000 + macroPeriodNsec/2) / macroPeriodNsec)
 }
 
 // SetVcselPulsePeriod set the VCSEL (vertical cavity surface emitting laser) pulse period
@@ -833,8 +778,13 @@ func (v *Vl53l0x) timeoutMicrosecondsToMclks(timeoutPeriodUsec uint32, vcselPeri
 // Valid values are (even numbers only):
 //  pre:  12 to 18 (initialized default: 14),
 //  final: 8 to 14 (initialized default: 10).
+// Invalid period is rejected with *VcselPeriodError before sensor is accessed.
 // Based on VL53L0X_set_vcsel_pulse_period().
-func (v *Vl53l0x) SetVcselPulsePeriod(i2c *i2c.I2C, tpe VcselPeriodType, periodPclks uint8) error {
+func (v *Vl53l0x) SetVcselPulsePeriod(i2c Bus, tpe VcselPeriodType, periodPclks uint8) error {
+	err := validateVcselPeriod(tpe, periodPclks)
+	if err != nil {
+		return err
+	}
 	vcselPeriodReg := v.encodeVcselPeriod(periodPclks)
 
 	enables, err := v.getSequenceStepEnables(i2c)
@@ -882,8 +832,8 @@ func (v *Vl53l0x) SetVcselPulsePeriod(i2c *i2c.I2C, tpe VcselPeriodType, periodP
 				return err
 			}
 		default:
-			// invalid period
-			return errors.New("invalid period")
+			// unreachable, since period is validated
+			return &VcselPeriodError{Type: tpe, Pclks: periodPclks}
 		}
 		err = v.writeRegU8(i2c, PRE_RANGE_CONFIG_VALID_PHASE_LOW, 0x08)
 		if err != nil {
@@ -904,8 +854,11 @@ func (v *Vl53l0x) SetVcselPulsePeriod(i2c *i2c.I2C, tpe VcselPeriodType, periodP
 		newPreRangeTimeoutMclks := v.timeoutMicrosecondsToMclks(timeouts.PreRangeUsec,
 			uint16(periodPclks))
 
-		err = v.writeRegU16(i2c, PRE_RANGE_CONFIG_TIMEOUT_MACROP_HI,
-			v.encodeTimeout(uint16(newPreRangeTimeoutMclks)))
+		u16, err := v.encodeTimeoutChecked("pre-range", newPreRangeTimeoutMclks)
+		if err != nil {
+			return err
+		}
+		err = v.writeRegU16(i2c, PRE_RANGE_CONFIG_TIMEOUT_MACROP_HI, u16)
 		if err != nil {
 			return err
 		}
@@ -984,8 +937,8 @@ func (v *Vl53l0x) SetVcselPulsePeriod(i2c *i2c.I2C, tpe VcselPeriodType, periodP
 				return err
 			}
 		default:
-			// invalid period
-			return errors.New("invalid period")
+			// unreachable, since period is validated
+			return &VcselPeriodError{Type: tpe, Pclks: periodPclks}
 		}
 
 		// apply new VCSEL period
@@ -1011,16 +964,19 @@ func (v *Vl53l0x) SetVcselPulsePeriod(i2c *i2c.I2C, tpe VcselPeriodType, periodP
 			newFinalRangeTimeoutMclks += uint32(timeouts.PreRangeMclks)
 		}
 
-		err = v.writeRegU16(i2c, FINAL_RANGE_CONFIG_TIMEOUT_MACROP_HI,
-			v.encodeTimeout(uint16(newFinalRangeTimeoutMclks)))
+		u16, err := v.encodeTimeoutChecked("final range", newFinalRangeTimeoutMclks)
+		if err != nil {
+			return err
+		}
+		err = v.writeRegU16(i2c, FINAL_RANGE_CONFIG_TIMEOUT_MACROP_HI, u16)
 		if err != nil {
 			return err
 		}
 
 		// set_sequence_step_timeout end
 	} else {
-		// invalid type
-		return errors.New("invalid type")
+		// unreachable, since type is validated
+		return &VcselPeriodError{Type: tpe, Pclks: periodPclks}
 	}
 
 	// "Finally, the timing budget must be re-applied"
@@ -1052,14 +1008,20 @@ func (v *Vl53l0x) SetVcselPulsePeriod(i2c *i2c.I2C, tpe VcselPeriodType, periodP
 
 	// VL53L0X_perform_phase_calibration() end
 
+	if tpe == VcselPeriodPreRange {
+		v.settings.preRangeVcselPeriod = periodPclks
+	} else {
+		v.settings.finalRangeVcselPeriod = periodPclks
+	}
+
 	return nil
 }
 
 // Get the VCSEL pulse period in PCLKs for the given period type.
 // Based on VL53L0X_get_vcsel_pulse_period().
-func (v *Vl53l0x) getVcselPulsePeriod(i2c *i2c.I2C, tpe VcselPeriodType) (byte, error) {
+func (v *Vl53l0x) getVcselPulsePeriod(i2c Bus, tpe VcselPeriodType) (byte, error) {
 
-	lg.Debug("Start getting VCSEL pulse period")
+	debug("Start getting VCSEL pulse period")
 
 	switch tpe {
 	case VcselPeriodPreRange:
@@ -1079,14 +1041,26 @@ func (v *Vl53l0x) getVcselPulsePeriod(i2c *i2c.I2C, tpe VcselPeriodType) (byte,
 	}
 }
 
+// StartContinuousDuration start continuous ranging measurements, same as StartContinuous,
+// but inter-measurement period specified as time.Duration (with millisecond resolution).
+// If period is 0, continuous back-to-back mode is used.
+func (v *Vl53l0x) StartContinuousDuration(i2c Bus, period time.Duration) error {
+	if period < 0 {
+		return errors.New("negative inter-measurement period")
+	}
+	return v.StartContinuous(i2c, uint32(period/time.Millisecond))
+}
+
 // StartContinuous start continuous ranging measurements. If period_ms (optional) is 0 or not
 // given, continuous back-to-back mode is used (the sensor takes measurements as
 // often as possible); otherwise, continuous timed mode is used, with the given
 // inter-measurement period in milliseconds determining how often the sensor
 // takes a measurement. Based on VL53L0X_StartMeasurement().
-func (v *Vl53l0x) StartContinuous(i2c *i2c.I2C, periodMs uint32) error {
+func (v *Vl53l0x) StartContinuous(i2c Bus, periodMs uint32) error {
+
+	debug("Start continuous")
 
-	lg.Debug("Start continuous")
+	requestedPeriodMs := periodMs
 
 	err := v.writeRegValues(i2c, []RegBytePair{
 		{Reg: 0x80, Value: 0x01},
@@ -1132,14 +1106,18 @@ func (v *Vl53l0x) StartContinuous(i2c *i2c.I2C, periodMs uint32) error {
 			return err
 		}
 	}
+	v.settings.continuous = true
+	v.settings.periodMs = requestedPeriodMs
+	v.markRangingStarted()
+	v.armRangingLimit()
 	return nil
 }
 
 // StopContinuous stop continuous measurements.
 // Based on VL53L0X_StopMeasurement().
-func (v *Vl53l0x) StopContinuous(i2c *i2c.I2C) error {
+func (v *Vl53l0x) StopContinuous(i2c Bus) error {
 
-	lg.Debug("Stop continuous")
+	debug("Stop continuous")
 
 	err := v.writeRegValues(i2c, []RegBytePair{
 		{Reg: SYSRANGE_START, Value: 0x01}, // VL53L0X_REG_SYSRANGE_MODE_SINGLESHOT
@@ -1149,50 +1127,70 @@ func (v *Vl53l0x) StopContinuous(i2c *i2c.I2C) error {
 		{Reg: 0x00, Value: 0x01},
 		{Reg: 0xFF, Value: 0x00},
 	}...)
-	return err
+	if err != nil {
+		return err
+	}
+	v.settings.continuous = false
+	v.rangingStarted = time.Time{}
+	return nil
 }
 
-// Read measured distance from the sensor.
-func (v *Vl53l0x) readRangeMillimeters(i2c *i2c.I2C) (uint16, error) {
+// ReadMeasurementContinuous returns a measurement when continuous mode is active.
+func (v *Vl53l0x) ReadMeasurementContinuous(i2c Bus) (Measurement, error) {
 
-	err := v.waitUntilOrTimeout(i2c, RESULT_INTERRUPT_STATUS,
-		func(checkReg byte, err error) (bool, error) {
-			return checkReg&0x07 != 0, err
-		})
-	if err != nil {
-		return 0, err
-	}
+	debug("Read measurement continuous")
+
+	return v.readContinuous(i2c)
+}
+
+// ReadRangeContinuousMillimeters returns a range reading in millimeters
+// when continuous mode is active. Returns ErrOutOfRange along with raw
+// range value, when no target detected.
+func (v *Vl53l0x) ReadRangeContinuousMillimeters(i2c Bus) (uint16, error) {
+
+	debug("Read range continuous")
 
-	// assumptions: Linearity Corrective Gain is 1000 (default);
-	// fractional ranging is not enabled
-	rng, err := v.readRegU16(i2c, RESULT_RANGE_STATUS+10)
+	m, err := v.readContinuous(i2c)
 	if err != nil {
 		return 0, err
 	}
-	err = v.writeRegU8(i2c, SYSTEM_INTERRUPT_CLEAR, 0x01)
+	return m.RangeMillimeters, m.outOfRangeErr()
+}
+
+// ReadRangeSingleMillimeters performs a single-shot range measurement and returns the reading in
+// millimeters based on VL53L0X_PerformSingleRangingMeasurement(). Returns ErrOutOfRange
+// along with raw range value, when no target detected.
+func (v *Vl53l0x) ReadRangeSingleMillimeters(i2c Bus) (uint16, error) {
+
+	debug("Read range single")
+
+	m, err := v.withRecovery(i2c, v.readMeasurementSingle)
 	if err != nil {
 		return 0, err
 	}
-
-	return rng, nil
+	return m.RangeMillimeters, m.outOfRangeErr()
 }
 
-// ReadRangeContinuousMillimeters returns a range reading in millimeters
-// when continuous mode is active (readRangeSingleMillimeters() also calls
-// this function after starting a single-shot range measurement).
-func (v *Vl53l0x) ReadRangeContinuousMillimeters(i2c *i2c.I2C) (uint16, error) {
+// ReadMeasurementSingle performs a single-shot range measurement and returns
+// the measurement based on VL53L0X_PerformSingleRangingMeasurement().
+func (v *Vl53l0x) ReadMeasurementSingle(i2c Bus) (Measurement, error) {
 
-	lg.Debug("Read range continuous")
+	debug("Read measurement single")
 
-	return v.readRangeMillimeters(i2c)
+	return v.withRecovery(i2c, v.readMeasurementSingle)
 }
 
-// ReadRangeSingleMillimeters performs a single-shot range measurement and returns the reading in
-// millimeters based on VL53L0X_PerformSingleRangingMeasurement().
-func (v *Vl53l0x) ReadRangeSingleMillimeters(i2c *i2c.I2C) (uint16, error) {
-
-	lg.Debug("Read range single")
+// Start single-shot range measurement and read the result.
+func (v *Vl53l0x) readMeasurementSingle(i2c Bus) (Measurement, error) {
+	err := v.startSingle(i2c)
+	if err != nil {
+		return Measurement{}, err
+	}
+	return v.finishSingle(i2c)
+}
 
+// Trigger single-shot range measurement without waiting for result.
+func (v *Vl53l0x) startSingle(i2c Bus) error {
 	err := v.writeRegValues(i2c, []RegBytePair{
 		{Reg: 0x80, Value: 0x01},
 		{Reg: 0xFF, Value: 0x01},
@@ -1204,27 +1202,40 @@ func (v *Vl53l0x) ReadRangeSingleMillimeters(i2c *i2c.I2C) (uint16, error) {
 		{Reg: SYSRANGE_START, Value: 0x01},
 	}...)
 	if err != nil {
-		return 0, err
+		return err
 	}
+	v.markRangingStarted()
+	return nil
+}
 
+// Wait for single-shot range measurement triggered by startSingle
+// and read result.
+func (v *Vl53l0x) finishSingle(i2c Bus) (Measurement, error) {
 	// "Wait until start bit has been cleared"
-	err = v.waitUntilOrTimeout(i2c, SYSRANGE_START,
+	err := v.waitUntilOrTimeout(i2c, WaitStartBit, SYSRANGE_START,
 		func(checkReg byte, err error) (bool, error) {
 			return checkReg&0x01 == 0, err
 		})
 	if err != nil {
-		return 0, err
+		return Measurement{}, err
 	}
-	return v.readRangeMillimeters(i2c)
+	return v.readMeasurement(i2c)
 }
 
 // Decode sequence step timeout in MCLKs from register value
 // based on VL53L0X_decode_timeout()
 // Note: the original function returned a uint32_t, but the return value is
-// always stored in a uint16_t.
+// always stored in a uint16_t, so it's saturated to uint16 range.
 func (v *Vl53l0x) decodeTimeout(regVal uint16) uint16 {
 	// format: "(LSByte * 2^MSByte) + 1"
-	return (regVal&0x00FF)<<((regVal&0xFF00)>>8) + 1
+	lsByte, msByte := uint32(regVal&0x00FF), regVal>>8
+	if lsByte == 0 {
+		return 1
+	}
+	if msByte >= 16 || lsByte<<msByte >= math.MaxUint16 {
+		return math.MaxUint16
+	}
+	return uint16(lsByte<<msByte + 1)
 }
 
 // Encode sequence step timeout register value from timeout in MCLKs
@@ -1247,13 +1258,44 @@ func (v *Vl53l0x) encodeTimeout(timeoutMclks uint16) uint16 {
 	return 0
 }
 
+// TimeoutRangeError returned, when sequence step timeout doesn't fit
+// timeout register, so it can't be programmed without wrapping around,
+// for instance when requested timing budget is too long.
+type TimeoutRangeError struct {
+	// sequence step: "pre-range" or "final range"
+	Step string
+	// requested timeout and maximum one in MCLKs
+	Mclks    uint32
+	MaxMclks uint32
+}
+
+// Error implement error interface.
+func (e *TimeoutRangeError) Error() string {
+	return spew.Sprintf("%s timeout of %d MCLKs exceeds maximum of %d MCLKs",
+		e.Step, e.Mclks, e.MaxMclks)
+}
+
+// Maximum sequence step timeout in MCLKs, which could be programmed;
+// decoded timeouts are kept in uint16, as in original library.
+const maxTimeoutMclks = math.MaxUint16
+
+// Encode sequence step timeout register value from timeout in MCLKs,
+// rejecting timeouts, which don't fit, instead of truncating them.
+func (v *Vl53l0x) encodeTimeoutChecked(step string, timeoutMclks uint32) (uint16, error) {
+	if timeoutMclks > maxTimeoutMclks {
+		return 0, &TimeoutRangeError{Step: step, Mclks: timeoutMclks,
+			MaxMclks: maxTimeoutMclks}
+	}
+	return v.encodeTimeout(uint16(timeoutMclks)), nil
+}
+
 // Get sequence step timeouts
 // based on get_sequence_step_timeout(),
 // but gets all timeouts instead of just the requested one, and also stores
 // intermediate values.
-func (v *Vl53l0x) getSequenceStepTimeouts(i2c *i2c.I2C, enables SequenceStepEnables) (*SequenceStepTimeouts, error) {
+func (v *Vl53l0x) getSequenceStepTimeouts(i2c Bus, enables SequenceStepEnables) (*SequenceStepTimeouts, error) {
 
-	lg.Debug("Start getting sequence step timeouts")
+	debug("Start getting sequence step timeouts")
 
 	timeouts := &SequenceStepTimeouts{}
 
@@ -1310,7 +1352,7 @@ func (v *Vl53l0x) getSequenceStepTimeouts(i2c *i2c.I2C, enables SequenceStepEnab
 // factor of N decreases the range measurement standard deviation by a factor of
 // sqrt(N). Defaults to about 33 milliseconds; the minimum is 20 ms.
 // Based on VL53L0X_set_measurement_timing_budget_micro_seconds().
-func (v *Vl53l0x) SetMeasurementTimingBudget(i2c *i2c.I2C, budgetUsec uint32) error {
+func (v *Vl53l0x) SetMeasurementTimingBudget(i2c Bus, budgetUsec uint32) error {
 	const StartOverhead = 1320 // note that this is different than the value in get_
 	const EndOverhead = 960
 	const MsrcOverhead = 660
@@ -1321,7 +1363,7 @@ func (v *Vl53l0x) SetMeasurementTimingBudget(i2c *i2c.I2C, budgetUsec uint32) er
 
 	const MinTimingBudget = 20000
 
-	lg.Debug("Start setting measurement timing budget")
+	debug("Start setting measurement timing budget")
 
 	if budgetUsec < MinTimingBudget {
 		return errors.New("budget is lower than minimum allowed")
@@ -1332,12 +1374,16 @@ func (v *Vl53l0x) SetMeasurementTimingBudget(i2c *i2c.I2C, budgetUsec uint32) er
 	if err != nil {
 		return err
 	}
-	lg.Debugf("Sequence step enables = %#v", enables)
+	if debugEnabled() {
+		lg.Debugf("Sequence step enables = %#v", enables)
+	}
 	timeouts, err := v.getSequenceStepTimeouts(i2c, *enables)
 	if err != nil {
 		return err
 	}
-	lg.Debugf("Sequence step timeouts = %#v", timeouts)
+	if debugEnabled() {
+		lg.Debugf("Sequence step timeouts = %#v", timeouts)
+	}
 
 	if enables.TCC {
 		usedBudgetUsec += timeouts.MsrcDssTccUsec + TccOverhead
@@ -1377,7 +1423,7 @@ func (v *Vl53l0x) SetMeasurementTimingBudget(i2c *i2c.I2C, budgetUsec uint32) er
 		//  timeouts must be expressed in macro periods MClks
 		//  because they have different vcsel periods."
 
-		lg.Debug("set_sequence_step_timeout() begin")
+		debug("set_sequence_step_timeout() begin")
 
 		finalRangeTimeoutMclks := v.timeoutMicrosecondsToMclks(finalRangeTimeoutUsec,
 			timeouts.FinalRangeVcselPeriodPclks)
@@ -1386,28 +1432,57 @@ func (v *Vl53l0x) SetMeasurementTimingBudget(i2c *i2c.I2C, budgetUsec uint32) er
 			finalRangeTimeoutMclks += uint32(timeouts.PreRangeMclks)
 		}
 
-		err = v.writeRegU16(i2c, FINAL_RANGE_CONFIG_TIMEOUT_MACROP_HI,
-			v.encodeTimeout(uint16(finalRangeTimeoutMclks)))
+		u16, err := v.encodeTimeoutChecked("final range", finalRangeTimeoutMclks)
+		if err != nil {
+			return err
+		}
+		err = v.writeRegU16(i2c, FINAL_RANGE_CONFIG_TIMEOUT_MACROP_HI, u16)
 		if err != nil {
 			return err
 		}
 
-		lg.Debug("set_sequence_step_timeout() end")
+		debug("set_sequence_step_timeout() end")
 
 		// set_sequence_step_timeout() end
 
 		v.measurementTimingBudgetUsec = budgetUsec // store for internal reuse
 	}
 
-	lg.Debug("End setting measurement timing budget")
+	debug("End setting measurement timing budget")
 
 	return nil
 }
 
+// SetMeasurementTimingBudgetDuration set the measurement timing budget,
+// same as SetMeasurementTimingBudget, but budget specified as time.Duration
+// (with microsecond resolution).
+func (v *Vl53l0x) SetMeasurementTimingBudgetDuration(i2c Bus, budget time.Duration) error {
+	if budget < 0 || budget/time.Microsecond > math.MaxUint32 {
+		return errors.New("budget is out of range")
+	}
+	return v.SetMeasurementTimingBudget(i2c, uint32(budget/time.Microsecond))
+}
+
+// GetMeasurementTimingBudget reads the measurement timing budget
+// in microseconds from the sensor.
+func (v *Vl53l0x) GetMeasurementTimingBudget(i2c Bus) (uint32, error) {
+	return v.getMeasurementTimingBudget(i2c)
+}
+
+// GetMeasurementTimingBudgetDuration reads the measurement timing budget
+// from the sensor, returned as time.Duration.
+func (v *Vl53l0x) GetMeasurementTimingBudgetDuration(i2c Bus) (time.Duration, error) {
+	budgetUsec, err := v.getMeasurementTimingBudget(i2c)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(budgetUsec) * time.Microsecond, nil
+}
+
 // Get the measurement timing budget in microseconds
 // based on VL53L0X_get_measurement_timing_budget_micro_seconds()
 // in us (microseconds).
-func (v *Vl53l0x) getMeasurementTimingBudget(i2c *i2c.I2C) (uint32, error) {
+func (v *Vl53l0x) getMeasurementTimingBudget(i2c Bus) (uint32, error) {
 	const StartOverhead = 1910 // note that this is different than the value in set_
 	const EndOverhead = 960
 	const MsrcOverhead = 660
@@ -1460,7 +1535,7 @@ type SpadInfo struct {
 // Get reference SPAD (single photon avalanche diode) count and type
 // based on VL53L0X_get_info_from_device(),
 // but only gets reference SPAD count and type.
-func (v *Vl53l0x) getSpadInfo(i2c *i2c.I2C) (*SpadInfo, error) {
+func (v *Vl53l0x) getSpadInfo(i2c Bus) (*SpadInfo, error) {
 	var tmp uint8
 
 	err := v.writeRegValues(i2c, []RegBytePair{
@@ -1501,7 +1576,7 @@ func (v *Vl53l0x) getSpadInfo(i2c *i2c.I2C) (*SpadInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = v.waitUntilOrTimeout(i2c, 0x83,
+	err = v.waitUntilOrTimeout(i2c, WaitSpadInfo, 0x83,
 		func(checkReg byte, err error) (bool, error) {
 			return checkReg != 0, err
 		})
@@ -1550,13 +1625,58 @@ func (v *Vl53l0x) getSpadInfo(i2c *i2c.I2C) (*SpadInfo, error) {
 	return si, nil
 }
 
+// Enable reference SPADs (single photon avalanche diode) of type and count
+// given, choosing them from good SPAD map read by Init.
+// Based on VL53L0X_set_reference_spads().
+func (v *Vl53l0x) setReferenceSpads(i2c Bus, info SpadInfo) error {
+	err := v.writeRegValues(i2c, []RegBytePair{
+		{Reg: 0xFF, Value: 0x01},
+		{Reg: DYNAMIC_SPAD_REF_EN_START_OFFSET, Value: 0x00},
+		{Reg: DYNAMIC_SPAD_NUM_REQUESTED_REF_SPAD, Value: 0x2C},
+		{Reg: 0xFF, Value: 0x00},
+		{Reg: GLOBAL_CONFIG_REF_EN_START_SELECT, Value: 0xB4},
+	}...)
+	if err != nil {
+		return err
+	}
+
+	spadMap := make([]byte, len(v.goodSpadMap))
+	copy(spadMap, v.goodSpadMap[:])
+
+	var firstSpadToEnable byte
+	if info.TypeIsAperture {
+		// 12 is the first aperture spad
+		firstSpadToEnable = 12
+	}
+	var spadsEnabled byte
+
+	var i byte
+	for i = 0; i < 48; i++ {
+		if i < firstSpadToEnable || spadsEnabled == info.Count {
+			// This bit is lower than the first one that should be enabled, or
+			// (reference_spad_count) bits have already been enabled, so zero this bit
+			spadMap[i/8] &= ^(1 << (i % 8))
+		} else if (spadMap[i/8]>>(i%8))&0x1 != 0 {
+			spadsEnabled++
+		}
+	}
+
+	err = v.writeBytes(i2c, GLOBAL_CONFIG_SPAD_ENABLES_REF_0, spadMap)
+	if err != nil {
+		return err
+	}
+
+	v.refSpadInfo = info
+	return nil
+}
+
 // Based on VL53L0X_perform_single_ref_calibration().
-func (v *Vl53l0x) performSingleRefCalibration(i2c *i2c.I2C, vhvInitByte uint8) error {
+func (v *Vl53l0x) performSingleRefCalibration(i2c Bus, vhvInitByte uint8) error {
 	err := v.writeRegU8(i2c, SYSRANGE_START, 0x01|vhvInitByte) // VL53L0X_REG_SYSRANGE_MODE_START_STOP
 	if err != nil {
 		return err
 	}
-	err = v.waitUntilOrTimeout(i2c, RESULT_INTERRUPT_STATUS,
+	err = v.waitUntilOrTimeout(i2c, WaitRefCalibration, RESULT_INTERRUPT_STATUS,
 		func(checkReg byte, err error) (bool, error) {
 			return checkReg&0x07 != 0, err
 		})
@@ -1573,12 +1693,19 @@ func (v *Vl53l0x) performSingleRefCalibration(i2c *i2c.I2C, vhvInitByte uint8) e
 	return nil
 }
 
-// Set timeout duration for operations which could be
-// terminated on timeout events.
-func (v *Vl53l0x) setTimeout(timeout time.Duration) {
+// SetTimeout set timeout duration for operations which could be
+// terminated on timeout events. Zero value disables timeout.
+// Default is 1 second.
+func (v *Vl53l0x) SetTimeout(timeout time.Duration) {
 	v.ioTimeout = timeout
 }
 
+// GetTimeout returns timeout duration for operations which could be
+// terminated on timeout events.
+func (v *Vl53l0x) GetTimeout() time.Duration {
+	return v.ioTimeout
+}
+
 // Returns current time.
 func (v *Vl53l0x) startTimeout() time.Time {
 	return time.Now()
@@ -1592,7 +1719,8 @@ func (v *Vl53l0x) checkTimeoutExpired(startTime time.Time) bool {
 
 // Read specific register in the loop until condition is true,
 // or wait for timeout event.
-func (v *Vl53l0x) waitUntilOrTimeout(i2c *i2c.I2C, reg byte,
+// Returns *TimeoutError identifying wait stage on timeout.
+func (v *Vl53l0x) waitUntilOrTimeout(i2c Bus, stage WaitStage, reg byte,
 	breakWhen func(chechReg byte, err error) (bool, error)) error {
 
 	st := v.startTimeout()
@@ -1605,38 +1733,66 @@ func (v *Vl53l0x) waitUntilOrTimeout(i2c *i2c.I2C, reg byte,
 			break
 		}
 		if v.checkTimeoutExpired(st) {
-			return errors.New(spew.Sprintf("timeout occurs; last read register 0x%x equal to 0x%x", reg, u8))
+			return &TimeoutError{Stage: stage, Reg: reg, Value: u8,
+				Elapsed: time.Since(st)}
 		}
 	}
 	return nil
 }
 
 // Write an 8-bit register.
-func (v *Vl53l0x) writeRegU8(i2c *i2c.I2C, reg byte, value uint8) error {
-	return i2c.WriteRegU8(reg, value)
+func (v *Vl53l0x) writeRegU8(i2c Bus, reg byte, value uint8) error {
+	start := time.Now()
+	err := v.countWrite(TransactionWriteU8, start, i2c.WriteRegU8(reg, value))
+	v.ioBuf[0] = value
+	v.traceTx(true, reg, v.ioBuf[:1], start, err)
+	if err != nil {
+		return err
+	}
+	return v.verifyWrite(i2c, reg, v.ioBuf[:1])
 }
 
 // Write a 16-bit register.
-func (v *Vl53l0x) writeRegU16(i2c *i2c.I2C, reg byte, value uint16) error {
-	buf := []byte{reg, byte(value >> 8 & 0xFF), byte(value & 0xFF)}
+func (v *Vl53l0x) writeRegU16(i2c Bus, reg byte, value uint16) error {
+	buf := v.ioBuf[:3]
+	buf[0], buf[1], buf[2] = reg, byte(value>>8&0xFF), byte(value&0xFF)
+	start := time.Now()
 	_, err := i2c.WriteBytes(buf)
-	return err
+	err = v.countWrite(TransactionWriteBlock, start, err)
+	v.traceTx(true, reg, buf[1:], start, err)
+	if err != nil {
+		return err
+	}
+	return v.verifyWrite(i2c, reg, buf[1:])
 }
 
 // Write a 32-bit register.
-func (v *Vl53l0x) writeRegU32(i2c *i2c.I2C, reg byte, value uint32) error {
-	buf := []byte{reg, byte(value >> 24 & 0xFF), byte(value >> 16 & 0xFF),
-		byte(value >> 8 & 0xFF), byte(value & 0xFF)}
+func (v *Vl53l0x) writeRegU32(i2c Bus, reg byte, value uint32) error {
+	buf := v.ioBuf[:5]
+	buf[0], buf[1], buf[2] = reg, byte(value>>24&0xFF), byte(value>>16&0xFF)
+	buf[3], buf[4] = byte(value>>8&0xFF), byte(value&0xFF)
+	start := time.Now()
 	_, err := i2c.WriteBytes(buf)
-	return err
+	err = v.countWrite(TransactionWriteBlock, start, err)
+	v.traceTx(true, reg, buf[1:], start, err)
+	if err != nil {
+		return err
+	}
+	return v.verifyWrite(i2c, reg, buf[1:])
 }
 
 // Write an arbitrary number of bytes from the given array to the sensor,
 // starting at the given register.
-func (v *Vl53l0x) writeBytes(i2c *i2c.I2C, reg byte, buf []byte) error {
+func (v *Vl53l0x) writeBytes(i2c Bus, reg byte, buf []byte) error {
 	b := append([]byte{reg}, buf...)
+	start := time.Now()
 	_, err := i2c.WriteBytes(b)
-	return err
+	err = v.countWrite(TransactionWriteBlock, start, err)
+	v.traceTx(true, reg, buf, start, err)
+	if err != nil {
+		return err
+	}
+	return v.verifyWrite(i2c, reg, buf)
 }
 
 // Keeps pair of register and value to write to.
@@ -1647,10 +1803,57 @@ type RegBytePair struct {
 	Value uint8
 }
 
-// Write bunch of registers with with corresponding values.
-func (v *Vl53l0x) writeRegValues(i2c *i2c.I2C, pairs ...RegBytePair) error {
-	for _, pair := range pairs {
-		err := v.writeRegU8(i2c, pair.Reg, pair.Value)
+// WriteRegValues write bunch of registers with corresponding values.
+// Could be used to apply user-supplied tuning tables. Pairs addressing
+// consecutive registers are sent as single multi-byte I2C transaction,
+// relying on register address auto-increment of the sensor; command and
+// page select registers always written separately, keeping original order.
+func (v *Vl53l0x) WriteRegValues(i2c Bus, pairs ...RegBytePair) error {
+	return v.writeRegValues(i2c, pairs...)
+}
+
+// Write bunch of registers with with corresponding values,
+// merging consecutive registers to minimize I2C transactions.
+// Transactions are sent in single call, if bus implements BatchBus
+// and strict mode is off.
+func (v *Vl53l0x) writeRegValues(i2c Bus, pairs ...RegBytePair) error {
+	var msgs [][]byte
+	for i := 0; i < len(pairs); {
+		j := i + 1
+		if !volatileRegs[pairs[i].Reg] {
+			for j < len(pairs) && pairs[j].Reg == pairs[j-1].Reg+1 &&
+				!volatileRegs[pairs[j].Reg] {
+				j++
+			}
+		}
+		msg := make([]byte, j-i+1)
+		msg[0] = pairs[i].Reg
+		for k := range pairs[i:j] {
+			msg[k+1] = pairs[i+k].Value
+		}
+		msgs = append(msgs, msg)
+		i = j
+	}
+	if batch, ok := i2c.(BatchBus); ok && !v.strict {
+		start := time.Now()
+		err := batch.WriteBatch(msgs)
+		v.io.latency[TransactionWriteBatch].observe(time.Since(start))
+		v.io.writes.Add(uint64(len(msgs)))
+		if err != nil {
+			v.io.errors.Add(1)
+		}
+		for _, msg := range msgs {
+			v.traceTx(true, msg[0], msg[1:], start, err)
+		}
+		return err
+	}
+	for _, msg := range msgs {
+		var err error
+		if len(msg) == 2 {
+			err = v.writeRegU8(i2c, msg[0], msg[1])
+		} else {
+			err = v.writeBytes(i2c, msg[0], msg[1:])
+		}
 		if err != nil {
 			return err
 		}
@@ -1659,19 +1862,21 @@ func (v *Vl53l0x) writeRegValues(i2c *i2c.I2C, pairs ...RegBytePair) error {
 }
 
 // Read an 8-bit register.
-func (v *Vl53l0x) readRegU8(i2c *i2c.I2C, reg byte) (uint8, error) {
+func (v *Vl53l0x) readRegU8(i2c Bus, reg byte) (uint8, error) {
+	start := time.Now()
 	u8, err := i2c.ReadRegU8(reg)
+	err = v.countRead(TransactionReadU8, start, err)
+	if v.regTrace != nil {
+		v.ioBuf[0] = u8
+		v.traceTx(false, reg, v.ioBuf[:1], start, err)
+	}
 	return u8, err
 }
 
 // Read a 16-bit register.
-func (v *Vl53l0x) readRegU16(i2c *i2c.I2C, reg byte) (uint16, error) {
-	_, err := i2c.WriteBytes([]byte{reg})
-	if err != nil {
-		return 0, err
-	}
-	var buf [2]byte
-	_, err = i2c.ReadBytes(buf[0:])
+func (v *Vl53l0x) readRegU16(i2c Bus, reg byte) (uint16, error) {
+	buf := v.ioBuf[:2]
+	err := v.readRegBytes(i2c, reg, buf)
 	if err != nil {
 		return 0, err
 	}
@@ -1680,13 +1885,9 @@ func (v *Vl53l0x) readRegU16(i2c *i2c.I2C, reg byte) (uint16, error) {
 }
 
 // Read a 32-bit register.
-func (v *Vl53l0x) readRegU32(i2c *i2c.I2C, reg byte) (uint32, error) {
-	_, err := i2c.WriteBytes([]byte{reg})
-	if err != nil {
-		return 0, err
-	}
-	var buf [4]byte
-	_, err = i2c.ReadBytes(buf[0:])
+func (v *Vl53l0x) readRegU32(i2c Bus, reg byte) (uint32, error) {
+	buf := v.ioBuf[:4]
+	err := v.readRegBytes(i2c, reg, buf)
 	if err != nil {
 		return 0, err
 	}
@@ -1696,12 +1897,21 @@ func (v *Vl53l0x) readRegU32(i2c *i2c.I2C, reg byte) (uint32, error) {
 }
 
 // Read an arbitrary number of bytes from the sensor, starting at the given
-// register, into the given array.
-func (v *Vl53l0x) readRegBytes(i2c *i2c.I2C, reg byte, dest []byte) error {
-	_, err := i2c.WriteBytes([]byte{reg})
-	if err != nil {
-		return err
+// register, into the given array. Uses combined transaction,
+// if bus implements RegisterReader.
+func (v *Vl53l0x) readRegBytes(i2c Bus, reg byte, dest []byte) error {
+	start := time.Now()
+	var err error
+	if rr, ok := i2c.(RegisterReader); ok {
+		err = rr.ReadReg(reg, dest)
+	} else {
+		v.regBuf[0] = reg
+		_, err = i2c.WriteBytes(v.regBuf[:])
+		if err == nil {
+			_, err = i2c.ReadBytes(dest)
+		}
 	}
-	_, err = i2c.ReadBytes(dest)
+	err = v.countRead(TransactionReadBlock, start, err)
+	v.traceTx(false, reg, dest, start, err)
 	return err
 }
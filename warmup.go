@@ -0,0 +1,49 @@
+package vl53l0x
+
+import (
+	"time"
+)
+
+// Keeps warm-up policy and state.
+type warmUp struct {
+	samples  int
+	duration time.Duration
+	// time of last initialization and number
+	// of samples taken since that time
+	since time.Time
+	count int
+}
+
+// SetWarmUp set warm-up policy: first samples taken after Init, or taken
+// within duration after Init, are discarded by streaming (Stream, Measurements,
+// Listen, SampleAtRate, Capture) and averaged read (ReadRangeAveraged) functions,
+// since they are often noticeably off. Zero values disable corresponding
+// criterion; by default warm-up is disabled.
+func (v *Vl53l0x) SetWarmUp(samples int, duration time.Duration) {
+	v.warmUp.samples = samples
+	v.warmUp.duration = duration
+}
+
+// Restart warm-up period, since sensor just initialized.
+func (v *Vl53l0x) restartWarmUp() {
+	v.warmUp.since = time.Now()
+	v.warmUp.count = 0
+}
+
+// Returns true, if measurement should be discarded according to warm-up policy.
+func (v *Vl53l0x) warmingUp(m Measurement) bool {
+	if m.Err != nil {
+		return false
+	}
+	v.warmUp.count++
+	if v.warmUp.count <= v.warmUp.samples {
+		debugf("Discard warm-up sample %d", v.warmUp.count)
+		return true
+	}
+	if v.warmUp.duration > 0 && !m.Timestamp.IsZero() &&
+		m.Timestamp.Sub(v.warmUp.since) < v.warmUp.duration {
+		debug("Discard warm-up sample")
+		return true
+	}
+	return false
+}
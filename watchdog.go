@@ -0,0 +1,123 @@
+package vl53l0x
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RecoveryEvent reported by Watchdog, once it detects stalled sensor
+// and performs recovery.
+type RecoveryEvent struct {
+	Time time.Time
+	// error, which triggered recovery
+	Cause error
+	// recovery error; nil if recovery succeeded
+	Err error
+}
+
+// Watchdog runs continuous mode and notices, when no new measurement becomes
+// available within k× expected period (sensor wedged, power blip, etc).
+// In that case it stops ranging, re-initializes sensor with last applied
+// configuration and restarts continuous mode, reporting recovery event.
+// Intended for 24/7 installations, which otherwise need external supervision.
+type Watchdog struct {
+	sensor     *Vl53l0x
+	i2c        Bus
+	period     time.Duration
+	factor     int
+	onRecovery func(RecoveryEvent)
+}
+
+// NewWatchdog creates watchdog for sensor running in continuous mode
+// with inter-measurement period given (0 stands for back-to-back mode).
+// Sensor considered stalled, if no data ready within factor expected periods.
+func NewWatchdog(sensor *Vl53l0x, i2c Bus, period time.Duration, factor int) *Watchdog {
+	if factor < 1 {
+		factor = 1
+	}
+	v := &Watchdog{sensor: sensor, i2c: i2c, period: period, factor: factor}
+	return v
+}
+
+// OnRecovery register handler to be called after each recovery attempt.
+func (v *Watchdog) OnRecovery(handler func(RecoveryEvent)) {
+	v.onRecovery = handler
+}
+
+// Run continuous mode passing measurements to handler, until context is done.
+// Returns error only if continuous mode can't be started initially.
+func (v *Watchdog) Run(ctx context.Context, handler func(Measurement)) error {
+	// expected period is either inter-measurement period, or timing budget
+	expected := time.Duration(v.sensor.measurementTimingBudgetUsec) * time.Microsecond
+	if v.period > expected {
+		expected = v.period
+	}
+	timeout := v.sensor.ioTimeout
+	v.sensor.SetTimeout(expected * time.Duration(v.factor))
+	defer v.sensor.SetTimeout(timeout)
+
+	err := v.sensor.StartContinuousDuration(v.i2c, v.period)
+	if err != nil {
+		return err
+	}
+	defer v.sensor.stopContinuousQuietly(v.i2c)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		m, err := v.sensor.ReadMeasurementContinuous(v.i2c)
+		if err == nil {
+			handler(m)
+			continue
+		}
+		if errors.Is(err, ErrLimitReached) {
+			return nil
+		}
+		warnf("Watchdog detects stalled sensor: %s", err)
+		event := RecoveryEvent{Time: time.Now(), Cause: err}
+		event.Err = v.recover(timeout)
+		if event.Err != nil {
+			errorf("Watchdog recovery failed: %s", event.Err)
+		} else {
+			lg.Info("Watchdog recovery succeeded")
+		}
+		if v.onRecovery != nil {
+			v.onRecovery(event)
+		}
+		if event.Err != nil {
+			// give sensor some time before next attempt
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(expected * time.Duration(v.factor)):
+			}
+		}
+	}
+}
+
+// Stop ranging, re-initialize sensor and restart continuous mode.
+// Initialization uses regular timeout, rather than watchdog one.
+func (v *Watchdog) recover(timeout time.Duration) error {
+	watchdogTimeout := v.sensor.ioTimeout
+	v.sensor.SetTimeout(timeout)
+	defer v.sensor.SetTimeout(watchdogTimeout)
+
+	err := v.sensor.StopContinuous(v.i2c)
+	if err != nil {
+		debugf("Error stopping continuous measures: %s", err)
+	}
+	// stalled sensor usually isn't power-cycled, so try fast path first
+	err = v.sensor.ReInit(v.i2c)
+	if err != nil {
+		debugf("Fast re-initialization failed: %s", err)
+		err = v.sensor.reinit(v.i2c)
+		if err != nil {
+			return err
+		}
+	}
+	return v.sensor.restartContinuous(v.i2c, uint32(v.period/time.Millisecond))
+}
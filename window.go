@@ -0,0 +1,101 @@
+package vl53l0x
+
+import (
+	"time"
+)
+
+// WindowSummary keeps statistics of measurements of single time window.
+type WindowSummary struct {
+	// window boundaries, aligned to wall clock
+	Start time.Time
+	End   time.Time
+	StatsSnapshot
+}
+
+// WindowAggregator summarizes measurements per fixed wall-clock window,
+// aligned to the clock (10 s windows start at :00, :10, :20, etc),
+// for feeding time-series databases at low resolution:
+//
+//	agg := vl53l0x.NewWindowAggregator(10 * time.Second)
+//	for s := range agg.Run(ch) {
+//		// write s.Start, s.Min, s.Max, s.Mean, s.ValidRatio
+//	}
+//
+// Windows without measurements produce no summary.
+type WindowAggregator struct {
+	window time.Duration
+	// start of current window; zero, if no measurements collected
+	start time.Time
+	stats *Stats
+}
+
+// NewWindowAggregator creates aggregator with window duration given.
+func NewWindowAggregator(window time.Duration) *WindowAggregator {
+	if window <= 0 {
+		window = time.Second
+	}
+	v := &WindowAggregator{window: window, stats: NewStats()}
+	return v
+}
+
+// Add measurement and returns summary of the previous window,
+// once measurement belongs to the next one.
+func (v *WindowAggregator) Add(m Measurement) (WindowSummary, bool) {
+	ts := m.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	s, ok := v.Flush(ts)
+	if v.start.IsZero() {
+		v.start = ts.Truncate(v.window)
+	}
+	v.stats.Add(m)
+	return s, ok
+}
+
+// Flush returns summary of current window, if it's over by time given.
+func (v *WindowAggregator) Flush(now time.Time) (WindowSummary, bool) {
+	if v.start.IsZero() || now.Before(v.start.Add(v.window)) {
+		return WindowSummary{}, false
+	}
+	s := WindowSummary{Start: v.start, End: v.start.Add(v.window),
+		StatsSnapshot: v.stats.Snapshot()}
+	v.start = time.Time{}
+	v.stats.Reset()
+	return s, true
+}
+
+// Run attach aggregator to measurement stream, returning channel
+// of summaries. Window is closed by timer, even if stream stalls.
+// Summary channel is closed, once measurement stream is closed;
+// incomplete window is dropped then.
+func (v *WindowAggregator) Run(in <-chan Measurement) <-chan WindowSummary {
+	out := make(chan WindowSummary, 1)
+	go func() {
+		defer close(out)
+		timer := time.NewTimer(v.window)
+		defer timer.Stop()
+		for {
+			select {
+			case m, ok := <-in:
+				if !ok {
+					return
+				}
+				if s, ok := v.Add(m); ok {
+					out <- s
+				}
+			case now := <-timer.C:
+				if s, ok := v.Flush(now); ok {
+					out <- s
+				}
+			}
+			// wake up right after current window end
+			wait := v.window
+			if !v.start.IsZero() {
+				wait = time.Until(v.start.Add(v.window))
+			}
+			timer.Reset(max(wait, 0))
+		}
+	}()
+	return out
+}
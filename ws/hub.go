@@ -0,0 +1,148 @@
+// Package ws pushes VL53L0X measurements to browser dashboards over
+// WebSocket as JSON messages.
+package ws
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	vl53l0x "github.com/d2r2/go-vl53l0x"
+	"github.com/gorilla/websocket"
+)
+
+// Time allowed to write message to client.
+const writeTimeout = time.Second * 5
+
+// Hub is an http.Handler upgrading requests to WebSocket and pushing
+// measurements published to every connected client. Each client could
+// request subsampling with query parameters "interval" (minimal duration
+// between messages, for instance "?interval=200ms") and "every" (send each
+// N-th measurement). Client keeps only the latest pending measurement,
+// so slow clients skip measurements instead of stalling acquisition.
+type Hub struct {
+	sync.Mutex
+	upgrader websocket.Upgrader
+	clients  map[*client]struct{}
+}
+
+// Connected client.
+type client struct {
+	conn     *websocket.Conn
+	pending  chan vl53l0x.Measurement
+	interval time.Duration
+	every    int
+	// subsampling state, accessed under hub lock
+	count int
+	last  time.Time
+}
+
+// NewHub creates WebSocket hub. CheckOrigin of upgrader allows
+// any origin, replace it with SetCheckOrigin if required.
+func NewHub() *Hub {
+	v := &Hub{clients: make(map[*client]struct{})}
+	v.upgrader.CheckOrigin = func(r *http.Request) bool { return true }
+	return v
+}
+
+// SetCheckOrigin define request origin check.
+func (v *Hub) SetCheckOrigin(check func(r *http.Request) bool) {
+	v.upgrader.CheckOrigin = check
+}
+
+// Clients returns number of connected clients.
+func (v *Hub) Clients() int {
+	v.Lock()
+	defer v.Unlock()
+	return len(v.clients)
+}
+
+// Publish measurement to connected clients. It never blocks.
+func (v *Hub) Publish(m vl53l0x.Measurement) {
+	v.Lock()
+	defer v.Unlock()
+	for c := range v.clients {
+		c.count++
+		if c.every > 1 && c.count%c.every != 0 {
+			continue
+		}
+		if c.interval > 0 && m.Timestamp.Sub(c.last) < c.interval {
+			continue
+		}
+		c.last = m.Timestamp
+		// replace pending measurement, if client is slow
+		select {
+		case <-c.pending:
+		default:
+		}
+		c.pending <- m
+	}
+}
+
+// Run publish measurements from stream, for instance one returned
+// by Vl53l0x.Stream, until it's closed.
+func (v *Hub) Run(in <-chan vl53l0x.Measurement) {
+	for m := range in {
+		v.Publish(m)
+	}
+}
+
+// ServeHTTP implement http.Handler interface.
+func (v *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c := &client{pending: make(chan vl53l0x.Measurement, 1)}
+	q := r.URL.Query()
+	if s := q.Get("interval"); s != "" {
+		interval, err := time.ParseDuration(s)
+		if err != nil {
+			http.Error(w, "bad interval: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.interval = interval
+	}
+	if s := q.Get("every"); s != "" {
+		every, err := strconv.Atoi(s)
+		if err != nil || every < 1 {
+			http.Error(w, "bad every: "+s, http.StatusBadRequest)
+			return
+		}
+		c.every = every
+	}
+	conn, err := v.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// upgrader already replied with error
+		return
+	}
+	c.conn = conn
+	v.Lock()
+	v.clients[c] = struct{}{}
+	v.Unlock()
+	defer func() {
+		v.Lock()
+		delete(v.clients, c)
+		v.Unlock()
+		conn.Close()
+	}()
+
+	// detect client disconnection; incoming messages are ignored
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-closed:
+			return
+		case m := <-c.pending:
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(m); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package vl53l0x
+
+import (
+	"errors"
+	"time"
+)
+
+// Sensor boot duration after XSHUT release (tBOOT from datasheet is 1.2 ms max).
+const defaultBootTime = time.Millisecond * 2
+
+// Value of IDENTIFICATION_MODEL_ID register, read from VL53L0X.
+const modelIdVl53l0x = 0xEE
+
+// XShutController drives sensor XSHUT (hardware shutdown) pin, which is used
+// to reset sensor by power cycling, and to bring up several sensors sharing
+// I2C-bus one by one for address assignment. Pin is driven by SetLevel func,
+// which could be provided by "gpio" subpackage (Linux GPIO character device),
+// or by user, when pin connected via expander or something else.
+type XShutController struct {
+	setLevel func(high bool) error
+	bootTime time.Duration
+}
+
+// NewXShutController creates XSHUT pin controller, where setLevel drives
+// pin high (sensor active) or low (sensor in shutdown).
+func NewXShutController(setLevel func(high bool) error) *XShutController {
+	v := &XShutController{setLevel: setLevel, bootTime: defaultBootTime}
+	return v
+}
+
+// SetBootTime change time to wait after power on, before sensor is
+// available over I2C-bus. Default is 2 ms.
+func (v *XShutController) SetBootTime(bootTime time.Duration) {
+	v.bootTime = bootTime
+}
+
+// PowerOff put sensor to hardware standby, pulling XSHUT low.
+// All sensor settings including I2C address are lost.
+func (v *XShutController) PowerOff() error {
+	debug("XSHUT low")
+	return v.setLevel(false)
+}
+
+// PowerOn release XSHUT and wait for sensor boot. After that sensor
+// answers on default address 0x29 and should be initialized again.
+func (v *XShutController) PowerOn() error {
+	debug("XSHUT high")
+	err := v.setLevel(true)
+	if err != nil {
+		return err
+	}
+	time.Sleep(v.bootTime)
+	return nil
+}
+
+// PowerCycle power off sensor, hold it in standby for some time and power on.
+func (v *XShutController) PowerCycle(offTime time.Duration) error {
+	err := v.PowerOff()
+	if err != nil {
+		return err
+	}
+	time.Sleep(offTime)
+	return v.PowerOn()
+}
+
+// WaitBoot wait until sensor answers over I2C-bus with valid model id,
+// or timeout expired. Useful after PowerOn, when boot time is uncertain.
+func (v *XShutController) WaitBoot(i2c Bus, timeout time.Duration) error {
+	return v.waitBoot(i2c, timeout, func(i2c Bus) (bool, error) {
+		u8, err := i2c.ReadRegU8(IDENTIFICATION_MODEL_ID)
+		return err == nil && u8 == modelIdVl53l0x, err
+	})
+}
+
+// Wait until booted reports sensor is ready, or timeout expired.
+func (v *XShutController) waitBoot(i2c Bus, timeout time.Duration,
+	booted func(i2c Bus) (bool, error)) error {
+
+	st := time.Now()
+	for {
+		// Ignore errors for a while, since sensor in boot
+		// doesn't answer on I2C-bus.
+		ok, err := booted(i2c)
+		if ok {
+			return nil
+		}
+		if time.Since(st) > timeout {
+			if err != nil {
+				return err
+			}
+			return errors.New("timeout occurs waiting for sensor boot")
+		}
+		time.Sleep(v.bootTime)
+	}
+}